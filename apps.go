@@ -116,16 +116,21 @@ func (a *appsCmd) list(c *cli.Context) error {
 		}
 	}
 
-	if len(resp.Payload.Apps) == 0 {
-		fmt.Println("no apps found")
-		return nil
-	}
-
-	for _, app := range resp.Payload.Apps {
-		fmt.Println(app.Name)
+	apps := resp.Payload.Apps
+	if apps == nil {
+		apps = []*models.App{}
 	}
+	return render(c, apps, func() error {
+		if len(apps) == 0 {
+			fmt.Println("no apps found")
+			return nil
+		}
 
-	return nil
+		for _, app := range apps {
+			fmt.Println(app.Name)
+		}
+		return nil
+	})
 }
 
 func (a *appsCmd) create(c *cli.Context) error {
@@ -260,8 +265,7 @@ func (a *appsCmd) inspect(c *cli.Context) error {
 	enc.SetIndent("", "\t")
 
 	if prop == "" {
-		enc.Encode(resp.Payload.App)
-		return nil
+		return render(c, resp.Payload.App, func() error { return enc.Encode(resp.Payload.App) })
 	}
 
 	// TODO: we really need to marshal it here just to
@@ -281,9 +285,7 @@ func (a *appsCmd) inspect(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to inspect field %v", prop)
 	}
-	enc.Encode(field)
-
-	return nil
+	return render(c, field, func() error { return enc.Encode(field) })
 }
 
 func (a *appsCmd) delete(c *cli.Context) error {
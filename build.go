@@ -1,16 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
+	"github.com/fnproject/cli/langs"
 	"github.com/urfave/cli"
 )
 
 func build() cli.Command {
 	cmd := buildcmd{}
 	flags := append([]cli.Flag{}, cmd.flags()...)
+	flags = append(flags, proxyFlags...)
+	flags = append(flags, sbomFlag)
+	flags = append(flags, builderFlags...)
 	return cli.Command{
 		Name:   "build",
 		Usage:  "build function version",
@@ -20,8 +26,12 @@ func build() cli.Command {
 }
 
 type buildcmd struct {
-	verbose bool
-	noCache bool
+	verbose       bool
+	noCache       bool
+	noDocker      bool
+	force         bool
+	cacheAnalysis bool
+	platform      string
 }
 
 func (b *buildcmd) flags() []cli.Flag {
@@ -36,29 +46,155 @@ func (b *buildcmd) flags() []cli.Flag {
 			Usage:       "Don't use docker cache",
 			Destination: &b.noCache,
 		},
+		cli.BoolFlag{
+			Name:        "force",
+			Usage:       "build even if the sources and Dockerfile haven't changed since the image was built",
+			Destination: &b.force,
+		},
+		cli.BoolFlag{
+			Name:        "no-docker",
+			Usage:       "assemble the image into an OCI tarball without Docker, for functions with nothing to install",
+			Destination: &b.noDocker,
+		},
+		cli.BoolFlag{
+			Name:        "cache-analysis",
+			Usage:       "write a Dockerfile running each build step in a stage of its own, to see which layers rebuild, instead of building",
+			Destination: &b.cacheAnalysis,
+		},
+		cli.StringFlag{
+			Name:        "platform",
+			Usage:       "platforms to build the image for with docker buildx, eg: linux/amd64,linux/arm64",
+			Destination: &b.platform,
+		},
 	}
 }
 
-// build will take the found valid function and build it
+// build will take the found valid function and build it, reporting the image it built with --output json or yaml
 func (b *buildcmd) build(c *cli.Context) error {
-	path, err := os.Getwd()
+	if b.cacheAnalysis {
+		return writeCacheAnalysis()
+	}
+	if !structuredOutput(c) {
+		_, _, _, err := b.buildImage(c)
+		return err
+	}
+
+	restore := quietStdout()
+	fpath, ff, tarball, err := b.buildImage(c)
+	restore()
 	if err != nil {
 		return err
 	}
+	var result *buildResult
+	if tarball != "" {
+		result = &buildResult{Image: ff.ImageName(), Tarball: tarball}
+		if info, err := os.Stat(tarball); err == nil {
+			result.Size = info.Size()
+		}
+	} else if remoteBuild() {
+		// the image is in the registry alone, with the digest it was pushed with
+		result = &buildResult{Image: ff.ImageName(), Digest: builtDigest(fpath, ff.ImageName())}
+	} else if result, err = inspectImage(ff.ImageName()); err != nil {
+		return err
+	}
+	return render(c, result, nil)
+}
+
+// buildImage builds the function, returning the path and contents of its func.yaml and, for builds without Docker,
+// the tarball of its image.
+func (b *buildcmd) buildImage(c *cli.Context) (string, *funcfile, string, error) {
+	if b.platform != "" {
+		os.Setenv("FN_PLATFORM", b.platform)
+	}
+	setProxyEnv(c)
+	if err := checkSignFlags(c, false); err != nil {
+		return "", nil, "", err
+	}
+	if err := setBuilderEnv(c); err != nil {
+		return "", nil, "", err
+	}
+	if remoteBuild() && (b.noDocker || c.String("sbom") != "") {
+		return "", nil, "", errors.New("--no-docker and --sbom build locally, they can't be used with --builder remote")
+	}
+	path, err := os.Getwd()
+	if err != nil {
+		return "", nil, "", err
+	}
 	fpath, ff, err := findAndParseFuncfile(path)
 	if err != nil {
-		return err
+		return "", nil, "", err
 	}
 	// get name from directory if it's not defined
 	if ff.Name == "" {
 		ff.Name = filepath.Base(filepath.Dir(fpath)) // todo: should probably make a copy of ff before changing it
 	}
 
-	ff, err = buildfunc(fpath, ff, b.noCache)
+	if b.noDocker {
+		if c.String("sbom") != "" {
+			return "", nil, "", errors.New("--sbom reads the image from Docker, it can't be used with --no-docker")
+		}
+		tarball, err := dockerlessBuild(fpath, ff)
+		if err != nil {
+			return "", nil, "", err
+		}
+		fmt.Printf("Function image written to %v, load it with docker load or push it with skopeo.\n", tarball)
+		return fpath, ff, tarball, nil
+	}
+
+	ff, err = buildfunc(fpath, ff, b.noCache, false, b.force)
 	if err != nil {
-		return err
+		return "", nil, "", err
 	}
 
 	fmt.Printf("Function %v built successfully.\n", ff.ImageName())
+
+	if format := c.String("sbom"); format != "" {
+		engine, err := langs.Engine()
+		if err != nil {
+			return "", nil, "", err
+		}
+		source, err := langs.SBOMSource(engine, ff.ImageName())
+		if err != nil {
+			return "", nil, "", err
+		}
+		out := sbomFile(fpath, format)
+		if err := generateSBOM(fpath, ff, source, format, out, os.Stderr); err != nil {
+			return "", nil, "", err
+		}
+		fmt.Printf("SBOM written to %v\n", out)
+	}
+	return fpath, ff, "", nil
+}
+
+// cacheAnalysisFile is the Dockerfile fn build --cache-analysis writes next to func.yaml
+const cacheAnalysisFile = "Dockerfile.cache-analysis"
+
+// writeCacheAnalysis writes the cache analysis Dockerfile of the function's runtime, see
+// langs.LangHelper.CacheAnalysisDockerfile.
+func writeCacheAnalysis() error {
+	path, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	fpath, ff, err := findAndParseFuncfile(path)
+	if err != nil {
+		return err
+	}
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil {
+		return err
+	}
+	if _, err := langs.ResolveImages(helper, ff.Runtime, ff.BuildImage, ff.RunImage); err != nil {
+		return err
+	}
+	dockerfile := helper.CacheAnalysisDockerfile()
+	if dockerfile == "" {
+		return fmt.Errorf("the %s runtime has no cache analysis Dockerfile", ff.Runtime)
+	}
+	out := filepath.Join(filepath.Dir(fpath), cacheAnalysisFile)
+	if err := ioutil.WriteFile(out, []byte(dockerfile), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Cache analysis Dockerfile written to %v, build it with: docker build --progress=plain -f %v .\n", out, cacheAnalysisFile)
 	return nil
 }
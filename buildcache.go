@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fnproject/cli/langs"
+)
+
+// buildCacheFile returns where the inputs of the last build of each function are recorded, keyed by the path of
+// its func.yaml. It is a variable so tests can move it.
+var buildCacheFile = func() string {
+	return langs.StateFile("build-cache.json")
+}
+
+// buildCacheEntry is the image a function was last built into and the hash of what it was built from. Remote
+// builds record the digest of the image they pushed, which fn deploy pins the route to.
+type buildCacheEntry struct {
+	Image  string `json:"image"`
+	Hash   string `json:"hash"`
+	Digest string `json:"digest,omitempty"`
+}
+
+func readBuildCache() map[string]buildCacheEntry {
+	cache := map[string]buildCacheEntry{}
+	if b, err := ioutil.ReadFile(buildCacheFile()); err == nil {
+		json.Unmarshal(b, &cache)
+	}
+	return cache
+}
+
+// buildInputsHash hashes what goes into the image: the sources, the Dockerfile and anything else that changes the
+// build, such as the platforms
+func buildInputsHash(sources string, dockerfile []byte, extra ...string) string {
+	h := sha256.New()
+	io.WriteString(h, sources+"\x00")
+	h.Write(dockerfile)
+	for _, e := range extra {
+		io.WriteString(h, "\x00"+e)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildUpToDate reports whether the function at fpath was last built into image from the same inputs, and the
+// image is still there.
+func buildUpToDate(engine langs.ContainerEngine, fpath, image, hash string) bool {
+	entry, ok := readBuildCache()[fpath]
+	if !ok || entry.Image != image || entry.Hash != hash {
+		return false
+	}
+	return engine.Command("image", "inspect", image).Run() == nil
+}
+
+// remoteBuildUpToDate reports whether the function at fpath was last built remotely into image from the same inputs.
+func remoteBuildUpToDate(fpath, image, hash string) bool {
+	entry, ok := readBuildCache()[fpath]
+	return ok && entry.Image == image && entry.Hash == hash && entry.Digest != ""
+}
+
+// builtDigest returns the digest image was pushed with by the remote build that last built the function at fpath
+// into it, if any
+func builtDigest(fpath, image string) string {
+	if entry, ok := readBuildCache()[fpath]; ok && entry.Image == image {
+		return entry.Digest
+	}
+	return ""
+}
+
+// recordBuild saves the inputs image was built from, and its digest for remote builds, on a best effort basis as it
+// only spares rebuilds
+func recordBuild(fpath, image, hash, digest string) {
+	path := buildCacheFile()
+	if path == "" {
+		return
+	}
+	cache := readBuildCache()
+	cache[fpath] = buildCacheEntry{Image: image, Hash: hash, Digest: digest}
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil || os.MkdirAll(filepath.Dir(path), os.FileMode(0755)) != nil {
+		return
+	}
+	ioutil.WriteFile(path, b, os.FileMode(0644))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordBuild(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "build-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(f func() string) { buildCacheFile = f }(buildCacheFile)
+	buildCacheFile = func() string { return filepath.Join(tmp, "build-cache.json") }
+
+	hash := buildInputsHash("sha-0123456789ab", []byte("FROM funcy/node\n"), "linux/arm64")
+	if hash == buildInputsHash("sha-0123456789ab", []byte("FROM funcy/node\n")) {
+		t.Error("expected the platforms to change the hash")
+	}
+	recordBuild("/fns/hello/func.yaml", "fn/hello:0.0.2", hash, "")
+	if entry := readBuildCache()["/fns/hello/func.yaml"]; entry.Image != "fn/hello:0.0.2" || entry.Hash != hash {
+		t.Errorf("expected the build to be recorded, got %+v", entry)
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "build-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(f func() string) { buildCacheFile = f }(buildCacheFile)
+	buildCacheFile = func() string { return filepath.Join(tmp, "build-cache.json") }
+
+	recordBuild("/fns/hello/func.yaml", "registry.example.com/hello:0.0.3", "hash", "sha256:abc")
+	if !remoteBuildUpToDate("/fns/hello/func.yaml", "registry.example.com/hello:0.0.3", "hash") {
+		t.Error("expected the remote build to be up to date")
+	}
+	if image := pinnedImage("/fns/hello/func.yaml", "registry.example.com/hello:0.0.3"); image != "registry.example.com/hello:0.0.3@sha256:abc" {
+		t.Errorf("expected the image pinned to its digest, got %s", image)
+	}
+	if image := pinnedImage("/fns/other/func.yaml", "registry.example.com/other:0.0.1"); image != "registry.example.com/other:0.0.1" {
+		t.Errorf("expected images built locally to be left as they are, got %s", image)
+	}
+	// a function building into the same image isn't pinned to the digest of the other
+	if image := pinnedImage("/fns/copy/func.yaml", "registry.example.com/hello:0.0.3"); image != "registry.example.com/hello:0.0.3" {
+		t.Errorf("expected the digest to be looked up by func.yaml, got %s", image)
+	}
+}
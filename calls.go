@@ -72,8 +72,10 @@ func (call *callsCmd) get(ctx *cli.Context) error {
 			return err
 		}
 	}
-	printCalls([]*models.Call{resp.Payload.Call})
-	return nil
+	return render(ctx, resp.Payload.Call, func() error {
+		printCalls([]*models.Call{resp.Payload.Call})
+		return nil
+	})
 }
 
 func (call *callsCmd) list(ctx *cli.Context) error {
@@ -95,6 +97,12 @@ func (call *callsCmd) list(ctx *cli.Context) error {
 			return err
 		}
 	}
-	printCalls(resp.Payload.Calls)
-	return nil
+	calls := resp.Payload.Calls
+	if calls == nil {
+		calls = []*models.Call{}
+	}
+	return render(ctx, calls, func() error {
+		printCalls(calls)
+		return nil
+	})
 }
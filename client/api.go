@@ -1,9 +1,11 @@
 package client
 
 import (
+	"io"
 	"os"
 
 	"log"
+	"net/http"
 	"net/url"
 
 	fnclient "github.com/funcy/functions_go/client"
@@ -21,17 +23,39 @@ func Host() string {
 		apiURL = "http://localhost:8080"
 	}
 
-	u, err := url.Parse(apiURL)
+	host, err := HostOf(apiURL)
 	if err != nil {
 		log.Fatalln("Couldn't parse API URL:", err)
 	}
-	return u.Host
+	return host
+}
+
+// HostOf returns the host of a functions server URL, such as http://localhost:8080.
+func HostOf(apiURL string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Host, nil
 }
 
 func APIClient() *fnclient.Functions {
-	transport := httptransport.New(Host(), "/v1", []string{"http"})
-	if os.Getenv(envFnToken) != "" {
-		transport.DefaultAuthentication = httptransport.BearerToken(os.Getenv(envFnToken))
+	return NewAPIClient(Host(), os.Getenv(envFnToken))
+}
+
+// NewAPIClient returns a client of the functions server at host, authenticating with token unless it is empty.
+func NewAPIClient(host, token string) *fnclient.Functions {
+	return NewAPIClientTracingTo(host, token, nil)
+}
+
+// NewAPIClientTracingTo is NewAPIClient, writing the --debug-http traces to debugOut unless it is nil.
+func NewAPIClientTracingTo(host, token string, debugOut io.Writer) *fnclient.Functions {
+	transport := httptransport.New(host, "/v1", []string{"http"})
+	api := NewTransport(http.DefaultTransport)
+	api.DebugOut = debugOut
+	transport.Transport = api
+	if token != "" {
+		transport.DefaultAuthentication = httptransport.BearerToken(token)
 	}
 
 	// create the API client, with the transport
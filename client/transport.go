@@ -0,0 +1,448 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The environment variables configuring the requests to the API. --debug-http sets FN_DEBUG_HTTP.
+const (
+	envRetries   = "FN_API_RETRIES"
+	envTimeout   = "FN_API_TIMEOUT"
+	envDebugHTTP = "FN_DEBUG_HTTP"
+)
+
+// RequestIDHeader is the header requests carry the ID they are traced by, unless the server sends its own back
+const RequestIDHeader = "X-Request-Id"
+
+// requestIDHeaders are the response headers servers and load balancers report request IDs in
+var requestIDHeaders = []string{RequestIDHeader, "Fn-Request-Id", "Opc-Request-Id", "X-Amzn-Requestid"}
+
+// sensitiveHeaders are redacted from the traces of --debug-http
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Auth-Token":        true,
+}
+
+// sensitiveKeys are the words of the JSON keys that look like they hold credentials
+const sensitiveKeys = `(?i:token|secret|password|passwd|api_?key|credential)`
+
+var sensitiveJSONKey = regexp.MustCompile(sensitiveKeys)
+
+// sensitiveJSONValue matches the string values of JSON keys that look like they hold credentials, for bodies that
+// don't parse
+var sensitiveJSONValue = regexp.MustCompile(`("[^"]*` + sensitiveKeys + `[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactedJSONObjects are the JSON objects all of whose values are redacted, whatever their keys: the config and
+// headers of apps and routes are where functions get their secrets from.
+var redactedJSONObjects = map[string]bool{"config": true, "headers": true}
+
+// maxTraceBody is how much of a body --debug-http traces
+const maxTraceBody = 64 << 10
+
+// TransportConfig is how requests to the API are retried, timed out and traced.
+type TransportConfig struct {
+	// Retries is how many times a request is retried after a network error, a 429 or a 5xx status. Requests that
+	// aren't idempotent are only retried when the server turned them away, with 429 or 503.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each of the next up to MaxBackoff. Each delay is
+	// jittered down by up to half, and a Retry-After of the server takes precedence.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds each attempt, 0 leaves them unbounded.
+	Timeout time.Duration
+	// Debug writes sanitized traces of the requests and responses to DebugOut.
+	Debug    bool
+	DebugOut io.Writer
+}
+
+// LoadTransportConfig returns the transport settings of FN_API_RETRIES, FN_API_TIMEOUT, such as 30s, and
+// FN_DEBUG_HTTP.
+func LoadTransportConfig() (TransportConfig, error) {
+	cfg := TransportConfig{
+		Retries:    3,
+		Backoff:    500 * time.Millisecond,
+		MaxBackoff: 10 * time.Second,
+		Timeout:    30 * time.Second,
+		DebugOut:   os.Stderr,
+	}
+	if s := os.Getenv(envRetries); s != "" {
+		retries, err := strconv.Atoi(s)
+		if err != nil || retries < 0 {
+			return cfg, fmt.Errorf("%s must be a number of retries, got %q", envRetries, s)
+		}
+		cfg.Retries = retries
+	}
+	if s := os.Getenv(envTimeout); s != "" {
+		timeout, err := time.ParseDuration(s)
+		if err != nil || timeout < 0 {
+			return cfg, fmt.Errorf("%s must be a duration, such as 30s, got %q", envTimeout, s)
+		}
+		cfg.Timeout = timeout
+	}
+	cfg.Debug, _ = strconv.ParseBool(os.Getenv(envDebugHTTP))
+	return cfg, nil
+}
+
+// APIError is a request to the API that failed for good: it got no response, or a 5xx one, after its retries.
+type APIError struct {
+	Method string
+	URL    string
+	// StatusCode and Status are those of the last response, empty if there was none.
+	StatusCode int
+	Status     string
+	// Message is the error the server described, if it did.
+	Message   string
+	RequestID string
+	Attempts  int
+	// Err is the network error of the last attempt, if it got no response.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.Err != nil {
+		b.WriteString(e.Err.Error())
+	} else {
+		b.WriteString(e.Status)
+	}
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, ", after %d attempts", e.Attempts)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// Transport is the middleware of the requests to the API: it gives each an ID, retries them with backoff, times out
+// each attempt and traces them for --debug-http. It reads its TransportConfig from the environment at each request,
+// the flags setting it are parsed after the clients are created.
+type Transport struct {
+	Next http.RoundTripper
+	// Config overrides the environment, if set.
+	Config *TransportConfig
+	// DebugOut, if set, is where the traces go in place of the DebugOut of the config.
+	DebugOut io.Writer
+	// sleep waits between attempts, sleepContext unless tests set it.
+	sleep func(context.Context, time.Duration) error
+}
+
+// NewTransport returns the middleware sending requests with next.
+func NewTransport(next http.RoundTripper) *Transport {
+	return &Transport{Next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := TransportConfig{}
+	if t.Config != nil {
+		cfg = *t.Config
+	} else {
+		var err error
+		if cfg, err = LoadTransportConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if t.DebugOut != nil {
+		cfg.DebugOut = t.DebugOut
+	}
+	// the body is replayed for each attempt
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	requestID := req.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(req, body, requestID, attempt, cfg)
+		retry, wait := retryable(req.Method, resp, err)
+		if !retry && err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if !retry || attempt > cfg.Retries {
+			return nil, failure(req, resp, err, requestID, attempt)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if wait == 0 {
+			wait = backoff(cfg, attempt)
+		}
+		if cfg.Debug {
+			fmt.Fprintf(cfg.DebugOut, "* retrying in %s\n", wait.Round(time.Millisecond))
+		}
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, failure(req, nil, err, requestID, attempt)
+		}
+	}
+}
+
+// attempt sends req once, with body, within the timeout of an attempt
+func (t *Transport) attempt(req *http.Request, body []byte, requestID string, attempt int, cfg TransportConfig) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+	}
+	r := req.Clone(ctx)
+	r.Header.Set(RequestIDHeader, requestID)
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+	if cfg.Debug {
+		traceRequest(cfg.DebugOut, r, body, requestID, attempt)
+	}
+	start := time.Now()
+	resp, err := t.Next.RoundTrip(r)
+	if err != nil {
+		cancel()
+		if cfg.Debug {
+			fmt.Fprintf(cfg.DebugOut, "< error after %s: %v\n\n", time.Since(start).Round(time.Millisecond), err)
+		}
+		return nil, err
+	}
+	// the attempt lasts until its body is read
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	if cfg.Debug {
+		if err := traceResponse(cfg.DebugOut, resp, time.Since(start)); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// cancelBody ends the context of an attempt once its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryable reports whether an attempt that got resp, or err, is worth retrying, and how long the server asked to
+// wait before doing so, if it did
+func retryable(method string, resp *http.Response, err error) (bool, time.Duration) {
+	idempotent := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions ||
+		method == http.MethodPut || method == http.MethodDelete
+	if err != nil {
+		// a canceled request is not retried, the attempt timing out is
+		return idempotent && err != context.Canceled, 0
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return true, retryAfter(resp.Header.Get("Retry-After"))
+	case resp.StatusCode >= 500:
+		return idempotent, 0
+	}
+	return false, 0
+}
+
+// retryAfter returns the delay of a Retry-After header in seconds, 0 for others
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// backoff returns the delay before the retry following attempt: exponential, capped, and jittered down by up to
+// half so clients retrying together spread out
+func backoff(cfg TransportConfig, attempt int) time.Duration {
+	delay := float64(cfg.Backoff) * math.Pow(2, float64(attempt-1))
+	if max := float64(cfg.MaxBackoff); max > 0 && delay > max {
+		delay = max
+	}
+	return time.Duration(delay/2 + mathrand.Float64()*delay/2)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// failure returns the APIError of a request whose last attempt got resp, or err
+func failure(req *http.Request, resp *http.Response, err error, requestID string, attempts int) error {
+	e := &APIError{Method: req.Method, URL: req.URL.String(), RequestID: requestID, Attempts: attempts, Err: err}
+	if resp != nil {
+		defer resp.Body.Close()
+		e.StatusCode, e.Status = resp.StatusCode, resp.Status
+		for _, h := range requestIDHeaders {
+			if id := resp.Header.Get(h); id != "" {
+				e.RequestID = id
+				break
+			}
+		}
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxTraceBody))
+		e.Message = errorMessage(b)
+	}
+	return e
+}
+
+// errorMessage returns the message of an error body of the API, {"error": {"message": ...}}, or the body itself
+// if it is short text
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		return apiErr.Error.Message
+	}
+	if s := strings.TrimSpace(string(body)); len(s) <= 200 && !strings.ContainsAny(s, "<{") {
+		return s
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+func traceRequest(w io.Writer, req *http.Request, body []byte, requestID string, attempt int) {
+	fmt.Fprintf(w, "> %s %s (request id %s, attempt %d)\n", req.Method, req.URL, requestID, attempt)
+	traceHeaders(w, ">", req.Header)
+	traceBody(w, ">", body)
+}
+
+// traceResponse traces resp, reading its body and putting it back
+func traceResponse(w io.Writer, resp *http.Response, elapsed time.Duration) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(w, "< %s %s (%s)\n", resp.Proto, resp.Status, elapsed.Round(time.Millisecond))
+	traceHeaders(w, "<", resp.Header)
+	traceBody(w, "<", body)
+	return nil
+}
+
+func traceHeaders(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = "REDACTED"
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", prefix, name, value)
+	}
+}
+
+func traceBody(w io.Writer, prefix string, body []byte) {
+	fmt.Fprintln(w, prefix)
+	if len(body) == 0 {
+		fmt.Fprintln(w)
+		return
+	}
+	// sanitized before it is truncated, for JSON to parse
+	sanitized := SanitizeBody(string(body))
+	truncated := len(sanitized) > maxTraceBody
+	if truncated {
+		sanitized = sanitized[:maxTraceBody]
+	}
+	for _, line := range strings.Split(sanitized, "\n") {
+		fmt.Fprintf(w, "%s %s\n", prefix, line)
+	}
+	if truncated {
+		fmt.Fprintf(w, "%s ... truncated\n", prefix)
+	}
+	fmt.Fprintln(w)
+}
+
+// SanitizeBody redacts the values of the JSON keys of body that look like credentials, such as tokens and passwords,
+// and all the values of its config and headers objects. Bodies that aren't JSON only have the string values of the
+// keys that look like credentials redacted.
+func SanitizeBody(body string) string {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return sensitiveJSONValue.ReplaceAllString(body, `${1}"REDACTED"`)
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(redactJSON(v)); err != nil {
+		return sensitiveJSONValue.ReplaceAllString(body, `${1}"REDACTED"`)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// redactJSON redacts the values of v, a decoded JSON document, SanitizeBody redacts
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if object, ok := value.(map[string]interface{}); ok && redactedJSONObjects[strings.ToLower(key)] {
+				for name := range object {
+					object[name] = "REDACTED"
+				}
+			} else if sensitiveJSONKey.MatchString(key) && value != nil {
+				v[key] = "REDACTED"
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}
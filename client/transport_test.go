@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTransport(cfg TransportConfig) (*Transport, *[]time.Duration) {
+	var waits []time.Duration
+	t := NewTransport(http.DefaultTransport)
+	t.Config = &cfg
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return t, &waits
+}
+
+func TestTransportRetries(t *testing.T) {
+	var bodies []string
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		ids = append(ids, r.Header.Get(RequestIDHeader))
+		if len(bodies) < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport, waits := testTransport(TransportConfig{Retries: 3, Backoff: time.Second})
+	resp, err := (&http.Client{Transport: transport}).Post(server.URL, "application/json", strings.NewReader(`{"app": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != "ok" {
+		t.Errorf("expected the response of the third attempt, got %q", b)
+	}
+	if len(bodies) != 3 || bodies[2] != `{"app": {}}` {
+		t.Errorf("expected the body sent with each attempt, got %q", bodies)
+	}
+	if ids[0] == "" || ids[0] != ids[2] {
+		t.Errorf("expected the attempts to share a request id, got %q", ids)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 2*time.Second {
+		t.Errorf("expected the Retry-After of the server to be waited, got %v", *waits)
+	}
+}
+
+func TestTransportFailure(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Fn-Request-Id", "req-42")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": {"message": "database unavailable"}}`))
+	}))
+	defer server.Close()
+
+	transport, _ := testTransport(TransportConfig{Retries: 2, Backoff: time.Second})
+	_, err := (&http.Client{Transport: transport}).Get(server.URL)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if attempts != 3 || apiErr.Attempts != 3 || apiErr.StatusCode != 500 || apiErr.RequestID != "req-42" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if expected := "500 Internal Server Error, after 3 attempts: database unavailable (request id req-42)"; apiErr.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, apiErr.Error())
+	}
+
+	// a POST the server may have acted on isn't sent again
+	attempts = 0
+	if _, err := (&http.Client{Transport: transport}).Post(server.URL, "application/json", nil); err == nil || attempts != 1 {
+		t.Errorf("expected a single attempt, got %d, %v", attempts, err)
+	}
+}
+
+func TestTransportPassesClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	transport, waits := testTransport(TransportConfig{Retries: 3})
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil || resp.StatusCode != 404 || len(*waits) != 0 {
+		t.Errorf("expected the 404 to be returned as is, got %v, %v", resp, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := TransportConfig{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: 5 * time.Second} {
+		if d := backoff(cfg, attempt); d < max/2 || d > max {
+			t.Errorf("expected the backoff of attempt %d within [%s, %s], got %s", attempt, max/2, max, d)
+		}
+	}
+}
+
+func TestDebugTraceOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var config, own bytes.Buffer
+	transport, _ := testTransport(TransportConfig{Debug: true, DebugOut: &config})
+	transport.DebugOut = &own
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if config.Len() != 0 || !strings.Contains(own.String(), "> GET "+server.URL) {
+		t.Errorf("expected the trace in the DebugOut of the transport alone, got %q and %q", config.String(), own.String())
+	}
+}
+
+func TestDebugTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"app": {"name": "myapp"}}`))
+	}))
+	defer server.Close()
+
+	var trace bytes.Buffer
+	transport, _ := testTransport(TransportConfig{Debug: true, DebugOut: &trace})
+	req, _ := http.NewRequest("POST", server.URL+"/v1/apps", strings.NewReader(`{"app": {"name": "myapp", "config": {"DB_PASSWORD": "hunter2", "REGION": "eu"}}, "route": {"headers": {"X-Upstream": ["s3cr3t-upstream"]}}, "api_key": "k3y"}`))
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != `{"app": {"name": "myapp"}}` {
+		t.Errorf("expected the traced body to be read back, got %q", b)
+	}
+	out := trace.String()
+	for _, secret := range []string{"s3cr3t", "hunter2", `"eu"`, "k3y"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s to be redacted from the trace:\n%s", secret, out)
+		}
+	}
+	for _, expected := range []string{"> POST " + server.URL + "/v1/apps", `"REGION": "REDACTED"`, `"X-Upstream": "REDACTED"`, "< HTTP/1.1 200 OK", `"name": "myapp"`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the trace:\n%s", expected, out)
+		}
+	}
+}
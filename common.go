@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,18 +13,17 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
-	"unicode"
 
-	"github.com/coreos/go-semver/semver"
 	"github.com/fnproject/cli/langs"
 )
 
 const (
-	functionsDockerImage     = "fnproject/functions"
-	funcfileDockerRuntime    = "docker"
-	minRequiredDockerVersion = "17.5.0"
-	envFnRegistry            = "FN_REGISTRY"
+	functionsDockerImage  = "fnproject/functions"
+	funcfileDockerRuntime = "docker"
+	envFnRegistry         = "FN_REGISTRY"
 )
 
 type HasRegistry interface {
@@ -47,7 +47,10 @@ func getWd() string {
 	return wd
 }
 
-func buildfunc(fpath string, funcfile *funcfile, noCache bool) (*funcfile, error) {
+// buildfunc builds the function image, push has a build for several platforms push the image as it goes, as it can't
+// be loaded into Docker for pushing afterwards. Unless force is set, the build is skipped when the image was built
+// from the same sources and Dockerfile before.
+func buildfunc(fpath string, funcfile *funcfile, noCache, push, force bool) (*funcfile, error) {
 	var err error
 	if funcfile.Version == "" {
 		funcfile, err = bumpIt(fpath, Patch)
@@ -60,13 +63,132 @@ func buildfunc(fpath string, funcfile *funcfile, noCache bool) (*funcfile, error
 		return nil, err
 	}
 
-	if err := dockerBuild(fpath, funcfile, noCache); err != nil {
-		return nil, err
+	if err := dockerBuild(fpath, funcfile, noCache, push, force); err != nil {
+		if _, failed := err.(*imageBuildError); !failed || buildMode(funcfile) != langs.BuildModeNative {
+			return nil, err
+		}
+		// native-image can't compile every program, reflection the configuration misses fails it for one
+		fmt.Fprintf(os.Stderr, "Warning: the native image build failed, building for the JVM instead: %v\n", err)
+		jvm := *funcfile
+		jvm.BuildMode = langs.BuildModeJVM
+		if err := dockerBuild(fpath, &jvm, noCache, push, force); err != nil {
+			return nil, err
+		}
 	}
 
 	return funcfile, nil
 }
 
+// dockerlessBuild assembles the function image without Docker, for helpers whose image build only copies the
+// function, into an OCI image layout tarball next to func.yaml. It returns the tarball path.
+func dockerlessBuild(fpath string, ff *funcfile) (string, error) {
+	var err error
+	if ff.Version == "" {
+		ff, err = bumpIt(fpath, Patch)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := localBuild(fpath, ff.Build); err != nil {
+		return "", err
+	}
+
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil {
+		return "", fmt.Errorf("Cannot build without Docker, %v", err)
+	}
+	funcDir, err := langs.FunctionDir()
+	if err != nil {
+		return "", err
+	}
+	if !helper.SupportsDockerlessBuild(funcDir) {
+		return "", fmt.Errorf("The %s function has dependencies to install, it can only be built with Docker", ff.Runtime)
+	}
+	caCmds, err := langs.CACertCmds()
+	if err != nil {
+		return "", err
+	}
+	if len(caCmds) > 0 || langs.UseInitWrapper() {
+		return "", errors.New("FN_CA_BUNDLE and FN_USE_INIT change the image with Docker, unset them to build without it")
+	}
+	ext, err := langs.LoadDockerfileExtensions(filepath.Dir(fpath), ff.BuildExtra)
+	if err != nil {
+		return "", err
+	}
+	if !ext.IsEmpty() {
+		return "", fmt.Errorf("build_extra and %s add Dockerfile instructions, build with Docker to apply them", langs.DockerfileOverlayDir)
+	}
+	if err := setImageMirror(ff); err != nil {
+		return "", err
+	}
+	mirror, err := langs.LoadImageMirror()
+	if err != nil {
+		return "", err
+	}
+	images, err := langs.ResolveImages(helper, ff.Runtime, ff.BuildImage, ff.RunImage)
+	if err != nil {
+		return "", err
+	}
+	if helper.HasPreBuild() {
+		ctx, stop := interruptContext()
+		err := langs.PreBuildContext(ctx, helper)
+		stop()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	platforms, err := langs.Platforms()
+	if err != nil {
+		return "", err
+	}
+	if len(platforms) > 1 {
+		return "", errors.New("building without Docker is for a single platform, set one with --platform")
+	}
+	platform := ""
+	if len(platforms) == 1 {
+		platform = platforms[0]
+	}
+	ri := images.Run
+	if platform != "" {
+		if _, archImage := images.ArchImages(langs.PlatformArch(platform)); archImage != "" {
+			ri = archImage
+		}
+	}
+	ri = mirror.Image(ri)
+
+	tarballPath := filepath.Join(filepath.Dir(fpath), filepath.Base(strings.Split(ff.Name, ":")[0])+".oci.tar")
+	var exclude []string
+	if rel, err := filepath.Rel(funcDir, tarballPath); err == nil {
+		exclude = append(exclude, filepath.ToSlash(rel))
+	}
+	image := &langs.ImageTarball{
+		BaseImage:  ri,
+		Platform:   platform,
+		Dir:        funcDir,
+		Exclude:    exclude,
+		Name:       ff.ImageName(),
+		Entrypoint: strings.Fields(ff.Entrypoint),
+		Cmd:        strings.Fields(ff.Cmd),
+		User:       helper.DockerfileUser(),
+		StopSignal: helper.DockerfileStopSignal(),
+		Port:       exposedPort(helper, ff),
+	}
+
+	fmt.Printf("Assembling image %v without Docker\n", ff.ImageName())
+	f, err := os.Create(tarballPath)
+	if err != nil {
+		return "", err
+	}
+	if err := image.WriteTo(context.Background(), f); err != nil {
+		f.Close()
+		os.Remove(tarballPath)
+		return "", err
+	}
+	return tarballPath, f.Close()
+}
+
 func localBuild(path string, steps []string) error {
 	for _, cmd := range steps {
 		exe := exec.Command("/bin/sh", "-c", cmd)
@@ -79,35 +201,152 @@ func localBuild(path string, steps []string) error {
 	return nil
 }
 
-func dockerBuild(fpath string, ff *funcfile, noCache bool) error {
-	err := dockerVersionCheck()
-	if err != nil {
-		return err
+func dockerBuild(fpath string, ff *funcfile, noCache, push, force bool) error {
+	// remote builds don't need a container engine where fn runs
+	var engine langs.ContainerEngine
+	var builder langs.RemoteBuilder
+	var err error
+	if remoteBuild() {
+		if builder, err = remoteBuilder(); err != nil {
+			return err
+		}
+		push = true
+	} else {
+		if engine, err = langs.Engine(); err != nil {
+			return err
+		}
+		if err := engine.CheckVersion(); err != nil {
+			return err
+		}
 	}
 
 	dir := filepath.Dir(fpath)
 
+	platforms, err := langs.Platforms()
+	if err != nil {
+		return err
+	}
+	proxies, err := langs.LoadProxyConfig()
+	if err != nil {
+		return err
+	}
+	// hashed before the Dockerfile is written next to the sources
+	sources, err := (&langs.BaseHelper{}).ContentTag(dir)
+	if err != nil {
+		return err
+	}
+
 	var helper langs.LangHelper
 	dockerfile := filepath.Join(dir, "Dockerfile")
 	if !exists(dockerfile) {
 		if ff.Runtime == funcfileDockerRuntime {
 			return fmt.Errorf("Dockerfile does not exist for 'docker' runtime")
 		}
-		helper = langs.GetLangHelper(ff.Runtime)
-		if helper == nil {
-			return fmt.Errorf("Cannot build, no language helper found for %v", ff.Runtime)
+		helper, err = langs.GetLangHelper(ff.Runtime)
+		if err != nil {
+			return fmt.Errorf("Cannot build, %v", err)
+		}
+		if err := setImageMirror(ff); err != nil {
+			return err
+		}
+		// native builds run from images of their own
+		if err := setBuildMode(helper, ff); err != nil {
+			return err
+		}
+		images, err := langs.ResolveImages(helper, ff.Runtime, ff.BuildImage, ff.RunImage)
+		if err != nil {
+			return err
+		}
+		if err := langs.CheckTargetArch(images); err != nil {
+			return err
+		}
+		if err := langs.CheckPlatforms(images, platforms); err != nil {
+			return err
+		}
+		if err := langs.CheckPinnedImages(images); err != nil {
+			return err
+		}
+		if err := langs.CheckProxyReachable(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, dependency downloads may hang\n", err)
 		}
 		dockerfile, err = writeTmpDockerfile(helper, dir, ff)
 		if err != nil {
 			return err
 		}
 		defer os.Remove(dockerfile)
+		if langs.UseInitWrapper() {
+			defer os.Remove(filepath.Join(dir, langs.InitWrapperFile))
+		}
+		if helper.NativeImageBuild() {
+			defer os.Remove(filepath.Join(dir, langs.NativeImageReflectionFile))
+		}
+	}
+
+	df, err := ioutil.ReadFile(dockerfile)
+	if err != nil {
+		return err
+	}
+	inputs := []string{strings.Join(platforms, ","), fmt.Sprint(push)}
+	labels := annotationLabels(ff)
+	if len(labels) > 0 {
+		inputs = append(inputs, strings.Join(labels, " "))
+	}
+	hash := buildInputsHash(sources, df, inputs...)
+	if !force && !noCache {
+		upToDate := false
+		if builder != nil {
+			upToDate = remoteBuildUpToDate(fpath, ff.ImageName(), hash)
+		} else {
+			upToDate = buildUpToDate(engine, fpath, ff.ImageName(), hash)
+		}
+		if upToDate {
+			fmt.Printf("Image %v is up to date, skipping the build. Use --force to rebuild it.\n", ff.ImageName())
+			return nil
+		}
+	}
+
+	if helper != nil {
 		if helper.HasPreBuild() {
-			err := helper.PreBuild()
+			ctx, stop := interruptContext()
+			err := langs.PreBuildContext(ctx, helper)
+			stop()
 			if err != nil {
 				return err
 			}
 		}
+		if langs.LocalBuildEnabled(helper) {
+			funcDir, err := langs.FunctionDir()
+			if err != nil {
+				return err
+			}
+			if err := helper.LocalBuild(funcDir); err != nil {
+				return err
+			}
+		}
+	}
+
+	if builder != nil {
+		fmt.Printf("Building image %v with %s\n", ff.ImageName(), builder.Name())
+		digest, err := builder.Build(&langs.RemoteBuild{
+			Dir:        dir,
+			Dockerfile: dockerfile,
+			Image:      ff.ImageName(),
+			Platforms:  platforms,
+			NoCache:    noCache,
+			BuildArgs:  buildFlagValues(proxies.BuildArgs(), "--build-arg"),
+			Labels:     buildFlagValues(labels, "--label"),
+		}, os.Stdout)
+		if err != nil {
+			return &imageBuildError{err}
+		}
+		fmt.Printf("Pushed %v as %v\n", ff.ImageName(), digest)
+		recordBuild(fpath, ff.ImageName(), hash, digest)
+		return afterBuild(helper)
+	}
+
+	buildArgs, err := engine.BuildArgs(ff.ImageName(), platforms, push)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Building image %v\n", ff.ImageName())
@@ -119,20 +358,20 @@ func dockerBuild(fpath string, ff *funcfile, noCache bool) error {
 	result := make(chan error, 1)
 
 	go func(done chan<- error) {
-		args := []string{
-			"build",
-			"-t", ff.ImageName(),
+		args := append(buildArgs,
 			"-f", dockerfile,
-		}
+		)
 		if noCache {
 			args = append(args, "--no-cache")
 		}
-		args = append(args,
-			"--build-arg", "HTTP_PROXY",
-			"--build-arg", "HTTPS_PROXY",
-			".")
-		cmd := exec.Command("docker", args...)
+		args = append(args, labels...)
+		args = append(args, proxies.BuildArgs()...)
+		args = append(args, ".")
+		cmd := engine.Command(args...)
 		cmd.Dir = dir
+		if helper != nil && langs.DockerfileSyntax(helper) != "" {
+			cmd.Env = append(os.Environ(), engine.BuildEnv()...)
+		}
 		cmd.Stderr = os.Stderr
 		cmd.Stdout = os.Stdout
 		done <- cmd.Run()
@@ -141,41 +380,56 @@ func dockerBuild(fpath string, ff *funcfile, noCache bool) error {
 	select {
 	case err := <-result:
 		if err != nil {
-			return fmt.Errorf("error running docker build: %v", err)
+			return &imageBuildError{fmt.Errorf("error running %s build: %v", engine.Name(), err)}
 		}
 	case signal := <-cancel:
 		return fmt.Errorf("build cancelled on signal %v", signal)
 	}
+	recordBuild(fpath, ff.ImageName(), hash, "")
+	return afterBuild(helper)
+}
 
-	if helper != nil {
-		err := helper.AfterBuild()
-		if err != nil {
-			return err
-		}
+// afterBuild runs the AfterBuild of the helper of the function built, if it has one
+func afterBuild(helper langs.LangHelper) error {
+	if helper != nil && helper.HasAfterBuild() {
+		return helper.AfterBuild()
 	}
 	return nil
 }
 
-func dockerVersionCheck() error {
-	out, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").Output()
-	if err != nil {
-		return fmt.Errorf("could not check Docker version: %v", err)
+// imageBuildError is a failure of the image build itself, rather than of preparing it
+type imageBuildError struct{ error }
+
+// buildMode returns the build mode of the function, the one FN_BUILD_MODE sets if its func.yaml has no build_mode
+func buildMode(ff *funcfile) string {
+	if ff.BuildMode != "" {
+		return ff.BuildMode
 	}
-	// dev / test builds append '-ce', trim this
-	trimmed := strings.TrimRightFunc(string(out), func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+	return os.Getenv(langs.BuildModeEnv)
+}
 
-	v, err := semver.NewVersion(trimmed)
-	if err != nil {
-		return fmt.Errorf("could not check Docker version: %v", err)
+// setBuildMode passes the build mode of the function on to the helper. FN_BUILD_MODE=native only applies to the
+// runtimes with native builds.
+func setBuildMode(helper langs.LangHelper, ff *funcfile) error {
+	mode := buildMode(ff)
+	if ff.BuildMode == "" && !helper.SupportsNativeImage() {
+		mode = ""
 	}
-	vMin, err := semver.NewVersion(minRequiredDockerVersion)
-	if err != nil {
-		return fmt.Errorf("our bad, sorry... please make an issue.", err)
+	return langs.SetBuildMode(helper, ff.Runtime, mode)
+}
+
+// annotationLabels returns the build flags labelling the image with the annotations of the func.yaml
+func annotationLabels(ff *funcfile) []string {
+	var keys []string
+	for k := range ff.Annotations {
+		keys = append(keys, k)
 	}
-	if v.LessThan(*vMin) {
-		return fmt.Errorf("please upgrade your version of Docker to %s or greater", minRequiredDockerVersion)
+	sort.Strings(keys)
+	var args []string
+	for _, k := range keys {
+		args = append(args, "--label", k+"="+ff.Annotations[k])
 	}
-	return nil
+	return args
 }
 
 func exists(name string) bool {
@@ -187,54 +441,199 @@ func exists(name string) bool {
 	return true
 }
 
+// stageLines renders the Dockerfile stages of a helper laying its Dockerfile out itself. Stages based on the build
+// or run image get the shell, workdir and CA bundle, the ones building on an earlier stage inherit them.
+func stageLines(helper langs.LangHelper, runtime string, stages []langs.Stage, bi, ri string, shellCmds, caCmds []string, ext *langs.DockerfileExtensions) []string {
+	var lines []string
+	last := len(stages) - 1
+	for i, stage := range stages {
+		from, fromImage := stage.From, true
+		switch from {
+		case langs.FromBuildImage:
+			from = bi
+		case langs.FromRunImage:
+			from = ri
+		default:
+			fromImage = false
+		}
+		if stage.Name != "" {
+			from += " as " + stage.Name
+		}
+		lines = append(lines, fmt.Sprintf("FROM %s", from))
+		if fromImage {
+			lines = append(lines, shellCmds...)
+			lines = append(lines, "WORKDIR /function")
+			lines = append(lines, caCmds...)
+			if i < last || last == 0 {
+				lines = append(lines, ext.PreBuild...)
+			}
+		}
+		if i == last {
+			lines = append(lines, ext.PreRun...)
+		}
+		cmds := langs.ContextCmds(stage.Cmds)
+		if i == last {
+			cmds = langs.ChownCopyCmds(cmds, helper.DockerfileUser())
+		} else {
+			cmds = langs.CacheMountCmds(helper, runtime, cmds)
+		}
+		lines = append(lines, cmds...)
+		if i == last-1 || last == 0 {
+			lines = append(lines, ext.PostBuild...)
+		}
+		if i == last {
+			lines = append(lines, ext.PostRun...)
+		}
+	}
+	return lines
+}
+
 func writeTmpDockerfile(helper langs.LangHelper, dir string, ff *funcfile) (string, error) {
 	if ff.Entrypoint == "" && ff.Cmd == "" {
 		return "", errors.New("entrypoint and cmd are missing, you must provide one or the other")
 	}
 
+	caCmds, err := langs.CACertCmds()
+	if err != nil {
+		return "", err
+	}
+	images, err := langs.ResolveImages(helper, ff.Runtime, ff.BuildImage, ff.RunImage)
+	if err != nil {
+		return "", err
+	}
+	mirror, err := langs.LoadImageMirror()
+	if err != nil {
+		return "", err
+	}
+	if _, err := langs.FunctionDir(); err != nil {
+		return "", err
+	}
+	ext, err := langs.LoadDockerfileExtensions(dir, ff.BuildExtra)
+	if err != nil {
+		return "", err
+	}
+
 	fd, err := ioutil.TempFile(dir, "Dockerfile")
 	if err != nil {
 		return "", err
 	}
 	defer fd.Close()
 
+	// SHELL only applies to the stage it is declared in
+	shellCmds := []string{}
+	if shell := helper.DockerfileShell(); len(shell) > 0 {
+		shellCmds = append(shellCmds, fmt.Sprintf("SHELL [%s]", stringToSlice(strings.Join(shell, " "))))
+	}
+
 	// multi-stage build: https://medium.com/travis-on-docker/multi-stage-docker-builds-for-creating-tiny-go-images-e0e1867efe5a
 	dfLines := []string{}
-	bi := ff.BuildImage
-	if bi == "" {
-		bi = helper.BuildFromImage()
+	if syntax := langs.DockerfileSyntax(helper); syntax != "" {
+		// parser directives have to come first
+		dfLines = append(dfLines, fmt.Sprintf("# syntax=%s", syntax))
 	}
-	if helper.IsMultiStage() {
-		// build stage
-		dfLines = append(dfLines, fmt.Sprintf("FROM %s as build-stage", bi))
-	} else {
-		dfLines = append(dfLines, fmt.Sprintf("FROM %s", bi))
+	// building for other architectures, the stages start from the images the helper declares for them
+	platforms, err := langs.Platforms()
+	if err != nil {
+		return "", err
 	}
-	dfLines = append(dfLines, "WORKDIR /function")
-	dfLines = append(dfLines, helper.DockerfileBuildCmds()...)
-	if helper.IsMultiStage() {
-		// final stage
-		ri := ff.RunImage
-		if ri == "" {
-			ri = helper.RunFromImage()
-		}
+	archLines, bi := langs.ArchImage(platforms, "build-image", images.Build, func(arch string) string {
+		image, _ := images.ArchImages(arch)
+		return image
+	})
+	dfLines = append(dfLines, archLines...)
+	archLines, ri := langs.ArchImage(platforms, "run-image", images.Run, func(arch string) string {
+		_, image := images.ArchImages(arch)
+		return image
+	})
+	dfLines = append(dfLines, archLines...)
+	if langs.LocalBuildEnabled(helper) {
+		// built on the host, the image only packages the artifact
 		dfLines = append(dfLines, fmt.Sprintf("FROM %s", ri))
+		dfLines = append(dfLines, shellCmds...)
+		dfLines = append(dfLines, "WORKDIR /function")
+		dfLines = append(dfLines, caCmds...)
+		dfLines = append(dfLines, ext.PreRun...)
+		dfLines = append(dfLines, langs.ChownCopyCmds(langs.ContextCmds(helper.LocalBuildCopyCmds()), helper.DockerfileUser())...)
+		dfLines = append(dfLines, ext.PostRun...)
+	} else if stages := helper.DockerfileStages(); len(stages) > 0 {
+		dfLines = append(dfLines, stageLines(helper, ff.Runtime, stages, bi, ri, shellCmds, caCmds, ext)...)
+	} else {
+		if helper.IsMultiStage() {
+			// build stage
+			dfLines = append(dfLines, fmt.Sprintf("FROM %s as build-stage", bi))
+		} else {
+			dfLines = append(dfLines, fmt.Sprintf("FROM %s", bi))
+		}
+		dfLines = append(dfLines, shellCmds...)
 		dfLines = append(dfLines, "WORKDIR /function")
-		dfLines = append(dfLines, helper.DockerfileCopyCmds()...)
+		dfLines = append(dfLines, caCmds...)
+		dfLines = append(dfLines, ext.PreBuild...)
+		dfLines = append(dfLines, langs.CacheMountCmds(helper, ff.Runtime, langs.ContextCmds(helper.DockerfileBuildCmds()))...)
+		dfLines = append(dfLines, ext.PostBuild...)
+		if helper.IsMultiStage() {
+			// final stage
+			dfLines = append(dfLines, fmt.Sprintf("FROM %s", ri))
+			dfLines = append(dfLines, shellCmds...)
+			dfLines = append(dfLines, "WORKDIR /function")
+			dfLines = append(dfLines, caCmds...)
+		}
+		// the function files go into the image that runs it, the build stage itself for single stage builds
+		dfLines = append(dfLines, ext.PreRun...)
+		dfLines = append(dfLines, langs.ChownCopyCmds(langs.ContextCmds(helper.DockerfileCopyCmds()), helper.DockerfileUser())...)
+		dfLines = append(dfLines, ext.PostRun...)
+	}
+	if user := helper.DockerfileUser(); user != "" {
+		dfLines = append(dfLines, fmt.Sprintf("USER %s", user))
 	}
-	if ff.Entrypoint != "" {
+	if port := exposedPort(helper, ff); port > 0 {
+		dfLines = append(dfLines, fmt.Sprintf("EXPOSE %d", port))
+	}
+	if stopSignal := helper.DockerfileStopSignal(); stopSignal != "" {
+		dfLines = append(dfLines, fmt.Sprintf("STOPSIGNAL %s", stopSignal))
+	}
+	if helper.NativeImageBuild() {
+		if err := langs.WriteNativeImageReflection(helper, dir, ff.Cmd); err != nil {
+			return "", err
+		}
+	}
+	if langs.UseInitWrapper() {
+		// functions only setting a cmd run it with the entrypoint of the image the function runs from
+		final := images.Run
+		if !helper.IsMultiStage() && !langs.LocalBuildEnabled(helper) {
+			final = images.Build
+		}
+		entrypoint, err := langs.InitWrapperEntrypoint(ff.Entrypoint, mirror.Image(final))
+		if err != nil {
+			return "", err
+		}
+		if err := langs.WriteInitWrapper(dir); err != nil {
+			return "", err
+		}
+		dfLines = append(dfLines, fmt.Sprintf("COPY %s /fn-init.sh", langs.InitWrapperFile))
+		dfLines = append(dfLines, fmt.Sprintf("ENTRYPOINT [%s]", quotedList(entrypoint)))
+	} else if ff.Entrypoint != "" {
 		dfLines = append(dfLines, fmt.Sprintf("ENTRYPOINT [%s]", stringToSlice(ff.Entrypoint)))
 	}
 	if ff.Cmd != "" {
 		dfLines = append(dfLines, fmt.Sprintf("CMD [%s]", stringToSlice(ff.Cmd)))
 	}
-	err = writeLines(fd, dfLines)
+	// every image the Dockerfile pulls, the helper's or the func.yaml's, comes through the registry mirror
+	err = writeLines(fd, mirror.Dockerfile(dfLines))
 	if err != nil {
 		return "", err
 	}
 	return fd.Name(), err
 }
 
+// exposedPort returns the port the FDK of the function listens on, 0 unless it is an http format function: the
+// others read their input from stdin.
+func exposedPort(helper langs.LangHelper, ff *funcfile) int {
+	if ff.Format != "http" {
+		return 0
+	}
+	return helper.FDKListenPort()
+}
+
 func writeLines(w io.Writer, lines []string) error {
 	writer := bufio.NewWriter(w)
 	for _, l := range lines {
@@ -261,6 +660,34 @@ func stringToSlice(in string) string {
 	return buffer.String()
 }
 
+// interruptContext returns a context cancelled once fn is interrupted with Ctrl-C, stopping the steps of helpers that
+// support cancellation, and the function releasing it.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, os.Interrupt)
+	go func() {
+		select {
+		case <-sigC:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigC)
+		cancel()
+	}
+}
+
+// quotedList returns the JSON form of the arguments of an exec form Dockerfile instruction, without the brackets.
+func quotedList(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func extractEnvConfig(configs []string) map[string]string {
 	c := make(map[string]string)
 	for _, v := range configs {
@@ -277,12 +704,20 @@ func dockerPush(ff *funcfile) error {
 	if err != nil {
 		return err
 	}
+	engine, err := langs.Engine()
+	if err != nil {
+		return err
+	}
+	platforms, err := langs.Platforms()
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Pushing %v to docker registry...", ff.ImageName())
-	cmd := exec.Command("docker", "push", ff.ImageName())
+	cmd := engine.Command(engine.PushArgs(ff.ImageName(), platforms)...)
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error running docker push: %v", err)
+		return fmt.Errorf("error running %s push: %v", engine.Name(), err)
 	}
 	return nil
 }
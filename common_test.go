@@ -0,0 +1,260 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fnproject/cli/langs"
+)
+
+func tmpDockerfileLines(t *testing.T, runtime string, ff *funcfile) []string {
+	helper, err := langs.GetLangHelper(runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dockerfile, err := writeTmpDockerfile(helper, dir, ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWriteTmpDockerfileExposesFDKPort(t *testing.T) {
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Format: "http", Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	if !containsLine(lines, "EXPOSE 8080") {
+		t.Errorf("expected Dockerfile to expose the FDK port, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// default format functions read stdin, they don't listen
+	lines = tmpDockerfileLines(t, "java8", &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	if containsLine(lines, "EXPOSE 8080") {
+		t.Errorf("expected no EXPOSE for a default format function, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestWriteTmpDockerfileBuildKitSyntax(t *testing.T) {
+	ff := &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"}
+	if lines := tmpDockerfileLines(t, "java8", ff); strings.HasPrefix(lines[0], "# syntax=") {
+		t.Errorf("expected no syntax directive without BuildKit features, got %q", lines[0])
+	}
+
+	os.Setenv("FN_BUILDKIT", "1")
+	defer os.Unsetenv("FN_BUILDKIT")
+	if lines := tmpDockerfileLines(t, "java8", ff); lines[0] != "# syntax=docker/dockerfile:1.4" {
+		t.Errorf("expected the syntax directive first, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestWriteTmpDockerfileImageOverrides(t *testing.T) {
+	os.Setenv("FN_JAVA8_RUN_IMAGE", "mirror.example.com/fnproject/fn-java-fdk:1.0.56")
+	defer os.Unsetenv("FN_JAVA8_RUN_IMAGE")
+
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Runtime: "java8", Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	if !containsLine(lines, "FROM mirror.example.com/fnproject/fn-java-fdk:1.0.56") {
+		t.Errorf("expected the overridden run image, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestWriteTmpDockerfileInitWrapper(t *testing.T) {
+	os.Setenv("FN_USE_INIT", "1")
+	defer os.Unsetenv("FN_USE_INIT")
+
+	lines := tmpDockerfileLines(t, "go", &funcfile{Entrypoint: "./func"})
+	for _, line := range []string{"COPY .fn-init.sh /fn-init.sh", `ENTRYPOINT ["/fn-init.sh", "./func"]`} {
+		if !containsLine(lines, line) {
+			t.Errorf("expected Dockerfile to contain %q, got:\n%s", line, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestWriteTmpDockerfileStopSignal(t *testing.T) {
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	if !containsLine(lines, "STOPSIGNAL SIGTERM") {
+		t.Errorf("expected Dockerfile to declare the JVM stop signal, got:\n%s", strings.Join(lines, "\n"))
+	}
+	lines = tmpDockerfileLines(t, "octave", &funcfile{Cmd: "octave --no-gui --quiet func.m"})
+	for _, line := range lines {
+		if strings.HasPrefix(line, "STOPSIGNAL") {
+			t.Errorf("expected no stop signal by default, got %q", line)
+		}
+	}
+}
+
+func TestWriteTmpDockerfileSingleStageCopiesFunction(t *testing.T) {
+	lines := tmpDockerfileLines(t, "octave", &funcfile{Cmd: "octave --no-gui --quiet func.m"})
+	if !containsLine(lines, "ADD *.m /function/") {
+		t.Errorf("expected the single stage Dockerfile to add the scripts, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestWriteTmpDockerfileRunsAsDeclaredUser(t *testing.T) {
+	os.Setenv("FN_DOCKERFILE_USER", "fn")
+	defer os.Unsetenv("FN_DOCKERFILE_USER")
+
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	for _, line := range []string{
+		"COPY --chown=fn:fn --from=build-stage /function/target/*.jar /function/app/",
+		"USER fn",
+	} {
+		if !containsLine(lines, line) {
+			t.Errorf("expected Dockerfile to contain %q, got:\n%s", line, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestWriteTmpDockerfileTrustsCABundle(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := ioutil.TempDir("", "ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := ioutil.WriteFile("corp-ca.pem", []byte("-----BEGIN CERTIFICATE-----\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("FN_CA_BUNDLE", "corp-ca.pem")
+	defer os.Unsetenv("FN_CA_BUNDLE")
+
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	copies := 0
+	for _, line := range lines {
+		if line == "COPY corp-ca.pem /usr/local/share/ca-certificates/fn-ca-bundle.crt" {
+			copies++
+		}
+	}
+	if copies != 2 || !containsLine(lines, "RUN update-ca-certificates") {
+		t.Errorf("expected the CA bundle to be trusted in both stages, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+type pipefailHelper struct {
+	langs.BaseHelper
+}
+
+func (h *pipefailHelper) BuildFromImage() string { return "debian:stretch" }
+func (h *pipefailHelper) DockerfileShell() []string {
+	return []string{"/bin/bash", "-eo", "pipefail", "-c"}
+}
+
+func TestWriteTmpDockerfileDeclaresShell(t *testing.T) {
+	langs.OverrideLangHelper("pipefail", &pipefailHelper{})
+
+	lines := tmpDockerfileLines(t, "pipefail", &funcfile{Cmd: "./func"})
+	if lines[1] != `SHELL ["/bin/bash", "-eo", "pipefail", "-c"]` {
+		t.Errorf("expected the build stage to declare its shell, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestWriteTmpDockerfileStages(t *testing.T) {
+	lines := tmpDockerfileLines(t, "java8", &funcfile{Cmd: "com.example.fn.HelloFunction::handleRequest"})
+	var froms []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "FROM ") {
+			froms = append(froms, line)
+		}
+	}
+	expected := []string{
+		"FROM fnproject/fn-java-fdk-build:1.0.56 as deps",
+		"FROM deps as build-stage",
+		"FROM fnproject/fn-java-fdk:1.0.56",
+	}
+	if strings.Join(froms, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the stages %q, got %q", expected, froms)
+	}
+}
+
+func TestWriteTmpDockerfileArchImages(t *testing.T) {
+	os.Setenv("FN_PLATFORM", "linux/amd64,linux/arm64")
+	defer os.Unsetenv("FN_PLATFORM")
+
+	lines := tmpDockerfileLines(t, "go", &funcfile{Entrypoint: "./func"})
+	for _, line := range []string{
+		"FROM golang:1.9-alpine as build-image-arm64",
+		"FROM alpine:3.6 as run-image-arm64",
+		"FROM build-image-${TARGETARCH} as build-stage",
+		"FROM run-image-${TARGETARCH}",
+	} {
+		if !containsLine(lines, line) {
+			t.Errorf("expected Dockerfile to contain %q, got:\n%s", line, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestWriteTmpDockerfileExtensions(t *testing.T) {
+	lines := tmpDockerfileLines(t, "go", &funcfile{
+		Entrypoint: "./func",
+		BuildExtra: &langs.DockerfileExtensions{
+			PreBuild:  []string{"RUN apk add --no-cache gcc"},
+			PostBuild: []string{"RUN go vet ./..."},
+			PreRun:    []string{"RUN apk add --no-cache tzdata"},
+			PostRun:   []string{"ENV TZ=UTC"},
+		},
+	})
+	index := func(line string) int {
+		for i, l := range lines {
+			if l == line {
+				return i
+			}
+		}
+		t.Fatalf("expected Dockerfile to contain %q, got:\n%s", line, strings.Join(lines, "\n"))
+		return -1
+	}
+	order := []int{
+		index("RUN apk add --no-cache gcc"),
+		index("RUN go vet ./..."),
+		index("FROM funcy/go"),
+		index("RUN apk add --no-cache tzdata"),
+		index("ENV TZ=UTC"),
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i] < order[i-1] {
+			t.Fatalf("expected the instructions at their extension points, got:\n%s", strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestInterruptContext(t *testing.T) {
+	ctx, stop := interruptContext()
+	defer stop()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("can't interrupt the test: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("expected Ctrl-C to cancel the context")
+	}
+}
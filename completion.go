@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	client "github.com/fnproject/cli/client"
+	"github.com/fnproject/cli/langs"
+	apiapps "github.com/funcy/functions_go/client/apps"
+	apiroutes "github.com/funcy/functions_go/client/routes"
+	"github.com/urfave/cli"
+)
+
+// completionTimeout bounds the API queries of completions, a shell waits on them while the user types
+const completionTimeout = 2 * time.Second
+
+// completionScripts are the scripts fn completion prints. They run the words typed so far with
+// --generate-bash-completion, which has fn print the candidates for the next word.
+var completionScripts = map[string]string{
+	"bash": `_fn_completion() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null)
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+}
+complete -o default -F _fn_completion fn
+`,
+	"zsh": `#compdef fn
+_fn() {
+  local -a opts
+  opts=("${(@f)$(${words[1,CURRENT-1]} --generate-bash-completion 2>/dev/null)}")
+  compadd -a opts
+}
+compdef _fn fn
+`,
+	"fish": `function __fn_complete
+    set -l args (commandline -opc)
+    command $args --generate-bash-completion 2>/dev/null
+end
+complete -c fn -f -a '(__fn_complete)'
+`,
+	"powershell": `Register-ArgumentCompleter -Native -CommandName fn -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '') { $words = $words[0..($words.Count - 2)] }
+    $rest = @()
+    if ($words.Count -gt 1) { $rest = $words[1..($words.Count - 1)] }
+    & $words[0] @rest --generate-bash-completion 2>$null |
+        Where-Object { $_ -like "$wordToComplete*" } |
+        ForEach-Object { [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_) }
+}
+`,
+}
+
+func completionCmd() cli.Command {
+	return cli.Command{
+		Name:  "completion",
+		Usage: "print the shell completion script of fn, eg: source <(fn completion bash)",
+		Description: "Completes commands, and the apps, routes, runtimes and contexts they take, which are queried when completing.\n" +
+			"   bash: source <(fn completion bash), in ~/.bashrc\n" +
+			"   zsh: source <(fn completion zsh), in ~/.zshrc after compinit\n" +
+			"   fish: fn completion fish > ~/.config/fish/completions/fn.fish\n" +
+			"   powershell: fn completion powershell | Out-String | Invoke-Expression, in $PROFILE",
+		ArgsUsage: "<bash|zsh|fish|powershell>",
+		Action:    completion,
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() == 0 {
+				printCompletions(completionShells())
+			}
+		},
+	}
+}
+
+func completion(c *cli.Context) error {
+	script, ok := completionScripts[c.Args().First()]
+	if !ok {
+		return fmt.Errorf("unknown shell %q, use one of %s", c.Args().First(), strings.Join(completionShells(), ", "))
+	}
+	fmt.Print(script)
+	return nil
+}
+
+func completionShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+// completers complete the values of the flags, and of the arguments of ArgsUsage, they are named after. Those
+// of routes complete the routes of the app argument before them.
+var completers = map[string]func(args []string) []string{
+	"app":       completeApps,
+	"<app>":     completeApps,
+	"</path>":   completeRoutes,
+	"<route>":   completeRoutes,
+	"[route]":   completeRoutes,
+	"runtime":   completeRuntimes,
+	"context":   completeContexts,
+	"contexts":  completeContexts,
+	"<context>": completeContexts,
+}
+
+// prepareCmdCompletions has the commands complete their arguments, and the values of their flags, with the
+// completers of their names, see completers.
+func prepareCmdCompletions(cmds []cli.Command) {
+	for i, cmd := range cmds {
+		prepareCmdCompletions(cmd.Subcommands)
+		if cmd.Action == nil || cmd.BashComplete != nil {
+			continue
+		}
+		cmd.BashComplete = func(c *cli.Context) {
+			// the value of a flag doesn't parse yet, it is missing
+			if complete, ok := completers[completingFlag(os.Args)]; ok {
+				printCompletions(complete(nil))
+				return
+			}
+			args := strings.Fields(c.Command.ArgsUsage)
+			if c.NArg() >= len(args) {
+				return
+			}
+			if complete, ok := completers[args[c.NArg()]]; ok {
+				printCompletions(complete(c.Args()))
+			}
+		}
+		cmds[i] = cmd
+	}
+}
+
+// completingFlag returns the name of the flag whose value is being completed, the last word before
+// --generate-bash-completion, if it is a flag
+func completingFlag(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	last := args[len(args)-2]
+	if !strings.HasPrefix(last, "-") || strings.Contains(last, "=") {
+		return ""
+	}
+	return strings.TrimLeft(last, "-")
+}
+
+func printCompletions(values []string) {
+	for _, v := range values {
+		fmt.Println(v)
+	}
+}
+
+func completeApps([]string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	resp, err := client.APIClient().Apps.GetApps(&apiapps.GetAppsParams{Context: ctx})
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, app := range resp.Payload.Apps {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+func completeRoutes(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	resp, err := client.APIClient().Routes.GetAppsAppRoutes(&apiroutes.GetAppsAppRoutesParams{Context: ctx, App: args[0]})
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, route := range resp.Payload.Routes {
+		paths = append(paths, route.Path)
+	}
+	return paths
+}
+
+func completeRuntimes([]string) []string {
+	var runtimes []string
+	for _, entry := range langs.CompletionEntries() {
+		runtimes = append(runtimes, entry.Name)
+		runtimes = append(runtimes, entry.Aliases...)
+	}
+	return runtimes
+}
+
+func completeContexts([]string) []string {
+	contexts, err := readContexts()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCompletingFlag(t *testing.T) {
+	for args, expected := range map[string]string{
+		"fn init --runtime --generate-bash-completion":     "runtime",
+		"fn deploy --app=myapp --generate-bash-completion": "",
+		"fn routes list myapp --generate-bash-completion":  "",
+		"--generate-bash-completion":                       "",
+	} {
+		if flag := completingFlag(strings.Fields(args)); flag != expected {
+			t.Errorf("expected %q completing %q, got %q", expected, args, flag)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		if !strings.Contains(completionScripts[shell], "--generate-bash-completion") {
+			t.Errorf("expected the %s script to ask fn for the completions", shell)
+		}
+	}
+}
+
+func TestPrepareCmdCompletions(t *testing.T) {
+	cmds := []cli.Command{
+		{Name: "apps", Subcommands: []cli.Command{{Name: "inspect", ArgsUsage: "<app>", Action: func(*cli.Context) error { return nil }}}},
+		{Name: "version", Action: func(*cli.Context) error { return nil }},
+		{Name: "completion", Action: func(*cli.Context) error { return nil }, BashComplete: func(*cli.Context) {}},
+	}
+	prepareCmdCompletions(cmds)
+	if cmds[0].BashComplete != nil || cmds[0].Subcommands[0].BashComplete == nil || cmds[1].BashComplete == nil {
+		t.Error("expected the commands with actions to complete")
+	}
+}
+
+func TestCompleteContexts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(f func() string) { contextsFile = f }(contextsFile)
+	contextsFile = func() string { return filepath.Join(tmp, "contexts.json") }
+
+	if err := writeContexts(map[string]fnContext{"prod": {Name: "prod"}, "dev": {Name: "dev"}}); err != nil {
+		t.Fatal(err)
+	}
+	if names := completeContexts(nil); !reflect.DeepEqual(names, []string{"dev", "prod"}) {
+		t.Errorf("expected the contexts sorted, got %v", names)
+	}
+}
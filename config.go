@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+// proxyFlags override the proxies builds go through, for fn build and fn deploy
+var proxyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "http-proxy",
+		Usage: "proxy the build goes through for HTTP, overriding http_proxy and fn config http-proxy",
+	},
+	cli.StringFlag{
+		Name:  "https-proxy",
+		Usage: "proxy the build goes through for HTTPS, overriding https_proxy and fn config https-proxy",
+	},
+	cli.StringFlag{
+		Name:  "no-proxy",
+		Usage: "comma separated hosts and domains the build reaches without a proxy, overriding no_proxy and fn config no-proxy",
+	},
+}
+
+// setProxyEnv passes the proxy flags on to the build through FN_HTTP_PROXY, FN_HTTPS_PROXY and FN_NO_PROXY
+func setProxyEnv(c *cli.Context) {
+	for flag, env := range map[string]string{"http-proxy": "FN_HTTP_PROXY", "https-proxy": "FN_HTTPS_PROXY", "no-proxy": "FN_NO_PROXY"} {
+		if value := c.String(flag); value != "" {
+			os.Setenv(env, value)
+		}
+	}
+}
+
+type configCmd struct{}
+
+func configure() cli.Command {
+	cfg := configCmd{}
+
+	return cli.Command{
+		Name:  "config",
+		Usage: "manage the settings of the CLI, such as the proxies builds go through",
+		Subcommands: []cli.Command{
+			{
+				Name:      "set",
+				Aliases:   []string{"s"},
+				Usage:     "store a setting",
+				ArgsUsage: "<key> <value>",
+				Action:    cfg.set,
+			},
+			{
+				Name:      "get",
+				Aliases:   []string{"g"},
+				Usage:     "print a setting",
+				ArgsUsage: "<key>",
+				Action:    cfg.get,
+			},
+			{
+				Name:      "unset",
+				Aliases:   []string{"u"},
+				Usage:     "remove a setting",
+				ArgsUsage: "<key>",
+				Action:    cfg.unset,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "list the settings and their values",
+				Action:  cfg.list,
+			},
+		},
+	}
+}
+
+func (cfg *configCmd) set(c *cli.Context) error {
+	key, value := c.Args().Get(0), c.Args().Get(1)
+	if err := langs.SetSetting(key, value); err != nil {
+		return err
+	}
+	fmt.Println(key, "set")
+	return nil
+}
+
+func (cfg *configCmd) get(c *cli.Context) error {
+	fmt.Println(langs.Setting(c.Args().Get(0)))
+	return nil
+}
+
+func (cfg *configCmd) unset(c *cli.Context) error {
+	key := c.Args().Get(0)
+	if err := langs.UnsetSetting(key); err != nil {
+		return err
+	}
+	fmt.Println(key, "removed")
+	return nil
+}
+
+func (cfg *configCmd) list(c *cli.Context) error {
+	settings, err := langs.Settings()
+	if err != nil {
+		return err
+	}
+	keys, usages := langs.SettingKeys()
+	return render(c, settings, func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprint(w, "key", "\t", "value", "\t", "usage", "\n")
+		for _, key := range keys {
+			fmt.Fprint(w, key, "\t", settings[key], "\t", usages[key], "\n")
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/fnproject/cli/client"
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+// fnContext is a functions server deploys can target by name, and the registry the images it runs are pushed to.
+type fnContext struct {
+	Name     string `json:"name"`
+	APIURL   string `json:"api_url"`
+	Registry string `json:"registry"`
+	Token    string `json:"token,omitempty"`
+	// Builder builds the images of fn build --builder remote --context, see remoteBuilder.
+	Builder *langs.RemoteBuilderConfig `json:"builder,omitempty"`
+	// RegistryMirror and ImageDigests are where builds with --context pull base images from, see setImageMirror.
+	RegistryMirror string            `json:"registry_mirror,omitempty"`
+	ImageDigests   map[string]string `json:"image_digests,omitempty"`
+}
+
+// contextsFile returns where contexts are kept, keyed by name. It is a variable so tests can move it.
+var contextsFile = func() string {
+	return langs.StateFile("contexts.json")
+}
+
+var contextNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func readContexts() (map[string]fnContext, error) {
+	contexts := map[string]fnContext{}
+	b, err := ioutil.ReadFile(contextsFile())
+	if os.IsNotExist(err) {
+		return contexts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &contexts); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", contextsFile(), err)
+	}
+	return contexts, nil
+}
+
+// writeContexts keeps the contexts readable by the user alone, they hold API tokens.
+func writeContexts(contexts map[string]fnContext) error {
+	path := contextsFile()
+	if path == "" {
+		return errors.New("could not find the home directory to keep contexts in")
+	}
+	b, err := json.MarshalIndent(contexts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// lookupContexts returns the contexts of a comma separated list of names, in its order.
+func lookupContexts(list string) ([]fnContext, error) {
+	contexts, err := readContexts()
+	if err != nil {
+		return nil, err
+	}
+	var found []fnContext
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		ctx, ok := contexts[name]
+		if !ok {
+			return nil, fmt.Errorf("context %s does not exist, create it with fn contexts create", name)
+		}
+		seen[name] = true
+		found = append(found, ctx)
+	}
+	if len(found) == 0 {
+		return nil, errors.New("no contexts given")
+	}
+	return found, nil
+}
+
+type contextsCmd struct{}
+
+func contexts() cli.Command {
+	cmd := contextsCmd{}
+
+	return cli.Command{
+		Name:  "contexts",
+		Usage: "manage the functions servers, and their registries, fn deploy --contexts deploys to",
+		Subcommands: []cli.Command{
+			{
+				Name:      "create",
+				Aliases:   []string{"c"},
+				Usage:     "create or replace a context",
+				ArgsUsage: "<context>",
+				Action:    cmd.create,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "api-url",
+						Usage: "URL of the functions server, eg: http://fn.example.com:8080",
+					},
+					cli.StringFlag{
+						Name:  "registry",
+						Usage: "registry, and owner, to push the images of the functions server to, eg: registry.example.com/team",
+					},
+					cli.StringFlag{
+						Name:  "token",
+						Usage: "token to authenticate to the functions server with",
+					},
+					cli.StringFlag{
+						Name:  "builder",
+						Usage: "remote builder of the context, " + langs.RemoteBuilderBuildKit + " or " + langs.RemoteBuilderKaniko + ", which fn build --builder remote builds with",
+					},
+					cli.StringFlag{
+						Name:  "builder-addr",
+						Usage: "address of the BuildKit daemon, eg: tcp://buildkitd.example.com:1234",
+					},
+					cli.StringFlag{
+						Name:  "builder-namespace",
+						Usage: "Kubernetes namespace Kaniko builds run in",
+					},
+					cli.StringFlag{
+						Name:  "builder-kube-context",
+						Usage: "kubectl context of the cluster Kaniko builds run in",
+					},
+					cli.StringFlag{
+						Name:  "builder-secret",
+						Usage: "Kubernetes docker-registry secret Kaniko pushes images with",
+					},
+					cli.StringFlag{
+						Name:  "builder-image",
+						Usage: "Kaniko executor image",
+					},
+					cli.StringFlag{
+						Name:  "registry-mirror",
+						Usage: "mirror builds with the context pull Docker Hub images from, or registry=mirror pairs, eg: gcr.io=mirror.example.com/gcr",
+					},
+				},
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "list the contexts",
+				Action:  cmd.list,
+			},
+			{
+				Name:      "delete",
+				Aliases:   []string{"d"},
+				Usage:     "delete a context",
+				ArgsUsage: "<context>",
+				Action:    cmd.delete,
+			},
+		},
+	}
+}
+
+func (cmd *contextsCmd) create(c *cli.Context) error {
+	name := c.Args().Get(0)
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q, use letters, digits, dots, dashes and underscores", name)
+	}
+	ctx := fnContext{Name: name, APIURL: c.String("api-url"), Registry: c.String("registry"), Token: c.String("token")}
+	if ctx.APIURL == "" || ctx.Registry == "" {
+		return errors.New("--api-url and --registry are required")
+	}
+	if host, err := client.HostOf(ctx.APIURL); err != nil || host == "" {
+		return fmt.Errorf("invalid API URL %q, eg: http://fn.example.com:8080", ctx.APIURL)
+	}
+	if kind := c.String("builder"); kind != "" {
+		ctx.Builder = &langs.RemoteBuilderConfig{
+			Kind:        kind,
+			Addr:        c.String("builder-addr"),
+			Namespace:   c.String("builder-namespace"),
+			KubeContext: c.String("builder-kube-context"),
+			Secret:      c.String("builder-secret"),
+			Image:       c.String("builder-image"),
+		}
+		if err := checkRemoteBuilder(ctx.Builder); err != nil {
+			return err
+		}
+	}
+	ctx.RegistryMirror = c.String("registry-mirror")
+	if _, err := langs.ParseRegistryMirror(ctx.RegistryMirror); err != nil {
+		return err
+	}
+
+	contexts, err := readContexts()
+	if err != nil {
+		return err
+	}
+	contexts[name] = ctx
+	if err := writeContexts(contexts); err != nil {
+		return err
+	}
+	fmt.Println("Context", name, "created")
+	return nil
+}
+
+func (cmd *contextsCmd) list(c *cli.Context) error {
+	contexts, err := readContexts()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	listed := make([]fnContext, 0, len(names))
+	for _, name := range names {
+		ctx := contexts[name]
+		// tokens aren't listed
+		ctx.Token = ""
+		listed = append(listed, ctx)
+	}
+	return render(c, listed, func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprint(w, "name", "\t", "api url", "\t", "registry", "\t", "builder", "\t", "registry mirror", "\n")
+		for _, ctx := range listed {
+			builder := "local"
+			if ctx.Builder != nil {
+				builder = ctx.Builder.Kind
+			}
+			fmt.Fprint(w, ctx.Name, "\t", ctx.APIURL, "\t", ctx.Registry, "\t", builder, "\t", ctx.RegistryMirror, "\n")
+		}
+		return w.Flush()
+	})
+}
+
+// contextSettings are the settings of a context fn update context sets
+var contextSettings = []string{"api-url", "registry", "token", "registry-mirror", "image-digest"}
+
+// update sets a setting of a context. An empty value unsets the optional ones, and image-digest pins the image of
+// its value to a digest, or unpins it without one.
+func (cmd *contextsCmd) update(c *cli.Context) error {
+	name, setting, value := c.Args().Get(0), c.Args().Get(1), c.Args().Get(2)
+	contexts, err := readContexts()
+	if err != nil {
+		return err
+	}
+	ctx, ok := contexts[name]
+	if !ok {
+		return fmt.Errorf("context %s does not exist, create it with fn contexts create", name)
+	}
+	switch setting {
+	case "api-url":
+		if host, err := client.HostOf(value); err != nil || host == "" {
+			return fmt.Errorf("invalid API URL %q, eg: http://fn.example.com:8080", value)
+		}
+		ctx.APIURL = value
+	case "registry":
+		if value == "" {
+			return errors.New("the registry of a context can't be unset")
+		}
+		ctx.Registry = value
+	case "token":
+		ctx.Token = value
+	case "registry-mirror":
+		if _, err := langs.ParseRegistryMirror(value); err != nil {
+			return err
+		}
+		ctx.RegistryMirror = value
+	case "image-digest":
+		digest := c.Args().Get(3)
+		if value == "" {
+			return errors.New("image-digest needs the image, and the digest to pin it to")
+		}
+		if digest == "" {
+			delete(ctx.ImageDigests, value)
+			break
+		}
+		if err := langs.CheckImageDigest(value, digest); err != nil {
+			return err
+		}
+		if ctx.ImageDigests == nil {
+			ctx.ImageDigests = map[string]string{}
+		}
+		ctx.ImageDigests[value] = digest
+	default:
+		return fmt.Errorf("unknown context setting %q, use one of %s", setting, strings.Join(contextSettings, ", "))
+	}
+
+	contexts[name] = ctx
+	if err := writeContexts(contexts); err != nil {
+		return err
+	}
+	fmt.Println("Context", name, "updated")
+	return nil
+}
+
+func (cmd *contextsCmd) delete(c *cli.Context) error {
+	name := c.Args().Get(0)
+	contexts, err := readContexts()
+	if err != nil {
+		return err
+	}
+	if _, ok := contexts[name]; !ok {
+		return fmt.Errorf("context %s does not exist", name)
+	}
+	delete(contexts, name)
+	if err := writeContexts(contexts); err != nil {
+		return err
+	}
+	fmt.Println("Context", name, "deleted")
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestLookupContexts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(f func() string) { contextsFile = f }(contextsFile)
+	contextsFile = func() string { return filepath.Join(tmp, "contexts.json") }
+
+	if err := writeContexts(map[string]fnContext{
+		"prod-eu": {Name: "prod-eu", APIURL: "http://fn.eu.example.com:8080", Registry: "registry.eu.example.com/team"},
+		"prod-us": {Name: "prod-us", APIURL: "http://fn.us.example.com:8080", Registry: "registry.us.example.com/team"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := lookupContexts("prod-us, prod-eu,prod-us")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].Name != "prod-us" || targets[1].Name != "prod-eu" {
+		t.Errorf("expected prod-us then prod-eu, got %+v", targets)
+	}
+	if _, err := lookupContexts("prod-eu,staging"); err == nil {
+		t.Error("expected an unknown context to be reported")
+	}
+
+	ff := &funcfile{Name: "hello", Version: "0.0.3"}
+	if image := ff.ImageNameIn(targets[0].Registry); image != "registry.us.example.com/team/hello:0.0.3" {
+		t.Errorf("expected the image in the registry of the context, got %s", image)
+	}
+}
+
+func TestUpdateContext(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tmp, err := ioutil.TempDir("", "contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(f func() string) { contextsFile = f }(contextsFile)
+	contextsFile = func() string { return filepath.Join(tmp, "contexts.json") }
+
+	if err := writeContexts(map[string]fnContext{
+		"prod": {Name: "prod", APIURL: "http://fn.example.com:8080", Registry: "registry.example.com/team"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	app := cli.NewApp()
+	app.Commands = []cli.Command{updateCmd()}
+	for _, args := range [][]string{
+		{"registry-mirror", "mirror.example.com/hub"},
+		{"image-digest", "fnproject/go:dev", digest},
+		{"image-digest", "fnproject/go", digest},
+		{"image-digest", "fnproject/go"},
+	} {
+		if err := app.Run(append([]string{"fn", "update", "context", "prod"}, args...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contexts, err := readContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := contexts["prod"]
+	if ctx.RegistryMirror != "mirror.example.com/hub" || len(ctx.ImageDigests) != 1 || ctx.ImageDigests["fnproject/go:dev"] != digest {
+		t.Errorf("expected the registry mirror and a pinned image, got %+v", ctx)
+	}
+
+	for _, args := range [][]string{
+		{"registry-mirror", "Mirror"},
+		{"image-digest", "fnproject/go:dev", "latest"},
+		{"registry", ""},
+		{"builder", "kaniko"},
+	} {
+		if err := app.Run(append([]string{"fn", "update", "context", "prod"}, args...)); err == nil {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
-	"time"
+	"sync"
 
 	client "github.com/fnproject/cli/client"
+	"github.com/fnproject/cli/langs"
 	functions "github.com/funcy/functions_go"
 	"github.com/funcy/functions_go/models"
 	"github.com/urfave/cli"
@@ -20,6 +22,9 @@ func deploy() cli.Command {
 	}
 	var flags []cli.Flag
 	flags = append(flags, cmd.flags()...)
+	flags = append(flags, proxyFlags...)
+	flags = append(flags, signFlags...)
+	flags = append(flags, builderFlags...)
 	return cli.Command{
 		Name:   "deploy",
 		Usage:  "deploys a function to the functions server. (bumps, build, pushes and updates route)",
@@ -38,6 +43,16 @@ type deploycmd struct {
 	noCache  bool
 	registry string
 	all      bool
+	platform string
+	contexts string
+	parallel bool
+	env      string
+	jobs     int
+	// funcName and funcPath name and route the function, for the deploys of --all --jobs
+	funcName string
+	funcPath string
+	// targets are the contexts of --contexts
+	targets []fnContext
 }
 
 func (cmd *deploycmd) Registry() string {
@@ -76,6 +91,42 @@ func (p *deploycmd) flags() []cli.Flag {
 			Usage:       "if in root directory containing `app.yaml`, this will deploy all functions",
 			Destination: &p.all,
 		},
+		cli.StringFlag{
+			Name:        "platform",
+			Usage:       "platforms to build the image for with docker buildx, eg: linux/amd64,linux/arm64. Images for several platforms are pushed as they are built.",
+			Destination: &p.platform,
+		},
+		cli.StringFlag{
+			Name:        "contexts",
+			Usage:       "comma separated contexts to deploy to, building the image once and pushing it to the registry of each. See fn contexts.",
+			Destination: &p.contexts,
+		},
+		cli.BoolFlag{
+			Name:        "parallel",
+			Usage:       "deploy to the --contexts in parallel",
+			Destination: &p.parallel,
+		},
+		cli.StringFlag{
+			Name:        "env",
+			Usage:       "environment of func.yaml, such as prod, whose overrides are merged over its settings",
+			Destination: &p.env,
+		},
+		cli.IntFlag{
+			Name:        "jobs, j",
+			Usage:       "number of functions --all builds and deploys at once",
+			Value:       1,
+			Destination: &p.jobs,
+		},
+		cli.StringFlag{
+			Name:        "func-name",
+			Hidden:      true,
+			Destination: &p.funcName,
+		},
+		cli.StringFlag{
+			Name:        "func-path",
+			Hidden:      true,
+			Destination: &p.funcPath,
+		},
 	}
 }
 
@@ -90,6 +141,37 @@ func (p *deploycmd) flags() []cli.Flag {
 // is the one that lives in the same directory as the app.yaml.
 func (p *deploycmd) deploy(c *cli.Context) error {
 	setRegistryEnv(p)
+	if p.platform != "" {
+		os.Setenv("FN_PLATFORM", p.platform)
+	}
+	setProxyEnv(c)
+	if err := checkSignFlags(c, p.local); err != nil {
+		return err
+	}
+	if p.contexts != "" {
+		if p.local {
+			return errors.New("--contexts pushes to the registry of each context, it can't be used with --local")
+		}
+		targets, err := lookupContexts(p.contexts)
+		if err != nil {
+			return err
+		}
+		p.targets = targets
+	} else if p.parallel {
+		return errors.New("--parallel is only used with --contexts")
+	}
+	if err := setBuilderEnv(c); err != nil {
+		return err
+	}
+	if remoteBuild() && (p.local || p.contexts != "") {
+		return errors.New("--builder remote pushes the image to the registry of --context, it can't be used with --local or --contexts")
+	}
+	if p.jobs < 1 {
+		return errors.New("--jobs must be at least 1")
+	}
+	if p.jobs > 1 && !p.all {
+		return errors.New("--jobs is only used with --all")
+	}
 
 	appName := ""
 
@@ -145,6 +227,12 @@ func (p *deploycmd) deploySingle(c *cli.Context, appName string, appf *appfile)
 	if err != nil {
 		return err
 	}
+	if p.funcName != "" {
+		ff.Name = p.funcName
+	}
+	if p.funcPath != "" {
+		ff.Path = p.funcPath
+	}
 	if appf != nil {
 		if dir == wd {
 			setRootFuncInfo(ff, appf.Name)
@@ -153,78 +241,186 @@ func (p *deploycmd) deploySingle(c *cli.Context, appName string, appf *appfile)
 	return p.deployFunc(c, appName, wd, fpath, ff)
 }
 
-// deployAll deploys all functions in an app.
-func (p *deploycmd) deployAll(c *cli.Context, appName string, appf *appfile) error {
-	wd := getWd()
+// deployFunc performs several actions to deploy to a functions server.
+// Parse func.yaml file, bump version, build image, push to registry, and
+// finally it will update function's route. Optionally,
+// the route can be overriden inside the func.yaml file.
+func (p *deploycmd) deployFunc(c *cli.Context, appName, baseDir, funcfilePath string, funcfile *funcfile) error {
+	if appName == "" {
+		return errors.New("app name must be provided, try `--app APP_NAME`.")
+	}
+	if err := funcfile.validate(); err != nil {
+		return err
+	}
+	setFuncDefaults(funcfilePath, funcfile)
+	funcfile, err := funcfile.resolve(p.env)
+	if err != nil {
+		return err
+	}
+	// the resolved secrets are only kept in memory, in the config of the route
+	if funcfile.Config, err = resolveSecrets(funcfile.Config); err != nil {
+		return err
+	}
+	if p.env != "" {
+		fmt.Printf("Deploying %s to app: %s at path: %s with the %s environment\n", funcfile.Name, appName, funcfile.Path, p.env)
+	} else {
+		fmt.Printf("Deploying %s to app: %s at path: %s\n", funcfile.Name, appName, funcfile.Path)
+	}
 
-	var funcFound bool
-	err := filepath.Walk(wd, func(path string, info os.FileInfo, err error) error {
-		if path != wd && info.IsDir() {
-			return nil
-		}
+	funcfile2, err := bumpIt(funcfilePath, Patch)
+	if err != nil {
+		return err
+	}
+	funcfile.Version = funcfile2.Version
+	// TODO: this whole funcfile handling needs some love, way too confusing. Only bump makes permanent changes to it.
 
-		if !isFuncfile(path, info) {
-			return nil
+	// remote builders push the images they build
+	pushed := remoteBuild()
+	if !pushed {
+		// Docker can only push an image for several platforms as it builds it
+		engine, err := langs.Engine()
+		if err != nil {
+			return err
+		}
+		platforms, err := langs.Platforms()
+		if err != nil {
+			return err
+		}
+		if len(p.targets) > 0 {
+			return p.deployToContexts(c, appName, funcfilePath, funcfile, engine, platforms)
 		}
+		pushed = !p.local && engine.BuildPushes(platforms)
+	}
+	_, err = buildfunc(funcfilePath, funcfile, p.noCache, pushed, false)
+	if err != nil {
+		return err
+	}
 
-		// TODO: test/try this again to speed up deploys.
-		if false && !isstale(path) {
-			return nil
+	if !p.local && !pushed {
+		if err := dockerPush(funcfile); err != nil {
+			return err
 		}
-		// Then we found a func file, so let's deploy it:
-		ff, err := parseFuncfile(path)
-		if err != nil {
+	}
+	if !p.local {
+		if err := publishSupplyChain(c, funcfilePath, funcfile, funcfile.ImageName(), os.Stdout); err != nil {
 			return err
 		}
-		dir := filepath.Dir(path)
-		if dir == wd {
-			setRootFuncInfo(ff, appName)
-		} else {
-			// change dirs
-			err = os.Chdir(dir)
-			if err != nil {
-				return err
-			}
-			p2 := strings.TrimPrefix(dir, wd)
-			if ff.Name == "" {
-				ff.Name = strings.Replace(p2, "/", "-", -1)
-				if strings.HasPrefix(ff.Name, "-") {
-					ff.Name = ff.Name[1:]
-				}
-				// todo: should we prefix appname too?
-			}
-			if ff.Path == "" {
-				ff.Path = p2
-			}
+	}
+
+	return p.updateRoute(c, appName, funcfilePath, funcfile)
+}
+
+// deployToContexts builds the function once, as the image it has in the first context, then pushes it to the
+// registry of each context and routes it there. The contexts are deployed to one after the other, or all at once
+// with --parallel, and a failure in one doesn't stop the others.
+func (p *deploycmd) deployToContexts(c *cli.Context, appName, funcfilePath string, ff *funcfile, engine langs.ContainerEngine, platforms []string) error {
+	// the registries of the contexts take the place of the one of func.yaml
+	ff.Registry = ""
+	os.Setenv(envFnRegistry, p.targets[0].Registry)
+	pushed := engine.BuildPushes(platforms)
+	if _, err := buildfunc(funcfilePath, ff, p.noCache, pushed, false); err != nil {
+		return err
+	}
+	built := ff.ImageName()
+
+	errs := make([]error, len(p.targets))
+	if p.parallel {
+		// the output of each context is held back until all are done, so it doesn't interleave
+		outputs := make([]bytes.Buffer, len(p.targets))
+		var wg sync.WaitGroup
+		for i := range p.targets {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = p.deployToContext(c, appName, funcfilePath, ff, built, pushed, p.targets[i], engine, platforms, &outputs[i])
+			}(i)
 		}
-		err = p.deployFunc(c, appName, wd, path, ff)
-		if err != nil {
-			return fmt.Errorf("deploy error on %s: %v", path, err)
+		wg.Wait()
+		for i, target := range p.targets {
+			fmt.Printf("==> %s\n", target.Name)
+			os.Stdout.Write(outputs[i].Bytes())
+		}
+	} else {
+		for i, target := range p.targets {
+			fmt.Printf("==> %s\n", target.Name)
+			errs[i] = p.deployToContext(c, appName, funcfilePath, ff, built, pushed, target, engine, platforms, os.Stdout)
 		}
+	}
 
-		now := time.Now()
-		os.Chtimes(path, now, now)
-		funcFound = true
+	failed := 0
+	fmt.Printf("Deployed %s to:\n", ff.Name)
+	for i, target := range p.targets {
+		if errs[i] != nil {
+			failed++
+			fmt.Printf("  %s: FAILED: %v\n", target.Name, errs[i])
+		} else {
+			fmt.Printf("  %s: %s at %s\n", target.Name, ff.ImageNameIn(target.Registry), target.APIURL)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("deploy failed in %d of %d contexts", failed, len(p.targets))
+	}
+	return nil
+}
+
+// deployToContext pushes the image built as built to the registry of target, and routes the function to it on the
+// functions server of target. All it prints, the API traces included, goes to out.
+func (p *deploycmd) deployToContext(c *cli.Context, appName, funcfilePath string, ff *funcfile, built string, pushed bool, target fnContext, engine langs.ContainerEngine, platforms []string, out io.Writer) error {
+	image := ff.ImageNameIn(target.Registry)
+	if err := validateImageName(image); err != nil {
 		return err
-	})
+	}
+	if err := copyImage(engine, platforms, built, image, pushed, out); err != nil {
+		return err
+	}
+
+	host, err := client.HostOf(target.APIURL)
 	if err != nil {
+		return fmt.Errorf("invalid API URL of context %s: %v", target.Name, err)
+	}
+	fmt.Fprintf(out, "Updating route %s using image %s...\n", ff.Path, image)
+	rt := &models.Route{}
+	if err := routeWithFuncFile(ff, rt); err != nil {
+		return fmt.Errorf("error getting route with funcfile: %s", err)
+	}
+	rt.Image = image
+	routes := routesCmd{client: client.NewAPIClientTracingTo(host, target.Token, out)}
+	if err := routes.putRoute(c, appName, ff.Path, rt); err != nil {
 		return err
 	}
+	return publishSupplyChain(c, funcfilePath, ff, image, out)
+}
 
-	if !funcFound {
-		return errors.New("no functions found to deploy")
+// copyImage pushes the image built as src as dst. Images the build pushed itself are copied across registries.
+func copyImage(engine langs.ContainerEngine, platforms []string, src, dst string, pushed bool, out io.Writer) error {
+	var steps [][]string
+	switch {
+	case pushed && src == dst:
+	case pushed:
+		args, err := engine.CopyArgs(src, dst)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, args)
+	default:
+		if src != dst {
+			steps = append(steps, []string{"tag", src, dst})
+		}
+		steps = append(steps, engine.PushArgs(dst, platforms))
+	}
+	for _, args := range steps {
+		cmd := engine.Command(args...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("error running %s %s: %v", engine.Name(), args[0], err)
+		}
 	}
 	return nil
 }
 
-// deployFunc performs several actions to deploy to a functions server.
-// Parse func.yaml file, bump version, build image, push to registry, and
-// finally it will update function's route. Optionally,
-// the route can be overriden inside the func.yaml file.
-func (p *deploycmd) deployFunc(c *cli.Context, appName, baseDir, funcfilePath string, funcfile *funcfile) error {
-	if appName == "" {
-		return errors.New("app name must be provided, try `--app APP_NAME`.")
-	}
+// setFuncDefaults names a function after its directory and routes it at it, unless its func.yaml says otherwise.
+func setFuncDefaults(funcfilePath string, funcfile *funcfile) {
 	dir := filepath.Dir(funcfilePath)
 	// get name from directory if it's not defined
 	if funcfile.Name == "" {
@@ -238,27 +434,6 @@ func (p *deploycmd) deployFunc(c *cli.Context, appName, baseDir, funcfilePath st
 		}
 
 	}
-	fmt.Printf("Deploying %s to app: %s at path: %s\n", funcfile.Name, appName, funcfile.Path)
-
-	funcfile2, err := bumpIt(funcfilePath, Patch)
-	if err != nil {
-		return err
-	}
-	funcfile.Version = funcfile2.Version
-	// TODO: this whole funcfile handling needs some love, way too confusing. Only bump makes permanent changes to it.
-
-	_, err = buildfunc(funcfilePath, funcfile, p.noCache)
-	if err != nil {
-		return err
-	}
-
-	if !p.local {
-		if err := dockerPush(funcfile); err != nil {
-			return err
-		}
-	}
-
-	return p.updateRoute(c, appName, funcfile)
 }
 
 func setRootFuncInfo(ff *funcfile, appName string) {
@@ -272,7 +447,7 @@ func setRootFuncInfo(ff *funcfile, appName string) {
 	}
 }
 
-func (p *deploycmd) updateRoute(c *cli.Context, appName string, ff *funcfile) error {
+func (p *deploycmd) updateRoute(c *cli.Context, appName, fpath string, ff *funcfile) error {
 	fmt.Printf("Updating route %s using image %s...\n", ff.Path, ff.ImageName())
 	if err := resetBasePath(p.Configuration); err != nil {
 		return fmt.Errorf("error setting endpoint: %v", err)
@@ -283,6 +458,10 @@ func (p *deploycmd) updateRoute(c *cli.Context, appName string, ff *funcfile) er
 	if err := routeWithFuncFile(ff, rt); err != nil {
 		return fmt.Errorf("error getting route with funcfile: %s", err)
 	}
+	if remoteBuild() {
+		// the route runs the very image the remote build pushed, even if the tag moves
+		rt.Image = pinnedImage(fpath, rt.Image)
+	}
 	return routesCmd.putRoute(c, appName, ff.Path, rt)
 }
 
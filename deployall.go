@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// appFunc is a function of an app, found by fn deploy --all.
+type appFunc struct {
+	// path is the path of its func.yaml
+	path string
+	ff   *funcfile
+}
+
+// deployResult is how the deploy of a function of --all went.
+type deployResult struct {
+	fn      *appFunc
+	err     error
+	elapsed time.Duration
+	// skipped is set when a function it depends on failed, and it wasn't deployed
+	skipped bool
+}
+
+// deployAll deploys all functions in an app, --jobs of them at once, each after the ones it depends_on. A failure
+// doesn't stop the functions that don't depend on the one failing, and the deploys are summed up at the end.
+func (p *deploycmd) deployAll(c *cli.Context, appName string, appf *appfile) error {
+	wd := getWd()
+	funcs, err := findAppFuncs(wd, appName)
+	if err != nil {
+		return err
+	}
+	if len(funcs) == 0 {
+		return errors.New("no functions found to deploy")
+	}
+	if err := checkDependencies(funcs); err != nil {
+		return err
+	}
+
+	deployFn := func(f *appFunc) error {
+		if err := os.Chdir(filepath.Dir(f.path)); err != nil {
+			return err
+		}
+		defer os.Chdir(wd)
+		return p.deployFunc(c, appName, wd, f.path, f.ff)
+	}
+	if p.jobs > 1 {
+		fmt.Printf("Deploying %d functions, %d at a time\n", len(funcs), p.jobs)
+		var mu sync.Mutex
+		deployFn = func(f *appFunc) error {
+			mu.Lock()
+			fmt.Printf("Deploying %s...\n", f.ff.Name)
+			mu.Unlock()
+			// the output of each function is held back until it's done, so it doesn't interleave
+			var out bytes.Buffer
+			start := time.Now()
+			err := p.deployFuncProcess(c, appName, f, &out)
+			mu.Lock()
+			defer mu.Unlock()
+			status := "done"
+			if err != nil {
+				status = "FAILED"
+			}
+			os.Stdout.Write(out.Bytes())
+			fmt.Printf("==> %s %s in %s\n", f.ff.Name, status, time.Since(start).Round(time.Second))
+			return err
+		}
+	}
+	results := scheduleDeploys(funcs, p.jobs, deployFn)
+
+	failed := 0
+	fmt.Printf("Deployed %d functions of app %s:\n", len(results), appName)
+	for _, r := range results {
+		switch {
+		case r.skipped:
+			failed++
+			fmt.Printf("  %s: SKIPPED: %v\n", r.fn.ff.Name, r.err)
+		case r.err != nil:
+			failed++
+			fmt.Printf("  %s: FAILED: %v\n", r.fn.ff.Name, r.err)
+		default:
+			fmt.Printf("  %s: %s at %s in %s\n", r.fn.ff.Name, r.fn.ff.Path, appName, r.elapsed.Round(time.Second))
+			now := time.Now()
+			os.Chtimes(r.fn.path, now, now)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("deploy failed for %d of %d functions", failed, len(results))
+	}
+	return nil
+}
+
+// deployFuncProcess deploys f with fn deploy, run in the directory of f, writing its output to out. Each function
+// has a process of its own, as builds change the working directory and the environment.
+func (p *deploycmd) deployFuncProcess(c *cli.Context, appName string, f *appFunc, out io.Writer) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{"deploy", "--app", appName, "--func-name", f.ff.Name, "--func-path", f.ff.Path}
+	args = append(args, passedFlags(c, "app", "all", "jobs", "func-name", "func-path")...)
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = filepath.Dir(f.path)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("deploy error on %s, see its output above", f.path)
+		}
+		return fmt.Errorf("deploy error on %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// passedFlags returns the flags of the command set on the command line, but the ones of except, as --name=value.
+// Slice flags are passed once for each of their values.
+func passedFlags(c *cli.Context, except ...string) []string {
+	skip := map[string]bool{}
+	for _, name := range except {
+		skip[name] = true
+	}
+	var args []string
+	for _, f := range c.Command.Flags {
+		names := strings.Split(f.GetName(), ",")
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if skip[name] {
+				break
+			}
+			if !c.IsSet(name) {
+				continue
+			}
+			flag := "--" + strings.TrimSpace(names[0]) + "="
+			switch f.(type) {
+			case cli.StringSliceFlag:
+				for _, v := range c.StringSlice(name) {
+					args = append(args, flag+v)
+				}
+			case cli.IntSliceFlag:
+				for _, v := range c.IntSlice(name) {
+					args = append(args, flag+strconv.Itoa(v))
+				}
+			default:
+				args = append(args, flag+c.String(name))
+			}
+			break
+		}
+	}
+	return args
+}
+
+// findAppFuncs returns the functions of the app in wd, named after the directory they are in and routed at it,
+// unless their func.yaml says otherwise. The function in wd itself is the root function of the app.
+func findAppFuncs(wd, appName string) ([]*appFunc, error) {
+	var funcs []*appFunc
+	err := filepath.Walk(wd, func(path string, info os.FileInfo, err error) error {
+		if path != wd && info.IsDir() {
+			return nil
+		}
+
+		if !isFuncfile(path, info) {
+			return nil
+		}
+
+		// TODO: test/try this again to speed up deploys.
+		if false && !isstale(path) {
+			return nil
+		}
+		ff, err := parseFuncfile(path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(path)
+		if dir == wd {
+			setRootFuncInfo(ff, appName)
+		} else {
+			p2 := strings.TrimPrefix(dir, wd)
+			if ff.Name == "" {
+				ff.Name = strings.Replace(p2, "/", "-", -1)
+				if strings.HasPrefix(ff.Name, "-") {
+					ff.Name = ff.Name[1:]
+				}
+				// todo: should we prefix appname too?
+			}
+			if ff.Path == "" {
+				ff.Path = p2
+			}
+		}
+		funcs = append(funcs, &appFunc{path: path, ff: ff})
+		return nil
+	})
+	return funcs, err
+}
+
+// checkDependencies checks that the functions depend on functions of the app, and not on themselves through others.
+func checkDependencies(funcs []*appFunc) error {
+	byName := map[string]*appFunc{}
+	for _, f := range funcs {
+		if other, ok := byName[f.ff.Name]; ok {
+			return fmt.Errorf("functions %s and %s are both named %s", other.path, f.path, f.ff.Name)
+		}
+		byName[f.ff.Name] = f
+	}
+	for _, f := range funcs {
+		for _, dep := range f.ff.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return fmt.Errorf("%s depends on %s, which is not a function of the app", f.ff.Name, dep)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("functions depend on each other: %s", strings.Join(append(chain, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range byName[name].ff.DependsOn {
+			if err := visit(dep, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, f := range funcs {
+		if err := visit(f.ff.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleDeploys deploys the functions with deployFn, jobs of them at once, starting each once the functions it
+// depends on are deployed. The functions depending on one that failed are skipped. The results are in the order
+// of funcs, whose dependencies must have been checked.
+func scheduleDeploys(funcs []*appFunc, jobs int, deployFn func(*appFunc) error) []*deployResult {
+	results := map[string]*deployResult{}
+	done := make(chan *deployResult)
+	pending := append([]*appFunc(nil), funcs...)
+	running := 0
+	for len(pending) > 0 || running > 0 {
+		for i := 0; i < len(pending) && running < jobs; {
+			f := pending[i]
+			ready, failed := true, ""
+			for _, dep := range f.ff.DependsOn {
+				r, ok := results[dep]
+				if !ok {
+					ready = false
+				} else if r.err != nil {
+					failed = dep
+				}
+			}
+			switch {
+			case failed != "":
+				results[f.ff.Name] = &deployResult{fn: f, skipped: true, err: fmt.Errorf("%s failed", failed)}
+				pending = append(pending[:i], pending[i+1:]...)
+				// the functions skipped so far may be the ones the earlier pending ones wait for
+				i = 0
+			case ready:
+				pending = append(pending[:i], pending[i+1:]...)
+				running++
+				go func(f *appFunc) {
+					start := time.Now()
+					err := deployFn(f)
+					done <- &deployResult{fn: f, err: err, elapsed: time.Since(start)}
+				}(f)
+			default:
+				i++
+			}
+		}
+		if running == 0 {
+			// nothing left can start
+			break
+		}
+		r := <-done
+		running--
+		results[r.fn.ff.Name] = r
+	}
+
+	var ordered []*deployResult
+	for _, f := range funcs {
+		if r, ok := results[f.ff.Name]; ok {
+			ordered = append(ordered, r)
+		}
+	}
+	return ordered
+}
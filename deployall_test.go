@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestFindAppFuncs(t *testing.T) {
+	wd, err := ioutil.TempDir("", "fn-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(wd)
+	files := map[string]string{
+		"func.yaml":            "runtime: go\n",
+		"users/func.yaml":      "runtime: go\ndepends_on: [root-app-root]\n",
+		"users/auth/func.yaml": "name: auth\nruntime: go\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(wd, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	funcs, err := findAppFuncs(wd, "root-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]string{}
+	for _, f := range funcs {
+		found[f.ff.Name] = f.ff.Path
+	}
+	expected := map[string]string{"root-app-root": "/", "users": "/users", "auth": "/users/auth"}
+	if len(found) != len(expected) {
+		t.Fatalf("expected functions %v, got %v", expected, found)
+	}
+	for name, path := range expected {
+		if found[name] != path {
+			t.Errorf("expected %s at %s, got %q", name, path, found[name])
+		}
+	}
+	if err := checkDependencies(funcs); err != nil {
+		t.Error(err)
+	}
+}
+
+func testAppFunc(name string, dependsOn ...string) *appFunc {
+	return &appFunc{path: name + "/func.yaml", ff: &funcfile{Name: name, DependsOn: dependsOn}}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		funcs    []*appFunc
+		expected string
+	}{
+		{[]*appFunc{testAppFunc("a", "b"), testAppFunc("b")}, ""},
+		{[]*appFunc{testAppFunc("a", "c")}, "not a function of the app"},
+		{[]*appFunc{testAppFunc("a", "b"), testAppFunc("b", "c"), testAppFunc("c", "a")}, "a -> b -> c -> a"},
+		{[]*appFunc{testAppFunc("a"), testAppFunc("a")}, "both named a"},
+	} {
+		err := checkDependencies(tc.funcs)
+		if tc.expected == "" && err != nil || tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("expected %q, got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestScheduleDeploys(t *testing.T) {
+	funcs := []*appFunc{
+		testAppFunc("web", "api"),
+		testAppFunc("api", "db"),
+		testAppFunc("db"),
+		testAppFunc("worker"),
+		testAppFunc("broken"),
+		testAppFunc("report", "broken"),
+		testAppFunc("mail", "report"),
+	}
+	var mu sync.Mutex
+	deployed := map[string]bool{}
+	running, maxRunning := 0, 0
+	results := scheduleDeploys(funcs, 2, func(f *appFunc) error {
+		mu.Lock()
+		for _, dep := range f.ff.DependsOn {
+			if !deployed[dep] {
+				t.Errorf("%s deployed before %s", f.ff.Name, dep)
+			}
+		}
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			deployed[f.ff.Name] = true
+			mu.Unlock()
+		}()
+		if f.ff.Name == "broken" {
+			return errors.New("build failed")
+		}
+		return nil
+	})
+
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 deploys at once, got %d", maxRunning)
+	}
+	if len(results) != len(funcs) {
+		t.Fatalf("expected a result for each function, got %d", len(results))
+	}
+	for i, r := range results {
+		name := funcs[i].ff.Name
+		if r.fn != funcs[i] {
+			t.Errorf("expected the results in the order of the functions, got %s at %d", r.fn.ff.Name, i)
+		}
+		switch name {
+		case "broken":
+			if r.err == nil || r.skipped {
+				t.Errorf("expected %s to fail, got %+v", name, r)
+			}
+		case "report", "mail":
+			if !r.skipped || deployed[name] {
+				t.Errorf("expected %s to be skipped, got %+v", name, r)
+			}
+		default:
+			if r.err != nil || !deployed[name] {
+				t.Errorf("expected %s to be deployed, got %+v", name, r)
+			}
+		}
+	}
+}
+
+func TestPassedFlags(t *testing.T) {
+	var passed []string
+	app := cli.NewApp()
+	app.Commands = []cli.Command{{
+		Name: "deploy",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "app"},
+			cli.BoolFlag{Name: "local, l"},
+			cli.StringSliceFlag{Name: "env, e"},
+			cli.StringFlag{Name: "registry"},
+		},
+		Action: func(c *cli.Context) error {
+			passed = passedFlags(c, "app")
+			return nil
+		},
+	}}
+	if err := app.Run([]string{"fn", "deploy", "--app", "myapp", "-l", "-e", "A=1", "-e", "B=2"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"--local=true", "--env=A=1", "--env=B=2"}; !reflect.DeepEqual(passed, expected) {
+		t.Errorf("expected %v, got %v", expected, passed)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	client "github.com/fnproject/cli/client"
+	"github.com/fnproject/cli/langs"
+	functions "github.com/funcy/functions_go"
+	"github.com/urfave/cli"
+)
+
+type developCmd struct {
+	appName  string
+	interval time.Duration
+	payload  string
+	logs     bool
+}
+
+func develop() cli.Command {
+	d := &developCmd{}
+
+	return cli.Command{
+		Name:  "develop",
+		Usage: "deploy the function to the local functions server and redeploy it as its files change",
+		Description: "Watches the function directory, leaving out the files matched by .dockerignore and .fnignore, " +
+			"and redeploys the function to the local functions server whenever they change. Runtimes which can " +
+			"copy changed sources onto the previous image do so rather than rebuilding it.",
+		Action: d.develop,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "app",
+				Usage:       "app name to deploy to",
+				Destination: &d.appName,
+			},
+			cli.DurationFlag{
+				Name:        "interval",
+				Usage:       "how often to look for changes",
+				Value:       500 * time.Millisecond,
+				Destination: &d.interval,
+			},
+			cli.StringFlag{
+				Name:        "payload",
+				Usage:       "call the function with payload after each deploy and print the result",
+				Destination: &d.payload,
+			},
+			cli.BoolFlag{
+				Name:        "logs",
+				Usage:       "stream the logs of the local functions server",
+				Destination: &d.logs,
+			},
+		},
+	}
+}
+
+func (d *developCmd) develop(c *cli.Context) error {
+	appName := d.appName
+	if appName == "" {
+		if appf, err := loadAppfile(); err == nil {
+			appName = appf.Name
+		}
+	}
+	if appName == "" {
+		return errors.New("app name must be provided, try `--app APP_NAME`.")
+	}
+	wd := getWd()
+	fpath, _, err := findAndParseFuncfile(wd)
+	if err != nil {
+		return err
+	}
+	engine, err := langs.Engine()
+	if err != nil {
+		return err
+	}
+	if d.logs {
+		stop := streamServerLogs(engine)
+		defer stop()
+	}
+
+	deployer := &deploycmd{RoutesApi: functions.NewRoutesApi(), local: true}
+	image, err := d.deploy(c, deployer, appName, wd, fpath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Deploy failed:", err)
+	}
+
+	watcher, err := langs.NewWatcher(filepath.Dir(fpath))
+	if err != nil {
+		return err
+	}
+	sigC := make(chan os.Signal, 2)
+	signal.Notify(sigC, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigC)
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	fmt.Println("Watching for changes, press Ctrl-C to stop.")
+	var pending []string
+	for {
+		select {
+		case <-sigC:
+			return nil
+		case <-ticker.C:
+		}
+		changed, err := watcher.Changes()
+		if err != nil {
+			return err
+		}
+		// wait for the files to settle, editors write several at once
+		if len(changed) > 0 {
+			pending = mergeChanges(pending, changed)
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		fmt.Println("Changed:", strings.Join(pending, ", "))
+		if image != "" && d.hotReloads(fpath, pending) {
+			image, err = d.hotReload(c, deployer, engine, appName, image, fpath, pending)
+		} else {
+			image, err = d.deploy(c, deployer, appName, wd, fpath)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Deploy failed:", err)
+		}
+		pending = nil
+		// version bumps aren't changes
+		if err := watcher.Reset(); err != nil {
+			return err
+		}
+	}
+}
+
+// deploy builds and deploys the function as fn deploy --local does, returning its image.
+func (d *developCmd) deploy(c *cli.Context, deployer *deploycmd, appName, wd, fpath string) (string, error) {
+	ff, err := parseFuncfile(fpath)
+	if err != nil {
+		return "", err
+	}
+	if err := deployer.deployFunc(c, appName, wd, fpath, ff); err != nil {
+		return "", err
+	}
+	d.call(appName, ff)
+	return ff.ImageName(), nil
+}
+
+// hotReloads reports whether the changed files can be copied onto the previous image: the runtime's helper says so,
+// none of them was removed and the function is built from a generated Dockerfile.
+func (d *developCmd) hotReloads(fpath string, changed []string) bool {
+	ff, err := parseFuncfile(fpath)
+	if err != nil || ff.Runtime == "" || ff.Runtime == funcfileDockerRuntime {
+		return false
+	}
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil || !helper.HotReloads(changed) {
+		return false
+	}
+	dir := filepath.Dir(fpath)
+	for _, file := range changed {
+		if file == filepath.Base(fpath) || !exists(filepath.Join(dir, filepath.FromSlash(file))) {
+			return false
+		}
+	}
+	return true
+}
+
+// hotReload builds the next version of the function by copying the changed files onto the previous image, and
+// routes it, returning the new image.
+func (d *developCmd) hotReload(c *cli.Context, deployer *deploycmd, engine langs.ContainerEngine, appName, previous, fpath string, changed []string) (string, error) {
+	ff, err := parseFuncfile(fpath)
+	if err != nil {
+		return "", err
+	}
+	setFuncDefaults(fpath, ff)
+	bumped, err := bumpIt(fpath, Patch)
+	if err != nil {
+		return "", err
+	}
+	ff.Version = bumped.Version
+
+	dir := filepath.Dir(fpath)
+	dockerfile, err := ioutil.TempFile(dir, "Dockerfile.develop")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(dockerfile.Name())
+	fmt.Fprintf(dockerfile, "FROM %s\n", previous)
+	for _, file := range changed {
+		fmt.Fprintf(dockerfile, "ADD [%q, %q]\n", file, path.Join("/function", file))
+	}
+	if err := dockerfile.Close(); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Copying the changes onto %v as %v\n", previous, ff.ImageName())
+	args, err := engine.BuildArgs(ff.ImageName(), nil, false)
+	if err != nil {
+		return "", err
+	}
+	cmd := engine.Command(append(args, "-f", dockerfile.Name(), ".")...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running %s build: %v", engine.Name(), err)
+	}
+	if err := deployer.updateRoute(c, appName, fpath, ff); err != nil {
+		return "", err
+	}
+	d.call(appName, ff)
+	return ff.ImageName(), nil
+}
+
+// call calls the deployed function with --payload, if set, printing what it returns.
+func (d *developCmd) call(appName string, ff *funcfile) {
+	if d.payload == "" {
+		return
+	}
+	u := url.URL{
+		Scheme: "http",
+		Host:   client.Host(),
+	}
+	u.Path = path.Join(u.Path, "r", appName, ff.Path)
+	if err := client.CallFN(u.String(), strings.NewReader(d.payload), os.Stdout, "", nil, true); err != nil {
+		fmt.Fprintln(os.Stderr, "Call failed:", err)
+	}
+	fmt.Println()
+}
+
+// streamServerLogs follows the logs of the functions server fn start runs until the returned func is called.
+func streamServerLogs(engine langs.ContainerEngine) func() {
+	cmd := engine.Command("logs", "-f", "--tail", "0", "functions")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not stream the functions server logs: %v\n", err)
+		return func() {}
+	}
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+}
+
+// mergeChanges adds the changed files to the ones pending a redeploy.
+func mergeChanges(pending, changed []string) []string {
+	for _, file := range changed {
+		found := false
+		for _, p := range pending {
+			if p == file {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pending = append(pending, file)
+		}
+	}
+	return pending
+}
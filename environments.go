@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// funcfileSchemaVersion is the latest func.yaml format, the first with environments
+const funcfileSchemaVersion = 3
+
+// funcfileEnv overrides the settings of a func.yaml when deploying to an environment, such as prod. Config,
+// headers and annotations are merged key by key over the ones of the func.yaml, the other settings replace them.
+type funcfileEnv struct {
+	Registry    string              `yaml:"registry,omitempty" json:"registry,omitempty"`
+	Memory      uint64              `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Timeout     *int32              `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	IDLETimeout *int32              `yaml:"idle_timeout,omitempty" json:"idle_timeout,omitempty"`
+	Config      map[string]string   `yaml:"config,omitempty" json:"config,omitempty"`
+	Headers     map[string][]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Annotations map[string]string   `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// resolve returns the func.yaml with the overrides of env merged over it, and no environments. An empty env gives the
+// settings of the func.yaml itself.
+func (ff *funcfile) resolve(env string) (*funcfile, error) {
+	r := *ff
+	r.Environments = nil
+	if env == "" {
+		return &r, nil
+	}
+	e, ok := ff.Environments[env]
+	if !ok || e == nil {
+		defined := "none"
+		if names := environmentNames(ff); len(names) > 0 {
+			defined = strings.Join(names, ", ")
+		}
+		return nil, fmt.Errorf("environment %q is not defined in func.yaml, it has %s", env, defined)
+	}
+	if e.Registry != "" {
+		r.Registry = e.Registry
+	}
+	if e.Memory != 0 {
+		r.Memory = e.Memory
+	}
+	if e.Timeout != nil {
+		r.Timeout = e.Timeout
+	}
+	if e.IDLETimeout != nil {
+		r.IDLETimeout = e.IDLETimeout
+	}
+	r.Config = mergeStrings(ff.Config, e.Config)
+	r.Annotations = mergeStrings(ff.Annotations, e.Annotations)
+	if len(e.Headers) > 0 {
+		r.Headers = map[string][]string{}
+		for k, v := range ff.Headers {
+			r.Headers[k] = v
+		}
+		for k, v := range e.Headers {
+			r.Headers[k] = v
+		}
+	}
+	return &r, nil
+}
+
+func mergeStrings(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	r := map[string]string{}
+	for k, v := range base {
+		r[k] = v
+	}
+	for k, v := range overrides {
+		r[k] = v
+	}
+	return r
+}
+
+// environmentNames returns the names of the environments of the func.yaml, sorted
+func environmentNames(ff *funcfile) []string {
+	var names []string
+	for name := range ff.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the schema version and the environments of the func.yaml, whose overrides must be within the
+// route limits of the functions server.
+func (ff *funcfile) validate() error {
+	if ff.SchemaVersion > funcfileSchemaVersion {
+		return fmt.Errorf("func.yaml schema_version %d is newer than this fn supports, %d, update fn", ff.SchemaVersion, funcfileSchemaVersion)
+	}
+	if len(ff.Environments) == 0 {
+		return nil
+	}
+	if ff.SchemaVersion != 0 && ff.SchemaVersion < funcfileSchemaVersion {
+		return fmt.Errorf("environments need func.yaml schema_version %d, it is %d", funcfileSchemaVersion, ff.SchemaVersion)
+	}
+	maxTimeout := maxSyncTimeout
+	if ff.Type == "async" {
+		maxTimeout = maxAsyncTimeout
+	}
+	for _, name := range environmentNames(ff) {
+		e := ff.Environments[name]
+		switch {
+		case strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t/"):
+			return fmt.Errorf("environment name %q must not be empty nor contain spaces or slashes", name)
+		case e == nil:
+			return fmt.Errorf("environment %s has no settings", name)
+		case e.Memory != 0 && e.Memory < minMemory:
+			return fmt.Errorf("environment %s: memory %d MB is below the minimum of %d MB", name, e.Memory, minMemory)
+		case e.Timeout != nil && (*e.Timeout <= 0 || int(*e.Timeout) > maxTimeout):
+			return fmt.Errorf("environment %s: timeout %d must be between 1 and %d seconds for %s calls", name, *e.Timeout, maxTimeout, routeType(ff))
+		case e.IDLETimeout != nil && (*e.IDLETimeout <= 0 || *e.IDLETimeout > maxIdleTimeout):
+			return fmt.Errorf("environment %s: idle_timeout %d must be between 1 and %d seconds", name, *e.IDLETimeout, maxIdleTimeout)
+		}
+		for key := range e.Config {
+			if key == "" {
+				return fmt.Errorf("environment %s: config keys cannot be empty", name)
+			}
+		}
+	}
+	return nil
+}
+
+func inspectCmd() cli.Command {
+	return cli.Command{
+		Name:  "inspect",
+		Usage: "inspect local resources",
+		Subcommands: []cli.Command{
+			{
+				Name:      "function",
+				Usage:     "show the func.yaml of the function in the current directory, or in path",
+				ArgsUsage: "[path]",
+				Action:    inspectFunction,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "resolved",
+						Usage: "show the effective configuration fn deploy uses, with the overrides of --env merged in",
+					},
+					cli.StringFlag{
+						Name:  "env",
+						Usage: "environment of the func.yaml to resolve",
+					},
+				},
+			},
+		},
+	}
+}
+
+func inspectFunction(c *cli.Context) error {
+	env := c.String("env")
+	if env != "" && !c.Bool("resolved") {
+		return fmt.Errorf("--env is used with --resolved")
+	}
+	dir := getWd()
+	if path := c.Args().First(); path != "" {
+		dir = filepath.Join(dir, path)
+	}
+	fpath, ff, err := findAndParseFuncfile(dir)
+	if err != nil {
+		return err
+	}
+	if err := ff.validate(); err != nil {
+		return err
+	}
+	if c.Bool("resolved") {
+		setFuncDefaults(fpath, ff)
+		if ff, err = ff.resolve(env); err != nil {
+			return err
+		}
+	}
+	return render(c, ff, func() error { return writeYAML(os.Stdout, ff) })
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	timeout := int32(60)
+	ff := &funcfile{
+		SchemaVersion: 3,
+		Name:          "hello",
+		Memory:        128,
+		Config:        map[string]string{"DB_URL": "postgres://dev", "LOG_LEVEL": "debug"},
+		Annotations:   map[string]string{"team": "payments"},
+		Environments: map[string]*funcfileEnv{
+			"prod": {
+				Registry:    "registry.example.com/prod",
+				Memory:      512,
+				Timeout:     &timeout,
+				Config:      map[string]string{"DB_URL": "postgres://prod"},
+				Annotations: map[string]string{"tier": "critical"},
+			},
+		},
+	}
+	if err := ff.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := ff.resolve("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Memory != 512 || prod.Timeout == nil || *prod.Timeout != 60 || prod.Environments != nil {
+		t.Errorf("expected the prod overrides, got %+v", prod)
+	}
+	if expected := map[string]string{"DB_URL": "postgres://prod", "LOG_LEVEL": "debug"}; !reflect.DeepEqual(prod.Config, expected) {
+		t.Errorf("expected config %v, got %v", expected, prod.Config)
+	}
+	if expected := map[string]string{"team": "payments", "tier": "critical"}; !reflect.DeepEqual(prod.Annotations, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, prod.Annotations)
+	}
+	if image := prod.ImageName(); image != "registry.example.com/prod/hello" {
+		t.Errorf("expected the image in the prod registry, got %s", image)
+	}
+	os.Setenv(envFnRegistry, "localhost:5000")
+	if image := prod.ImageName(); image != "localhost:5000/hello" {
+		t.Errorf("expected FN_REGISTRY to win over the registry of func.yaml, got %s", image)
+	}
+	os.Unsetenv(envFnRegistry)
+	if ff.Memory != 128 || ff.Config["DB_URL"] != "postgres://dev" {
+		t.Errorf("expected resolving to leave the func.yaml as it is, got %+v", ff)
+	}
+
+	if _, err := ff.resolve("stage"); err == nil || !strings.Contains(err.Error(), "it has prod") {
+		t.Errorf("expected an error for an undefined environment, got %v", err)
+	}
+}
+
+func TestValidateEnvironments(t *testing.T) {
+	for _, tt := range []struct {
+		ff       funcfile
+		expected string
+	}{
+		{funcfile{SchemaVersion: 4}, "newer"},
+		{funcfile{SchemaVersion: 2, Environments: map[string]*funcfileEnv{"prod": {Memory: 256}}}, "schema_version 3"},
+		{funcfile{Environments: map[string]*funcfileEnv{"prod": {Memory: 32}}}, "below the minimum"},
+		{funcfile{Environments: map[string]*funcfileEnv{"my env": {Memory: 256}}}, "spaces"},
+		{funcfile{Environments: map[string]*funcfileEnv{"prod": nil}}, "no settings"},
+	} {
+		if err := tt.ff.validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/fnproject/cli/langs"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -43,7 +44,9 @@ type Expects struct {
 }
 
 type funcfile struct {
-	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// SchemaVersion is the version of the func.yaml format, 3 for files with Environments. Older files leave it out.
+	SchemaVersion int    `yaml:"schema_version,omitempty" json:"schema_version,omitempty"`
+	Name          string `yaml:"name,omitempty" json:"name,omitempty"`
 
 	// Build params
 	Version    string   `yaml:"version,omitempty" json:"version,omitempty"`
@@ -54,6 +57,17 @@ type funcfile struct {
 	Tests      []fftest `yaml:"tests,omitempty" json:"tests,omitempty"`
 	BuildImage string   `yaml:"build_image,omitempty" json:"build_image,omitempty"` // Image to use as base for building
 	RunImage   string   `yaml:"run_image,omitempty" json:"run_image,omitempty"`     // Image to use for running
+	// BuildExtra adds instructions to the Dockerfile generated for the runtime, see langs.DockerfileExtensions.
+	BuildExtra *langs.DockerfileExtensions `yaml:"build_extra,omitempty" json:"build_extra,omitempty"`
+	// BuildMode is jvm or native, compiling JVM functions into GraalVM native images, see langs.SetBuildMode.
+	BuildMode string `yaml:"build_mode,omitempty" json:"build_mode,omitempty"`
+	// RegistryMirror and ImageDigests add to FN_REGISTRY_MIRROR and FN_IMAGE_DIGESTS, see langs.ImageMirror.
+	RegistryMirror string            `yaml:"registry_mirror,omitempty" json:"registry_mirror,omitempty"`
+	ImageDigests   map[string]string `yaml:"image_digests,omitempty" json:"image_digests,omitempty"`
+	// Registry is the registry the image is pushed to, unless --registry or FN_REGISTRY set one.
+	Registry string `yaml:"registry,omitempty" json:"registry,omitempty"`
+	// Annotations are added to the image as labels.
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
 
 	// Route params
 	Type        string              `yaml:"type,omitempty" json:"type,omitempty"`
@@ -67,13 +81,27 @@ type funcfile struct {
 
 	// Run/test
 	Expects Expects `yaml:"expects,omitempty" json:"expects,omitempty"`
+
+	// DependsOn names the functions of the app fn deploy --all deploys before this one.
+	DependsOn []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+
+	// Environments override the settings above when deploying with fn deploy --env, see resolve.
+	Environments map[string]*funcfileEnv `yaml:"environments,omitempty" json:"environments,omitempty"`
 }
 
 func (ff *funcfile) ImageName() string {
+	// the registry of the command line wins over the one of func.yaml
+	if reg := os.Getenv(envFnRegistry); reg != "" {
+		return ff.ImageNameIn(reg)
+	}
+	return ff.ImageNameIn(ff.Registry)
+}
+
+// ImageNameIn is the image name of the function in registry, unless its name includes the registry already.
+func (ff *funcfile) ImageNameIn(reg string) string {
 	fname := ff.Name
 	if !strings.Contains(fname, "/") {
-		// then we'll prefix FN_REGISTRY
-		reg := os.Getenv(envFnRegistry)
+		// then we'll prefix the registry
 		if reg != "" {
 			if reg[len(reg)-1] != '/' {
 				reg += "/"
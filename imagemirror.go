@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fnproject/cli/langs"
+)
+
+// the registry mirrors and image digests FN_REGISTRY_MIRROR and FN_IMAGE_DIGESTS set, which the func.yaml of each
+// function adds to
+var (
+	defaultRegistryMirror = os.Getenv(langs.RegistryMirrorEnv)
+	defaultImageDigests   = os.Getenv(langs.ImageDigestsEnv)
+)
+
+// buildContext is the --context of the build, whose registry mirror and image digests the environment and func.yaml
+// add to
+var buildContext fnContext
+
+// setImageMirror passes the registry mirrors and image digests of the function on to the helper: those of the
+// func.yaml, over those of the environment, over those of the context.
+func setImageMirror(ff *funcfile) error {
+	envDigests, err := langs.ParseImageDigests(defaultImageDigests)
+	if err != nil {
+		return fmt.Errorf("%s: %v", langs.ImageDigestsEnv, err)
+	}
+	registries, digests := map[string]string{}, map[string]string{}
+	for _, layer := range []struct {
+		source  string
+		mirror  string
+		digests map[string]string
+	}{
+		{"context " + buildContext.Name, buildContext.RegistryMirror, buildContext.ImageDigests},
+		{langs.RegistryMirrorEnv, defaultRegistryMirror, envDigests},
+		{"func.yaml", ff.RegistryMirror, ff.ImageDigests},
+	} {
+		r, d, err := parseImageMirror(layer.mirror, layer.digests)
+		if err != nil {
+			return fmt.Errorf("%s: %v", layer.source, err)
+		}
+		for registry, mirror := range r {
+			registries[registry] = mirror
+		}
+		for image, digest := range d {
+			digests[image] = digest
+		}
+	}
+	os.Setenv(langs.RegistryMirrorEnv, langs.FormatImagePairs(registries))
+	os.Setenv(langs.ImageDigestsEnv, langs.FormatImagePairs(digests))
+	return nil
+}
+
+// parseImageMirror checks a registry mirror setting and the digests images are pinned to, returning them keyed as
+// langs.ImageMirror keys them
+func parseImageMirror(mirror string, digests map[string]string) (map[string]string, map[string]string, error) {
+	registries, err := langs.ParseRegistryMirror(mirror)
+	if err != nil {
+		return nil, nil, err
+	}
+	pinned := map[string]string{}
+	for image, digest := range digests {
+		if err := langs.CheckImageDigest(image, digest); err != nil {
+			return nil, nil, err
+		}
+		pinned[langs.CanonicalImage(image)] = digest
+	}
+	return registries, pinned, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fnproject/cli/langs"
+)
+
+func TestSetImageMirror(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	defer os.Unsetenv(langs.RegistryMirrorEnv)
+	defer os.Unsetenv(langs.ImageDigestsEnv)
+	defer func(mirror, digests string, ctx fnContext) {
+		defaultRegistryMirror, defaultImageDigests, buildContext = mirror, digests, ctx
+	}(defaultRegistryMirror, defaultImageDigests, buildContext)
+
+	buildContext = fnContext{
+		Name:           "prod",
+		RegistryMirror: "context.example.com/hub,gcr.io=context.example.com/gcr",
+		ImageDigests:   map[string]string{"fnproject/go:dev": digest},
+	}
+	defaultRegistryMirror = "env.example.com/hub"
+	defaultImageDigests = ""
+	ff := &funcfile{RegistryMirror: "gcr.io=func.example.com/gcr"}
+	if err := setImageMirror(ff); err != nil {
+		t.Fatal(err)
+	}
+	m, err := langs.LoadImageMirror()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := m.Image("fnproject/go:dev"); image != "env.example.com/hub/fnproject/go:dev@"+digest {
+		t.Errorf("expected the mirror of the environment and the digest of the context, got %s", image)
+	}
+	if image := m.Image("gcr.io/distroless/base"); image != "func.example.com/gcr/distroless/base" {
+		t.Errorf("expected the mirror of func.yaml, got %s", image)
+	}
+
+	// func.yaml settings only last for the build of the function
+	if err := setImageMirror(&funcfile{}); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ = langs.LoadImageMirror(); m.Image("gcr.io/distroless/base") != "context.example.com/gcr/distroless/base" {
+		t.Errorf("expected the mirror of the context, got %s", m.Image("gcr.io/distroless/base"))
+	}
+
+	ff = &funcfile{ImageDigests: map[string]string{"fnproject/go:dev": "latest"}}
+	if err := setImageMirror(ff); err == nil {
+		t.Error("expected an invalid digest to be rejected")
+	}
+}
@@ -20,6 +20,7 @@ package main
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -32,30 +33,17 @@ import (
 	"github.com/urfave/cli"
 )
 
-var (
-	fileExtToRuntime = map[string]string{
-		".go":   "go",
-		".js":   "node",
-		".rb":   "ruby",
-		".py":   "python",
-		".php":  "php",
-		".rs":   "rust",
-		".cs":   "dotnet",
-		".fs":   "dotnet",
-		".java": "java",
-	}
-
-	fnInitRuntimes []string
-)
-
-func init() {
-	for rt := range fileExtToRuntime {
-		fnInitRuntimes = append(fnInitRuntimes, rt)
-	}
-}
+// defaultTemplatePackage is the package templates put sources in unless --package is given, the one of the
+// boilerplate of the Java helper.
+const defaultTemplatePackage = "com.example.fn"
 
 type initFnCmd struct {
-	force bool
+	force       bool
+	regenerate  bool
+	offline     bool
+	interactive bool
+	template    string
+	pkg         string
 	funcfile
 }
 
@@ -71,11 +59,37 @@ func initFlags(a *initFnCmd) []cli.Flag {
 			Usage:       "overwrite existing func.yaml",
 			Destination: &a.force,
 		},
+		cli.BoolFlag{
+			Name:        "regenerate",
+			Usage:       "regenerate the function boilerplate, replacing existing files",
+			Destination: &a.regenerate,
+		},
+		cli.BoolFlag{
+			Name:        "offline",
+			Usage:       "generate without network access, using the FDK versions resolved before",
+			Destination: &a.offline,
+		},
+		cli.BoolFlag{
+			Name:        "interactive",
+			Usage:       "ask for the runtime, route type, memory and registry of the function",
+			Destination: &a.interactive,
+		},
 		cli.StringFlag{
 			Name:        "runtime",
-			Usage:       "choose an existing runtime - " + strings.Join(fnInitRuntimes, ", "),
+			Usage:       "choose an existing runtime - " + strings.Join(langs.KnownRuntimes(), ", ") + ", or that of a fn-lang-<runtime> plugin",
 			Destination: &a.Runtime,
 		},
+		cli.StringFlag{
+			Name:        "template",
+			Usage:       "generate the boilerplate from a template of the runtime, as name or source/name, see fn templates",
+			Destination: &a.template,
+		},
+		cli.StringFlag{
+			Name:        "package",
+			Usage:       "package the template puts sources in",
+			Destination: &a.pkg,
+			Value:       defaultTemplatePackage,
+		},
 		cli.StringFlag{
 			Name:        "entrypoint",
 			Usage:       "entrypoint is the command to run to start this function - equivalent to Dockerfile ENTRYPOINT.",
@@ -113,6 +127,9 @@ func initFn() cli.Command {
 
 func (a *initFnCmd) init(c *cli.Context) error {
 	wd := getWd()
+	if a.offline {
+		os.Setenv("FN_OFFLINE", "true")
+	}
 
 	var err error
 	path := c.Args().First()
@@ -159,6 +176,12 @@ func (a *initFnCmd) init(c *cli.Context) error {
 	
 	fmt.Println(runHeader + "\n")
 
+	if a.interactive {
+		if err := a.askSettings(c, newWizard(os.Stdin, os.Stdout)); err != nil {
+			return err
+		}
+	}
+
 	err = a.buildFuncFile(c)
 
 	if err != nil {
@@ -166,6 +189,9 @@ func (a *initFnCmd) init(c *cli.Context) error {
 	}
 
 	runtimeSpecified := a.Runtime != ""
+	if a.template != "" && (!runtimeSpecified || a.Runtime == funcfileDockerRuntime) {
+		return errors.New("--template requires --runtime")
+	}
 
 	if runtimeSpecified && a.Runtime != funcfileDockerRuntime {
 		err := a.generateBoilerplate()
@@ -183,17 +209,52 @@ func (a *initFnCmd) init(c *cli.Context) error {
 }
 
 func (a *initFnCmd) generateBoilerplate() error {
-	helper := langs.GetLangHelper(a.Runtime)
-	if helper != nil && helper.HasBoilerplate() {
-		if err := helper.GenerateBoilerplate(); err != nil {
-			if err == langs.ErrBoilerplateExists {
-				return nil
+	if a.template != "" {
+		return a.generateFromTemplate()
+	}
+	helper, err := langs.GetLangHelper(a.Runtime)
+	if err == nil && helper.HasBoilerplate() {
+		ctx, stop := interruptContext()
+		defer stop()
+		generate := func() error { return langs.GenerateBoilerplateContext(ctx, helper) }
+		if a.regenerate {
+			generate = helper.RegenerateBoilerplate
+		}
+		err := generate()
+		switch {
+		case err == nil:
+			if err := langs.MarkBoilerplateExecutable(helper); err != nil {
+				return err
 			}
+			fmt.Println("Function boilerplate generated.")
+		case errors.Is(err, langs.ErrBoilerplateExists) && a.force:
+			// --force only replaces func.yaml, the function's own files are kept
+			fmt.Println("Function boilerplate already exists, keeping it.")
+		default:
 			return err
 		}
-		fmt.Println("Function boilerplate generated.")
 	}
-	return nil
+	return langs.GenerateScaffoldExtras(a.Runtime)
+}
+
+// generateFromTemplate generates the boilerplate from the --template of the runtime rather than the helper's own.
+func (a *initFnCmd) generateFromTemplate() error {
+	wd, err := langs.FunctionDir()
+	if err != nil {
+		return err
+	}
+	name := a.Name
+	if name == "" {
+		name = filepath.Base(wd)
+	}
+	helper, _ := langs.GetLangHelper(a.Runtime)
+	ctx := context.Background()
+	vars := langs.NewTemplateVars(ctx, helper, name, a.Runtime, a.pkg)
+	if err := langs.GenerateFromTemplate(ctx, a.Runtime, a.template, vars, a.regenerate); err != nil {
+		return err
+	}
+	fmt.Printf("Function boilerplate generated from the %s template.\n", a.template)
+	return langs.GenerateScaffoldExtras(a.Runtime)
 }
 
 func (a *initFnCmd) buildFuncFile(c *cli.Context) error {
@@ -219,7 +280,7 @@ func (a *initFnCmd) buildFuncFile(c *cli.Context) error {
 
 	var rt string
 	if a.Runtime == "" {
-		rt, err = detectRuntime(wd)
+		rt, err = langs.DetectRuntime(wd)
 		if err != nil {
 			return err
 		}
@@ -228,8 +289,8 @@ func (a *initFnCmd) buildFuncFile(c *cli.Context) error {
 	} else {
 		fmt.Println("Runtime:", a.Runtime)
 	}
-	helper := langs.GetLangHelper(a.Runtime)
-	if helper == nil {
+	helper, err := langs.GetLangHelper(a.Runtime)
+	if err != nil {
 		fmt.Printf("Init does not support the %s runtime, you'll have to create your own Dockerfile for this function", a.Runtime)
 	}
 
@@ -249,19 +310,3 @@ func (a *initFnCmd) buildFuncFile(c *cli.Context) error {
 
 	return nil
 }
-
-func detectRuntime(path string) (runtime string, err error) {
-	for ext, runtime := range fileExtToRuntime {
-		filenames := []string{
-			filepath.Join(path, fmt.Sprintf("func%s", ext)),
-			filepath.Join(path, fmt.Sprintf("Func%s", ext)),
-			filepath.Join(path, fmt.Sprintf("src/main%s", ext)), // rust
-		}
-		for _, filename := range filenames {
-			if exists(filename) {
-				return runtime, nil
-			}
-		}
-	}
-	return "", fmt.Errorf("no supported files found to guess runtime, please set runtime explicitly with --runtime flag")
-}
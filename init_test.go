@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fnproject/cli/langs"
+)
+
+func TestInitExistingBoilerplate(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile("func.go", []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &initFnCmd{}
+	a.Runtime = "go"
+	if err := a.generateBoilerplate(); !errors.Is(err, langs.ErrBoilerplateExists) {
+		t.Errorf("expected the existing boilerplate to be reported, got %v", err)
+	}
+	a.force = true
+	if err := a.generateBoilerplate(); err != nil {
+		t.Errorf("expected --force to keep the existing boilerplate, got %v", err)
+	}
+	if b, _ := ioutil.ReadFile("func.go"); string(b) != "package main\n" {
+		t.Errorf("expected func.go to be kept, got %q", b)
+	}
+}
@@ -0,0 +1,152 @@
+package langs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// archInspector returns the architectures an image's manifest publishes. It is a variable so tests can stub out
+// the registry.
+var archInspector = engineManifestArchs
+
+type manifestPlatform struct {
+	Architecture string `json:"architecture"`
+}
+
+// manifestDescriptor is a manifest as docker manifest inspect --verbose prints it
+type manifestDescriptor struct {
+	Descriptor struct {
+		Platform manifestPlatform `json:"platform"`
+	}
+}
+
+// manifestList is a manifest list, or OCI image index, as the registry serves it
+type manifestList struct {
+	Manifests []struct {
+		Platform manifestPlatform `json:"platform"`
+	} `json:"manifests"`
+}
+
+func engineManifestArchs(image string) ([]string, error) {
+	engine, err := Engine()
+	if err != nil {
+		return nil, err
+	}
+	out, err := engine.Command(engine.ManifestInspectArgs(image)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Could not inspect the manifest of %s with %s: %v", image, engine.Name(), err)
+	}
+	archs, err := manifestArchs(out)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse the manifest of %s: %v", image, err)
+	}
+	return archs, nil
+}
+
+// manifestArchs returns the architectures of an inspected manifest: a descriptor per platform, or just the one for
+// a single arch image, printed by Docker, or the manifest list itself, printed by the other engines.
+func manifestArchs(out []byte) ([]string, error) {
+	archs := []string{}
+	var descriptors []manifestDescriptor
+	if err := json.Unmarshal(out, &descriptors); err == nil {
+		for _, d := range descriptors {
+			archs = append(archs, d.Descriptor.Platform.Architecture)
+		}
+		return archs, nil
+	}
+	var manifest struct {
+		manifestDescriptor
+		manifestList
+	}
+	if err := json.Unmarshal(out, &manifest); err != nil {
+		return nil, err
+	}
+	if arch := manifest.Descriptor.Platform.Architecture; arch != "" {
+		return append(archs, arch), nil
+	}
+	if len(manifest.Manifests) == 0 {
+		return nil, errors.New("it is not a manifest list, and names no platform")
+	}
+	for _, m := range manifest.Manifests {
+		archs = append(archs, m.Platform.Architecture)
+	}
+	return archs, nil
+}
+
+// SupportsArch reports whether the build and run images both publish the given architecture, such as arm64.
+func SupportsArch(images *Images, arch string) (bool, error) {
+	image, err := unsupportedArchImage(images, arch)
+	return image == "", err
+}
+
+// CheckTargetArch returns an error naming the image lacking the architecture set with FN_TARGET_ARCH, if any.
+func CheckTargetArch(images *Images) error {
+	arch := os.Getenv("FN_TARGET_ARCH")
+	if arch == "" {
+		return nil
+	}
+	image, err := unsupportedArchImage(images, arch)
+	if err != nil {
+		return err
+	}
+	if image != "" {
+		return fmt.Errorf("The image %s does not publish a %s variant, set in FN_TARGET_ARCH", image, arch)
+	}
+	return nil
+}
+
+// CheckPlatforms returns an error naming the image lacking the architecture of any of the platforms, taking the
+// images the helper declares for the architecture in place of the default ones.
+func CheckPlatforms(images *Images, platforms []string) error {
+	for _, p := range platforms {
+		arch := PlatformArch(p)
+		image, err := unsupportedArchImage(images, arch)
+		if err != nil {
+			return err
+		}
+		if image != "" {
+			return fmt.Errorf("The image %s does not publish a %s variant, needed to build for %s", image, arch, p)
+		}
+	}
+	return nil
+}
+
+func unsupportedArchImage(resolved *Images, arch string) (string, error) {
+	buildImage, runImage := resolved.ArchImages(arch)
+	if buildImage == "" {
+		buildImage = resolved.Build
+	}
+	if runImage == "" {
+		runImage = resolved.Run
+	}
+	images := []string{buildImage}
+	if resolved.lh.IsMultiStage() && runImage != buildImage {
+		images = append(images, runImage)
+	}
+	// the images are inspected where builds pull them from
+	mirror, err := LoadImageMirror()
+	if err != nil {
+		return "", err
+	}
+	for _, image := range images {
+		archs, err := archInspector(mirror.Image(image))
+		if err != nil {
+			return "", err
+		}
+		if !containsString(archs, arch) {
+			return image, nil
+		}
+	}
+	return "", nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package langs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSupportsArch(t *testing.T) {
+	defer func(inspector func(string) ([]string, error)) { archInspector = inspector }(archInspector)
+	archInspector = func(image string) ([]string, error) {
+		if image == "fnproject/fn-java-fdk-build:1.0.56" {
+			return []string{"amd64", "arm64"}, nil
+		}
+		return []string{"amd64"}, nil
+	}
+	images, err := ResolveImages(&JavaLangHelper{version: "1.8"}, "java8", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := SupportsArch(images, "amd64"); err != nil || !ok {
+		t.Errorf("expected amd64 to be supported, got %v, %v", ok, err)
+	}
+	if ok, err := SupportsArch(images, "arm64"); err != nil || ok {
+		t.Errorf("expected arm64 to be unsupported by the run image, got %v, %v", ok, err)
+	}
+
+	os.Setenv("FN_TARGET_ARCH", "arm64")
+	defer os.Unsetenv("FN_TARGET_ARCH")
+	if err := CheckTargetArch(images); err == nil {
+		t.Error("expected the unsupported target architecture to be rejected")
+	}
+
+	// the overridden run image is checked in place of the helper's
+	os.Setenv("FN_JAVA8_RUN_IMAGE", "fnproject/fn-java-fdk-build:1.0.56")
+	defer os.Unsetenv("FN_JAVA8_RUN_IMAGE")
+	if images, err = ResolveImages(&JavaLangHelper{version: "1.8"}, "java8", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckTargetArch(images); err != nil {
+		t.Errorf("expected the overridden run image to publish arm64, got %v", err)
+	}
+}
+
+func TestManifestArchs(t *testing.T) {
+	for out, expected := range map[string]string{
+		// docker manifest inspect --verbose of a multi-arch and a single arch image
+		`[{"Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}},
+		  {"Descriptor": {"platform": {"architecture": "arm64", "os": "linux"}}}]`: "amd64,arm64",
+		`{"Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}}`: "amd64",
+		// the manifest list podman and nerdctl print
+		`{"schemaVersion": 2, "manifests": [{"platform": {"architecture": "arm64", "os": "linux"}}]}`: "arm64",
+	} {
+		if archs, err := manifestArchs([]byte(out)); err != nil || strings.Join(archs, ",") != expected {
+			t.Errorf("expected %s, got %v, %v", expected, archs, err)
+		}
+	}
+	if _, err := manifestArchs([]byte(`{"schemaVersion": 2, "layers": []}`)); err == nil {
+		t.Error("expected a manifest without platforms to be rejected")
+	}
+}
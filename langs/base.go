@@ -4,17 +4,94 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
 )
 
 //used to indicate the default supported version of java
 const defaultJavaSupportedVersion = "9"
 
+// defaultFDKListenPort is the HTTP port FDKs listen on unless a helper says otherwise
+const defaultFDKListenPort = 8080
+
+// Log formats reported by LogFormat
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// defaultMemory is the memory in MB Fn gives functions unless told otherwise
+const defaultMemory = 128
+
+// defaultMaxPayloadBytes is the request size any FDK handles safely
+const defaultMaxPayloadBytes = 1 << 20
+
+// Cold start classes reported by ColdStartClass, for schedulers deciding what to pre-warm
+const (
+	ColdStartFast   = "fast"
+	ColdStartMedium = "medium"
+	ColdStartSlow   = "slow"
+)
+
 var (
 	ErrBoilerplateExists = errors.New("Function boilerplate already exists")
+	ErrPreBuildTimeout   = errors.New("Pre-build step timed out, the limit is set with FN_PREBUILD_TIMEOUT")
+
+	// ErrNotProject is the category of PreBuild errors for functions missing the project files of their runtime,
+	// which fn init would generate
+	ErrNotProject = errors.New("Not a function project for the runtime")
+	// ErrFDKVersionUnavailable is the category of errors resolving the FDK version to generate a function against
+	ErrFDKVersionUnavailable = errors.New("FDK version unavailable")
+	// ErrRegenerateUnsupported is returned by RegenerateBoilerplate for runtimes that can't replace their boilerplate
+	ErrRegenerateUnsupported = errors.New("Regenerating the boilerplate is not supported for this runtime")
 )
 
-// GetLangHelper returns a LangHelper for the passed in language
-func GetLangHelper(lang string) LangHelper {
+// BuildError is an error of one of the categories above, such as ErrNotProject, carrying a human readable message.
+// errors.Is matches it against its category.
+type BuildError struct {
+	Category error
+	Message  string
+}
+
+func (e *BuildError) Error() string { return e.Message }
+
+// Unwrap returns the category of the error.
+func (e *BuildError) Unwrap() error { return e.Category }
+
+// notProjectError returns msg as an ErrNotProject error
+func notProjectError(msg string) error {
+	return &BuildError{Category: ErrNotProject, Message: msg}
+}
+
+// GetLangHelper returns a LangHelper for the passed in runtime. The runtime can carry a version, either directly or
+// after a colon, e.g. java9 or java:9, with the default version used when there is none. It returns an
+// *UnknownRuntimeError listing the supported runtimes if there is no helper for it. Runtimes without a built-in or
+// registered helper are looked up as fn-lang-<runtime> plugin executables on the PATH.
+func GetLangHelper(runtime string) (LangHelper, error) {
+	for _, name := range runtimeCandidates(runtime) {
+		if lh := registeredLangHelper(name); lh != nil {
+			return lh, nil
+		}
+		if lh := builtinLangHelper(name); lh != nil {
+			return lh, nil
+		}
+	}
+	if lh, err := pluginLangHelper(runtime); lh != nil || err != nil {
+		return lh, err
+	}
+	return nil, &UnknownRuntimeError{Runtime: runtime, Supported: SupportedRuntimes()}
+}
+
+// builtinRuntimes lists the runtimes builtinLangHelper knows about
+var builtinRuntimes = []string{
+	"go", "node", "ruby", "python", "php", "rust", "dotnet", "factor", "haxe", "octave", "solidity", "binary",
+	"oberon", "gst", "kotlin", "elixir", "lambda-nodejs4.3", "lambda-node-4", "java", "java8", "java9",
+}
+
+func builtinLangHelper(lang string) LangHelper {
 	switch lang {
 	case "go":
 		return &GoLangHelper{}
@@ -30,6 +107,24 @@ func GetLangHelper(lang string) LangHelper {
 		return &RustLangHelper{}
 	case "dotnet":
 		return &DotNetLangHelper{}
+	case "factor":
+		return &FactorLangHelper{}
+	case "haxe":
+		return &HaxeLangHelper{}
+	case "octave":
+		return &OctaveLangHelper{}
+	case "solidity":
+		return &SolidityLangHelper{}
+	case "binary":
+		return &BinaryLangHelper{}
+	case "oberon":
+		return &OberonLangHelper{}
+	case "gst":
+		return &GnuSmalltalkLangHelper{}
+	case "kotlin":
+		return &KotlinLangHelper{}
+	case "elixir":
+		return &ElixirLangHelper{}
 	case "lambda-nodejs4.3", "lambda-node-4":
 		return &LambdaNodeHelper{}
 	case "java":
@@ -59,16 +154,146 @@ type LangHelper interface {
 	Cmd() string
 	HasPreBuild() bool
 	PreBuild() error
+	// HasAfterBuild indicates whether AfterBuild should run once the image is built, to verify or clean up.
+	HasAfterBuild() bool
 	AfterBuild() error
 	// HasBoilerplate indicates whether a language has support for generating function boilerplate.
 	HasBoilerplate() bool
 	// GenerateBoilerplate generates basic function boilerplate. Returns ErrBoilerplateExists if the function file
 	// already exists.
 	GenerateBoilerplate() error
+	// FDKListenPort is the port the function's FDK listens on, exposed in the generated Dockerfile of http format
+	// functions.
+	FDKListenPort() int
+	// StartupGracePeriodSeconds is how long the function may take to become ready after its container starts.
+	StartupGracePeriodSeconds() int
+	// DockerfileUser is the user:group the function runs as in the final image, empty to keep the image default.
+	DockerfileUser() string
+	// ComposeFragment is a docker-compose service definition for the function, empty when not supported.
+	ComposeFragment() string
+	// PrewarmImage is an image holding dependency caches shared across function builds, that can be pulled or
+	// built once ahead of them. Empty when the runtime has nothing to prewarm.
+	PrewarmImage() (string, error)
+	// BuildParallelism is the number of parallel jobs build tools that support it are told to use.
+	BuildParallelism() int
+	// ColdStartClass is how quickly a new container serves its first call, one of ColdStartFast, ColdStartMedium
+	// or ColdStartSlow.
+	ColdStartClass() string
+	// DockerfileShell is the shell RUN instructions execute in, such as bash with pipefail for builds using pipes.
+	// Empty keeps the image default.
+	DockerfileShell() []string
+	// DefaultMemory is the memory in MB the function needs to run comfortably.
+	DefaultMemory() uint64
+	// ExecutableBoilerplateFiles lists the generated files, relative to the function directory, that must be
+	// executable, such as script handlers run directly.
+	ExecutableBoilerplateFiles() []string
+	// ImagesToMirror lists the images the runtime pulls besides its build and run images, such as tool images
+	// pre-build steps run, so they can be mirrored for air-gapped builds.
+	ImagesToMirror() []string
+	// MinFDKVersion is the oldest FDK version the helper's images and boilerplate work with, empty if any will do.
+	MinFDKVersion() string
+	// DockerfileStopSignal is the signal the container is stopped with, empty to keep the image default.
+	DockerfileStopSignal() string
+	// DockerfileSyntax is the BuildKit frontend, such as docker/dockerfile:1.4, the Dockerfile needs for the
+	// BuildKit features the helper uses. Empty when it uses none.
+	DockerfileSyntax() string
+	// SupportsLocalBuild indicates whether the function can be built on the host, with the image only packaging
+	// the artifact.
+	SupportsLocalBuild() bool
+	// LocalBuild builds the function in dir on the host.
+	LocalBuild(dir string) error
+	// LocalBuildCopyCmds adds the artifact LocalBuild produced to the image, in place of DockerfileCopyCmds.
+	LocalBuildCopyCmds() []string
+	// LogFormat is how the function writes its logs, LogFormatText or LogFormatJSON, so the platform can parse them.
+	LogFormat() string
+	// ContentTag hashes the function sources and build descriptors in dir into a deterministic image tag, such as
+	// sha-0123456789ab, for content addressed images.
+	ContentTag(dir string) (string, error)
+	// DockerfileStages lays the Dockerfile out as named stages, in place of DockerfileBuildCmds and
+	// DockerfileCopyCmds, for helpers splitting their build up further to make the most of the layer cache. Nil keeps
+	// the build and run stages.
+	DockerfileStages() []Stage
+	// RegenerateBoilerplate generates the boilerplate again, replacing the files GenerateBoilerplate would refuse
+	// to overwrite.
+	RegenerateBoilerplate() error
+	// MaxPayloadBytes is the largest request body the runtime's FDK handles safely, for the platform to limit
+	// requests to.
+	MaxPayloadBytes() int64
+	// BuildInfoResource is the path, relative to the function directory, and content of a resource generated with
+	// the boilerplate and packaged into the image, recording the function's provenance, including fdkVersion, the
+	// FDK version the boilerplate depends on. An empty path if none.
+	BuildInfoResource(fdkVersion string) (path, content string)
+	// IsHermeticBuild indicates whether the build runs fully offline, from dependencies vendored with the function.
+	IsHermeticBuild() bool
+	// CacheAnalysisDockerfile is a Dockerfile running each logical build step in a stage of its own, annotated with
+	// what invalidates it, so that users can see which layers rebuild. Empty if the helper has none.
+	CacheAnalysisDockerfile() string
+	// LooksLikeProject indicates whether dir holds a project of the runtime, for DetectRuntime.
+	LooksLikeProject(dir string) bool
+	// ArchImages returns the build and run images for an architecture, such as arm64, whose variant isn't published
+	// under BuildFromImage and RunFromImage. Empty images mean the default ones publish the architecture.
+	ArchImages(arch string) (buildImage, runImage string)
+	// SupportsDockerlessBuild indicates whether the image of the function in dir can be assembled without Docker,
+	// by adding the directory onto the run image, as its build has nothing to install.
+	SupportsDockerlessBuild(dir string) bool
+	// BuildCacheDirs lists the directories the build stage downloads dependencies into, such as /root/.npm, to keep
+	// across builds with BuildKit cache mounts, see CacheMountCmds.
+	BuildCacheDirs() []string
+	// HotReloads indicates whether the changes to files, relative to the function directory, take effect by copying
+	// them onto the previous image of the function, sparing fn develop a full rebuild. Interpreted runtimes can
+	// unless their dependencies changed.
+	HotReloads(changed []string) bool
+	// Validate checks the project of the function in dir for fn lint, such as whether the files the build needs are
+	// there and the FDK version it depends on. It reports all the issues it finds.
+	Validate(dir string) []LintIssue
+	// DependencyManifests lists the files, relative to the function directory, declaring the function's language
+	// dependencies, such as pom.xml, for SBOMs to include dependencies the image doesn't show.
+	DependencyManifests() []string
+	// TestCmds lists the shell commands running the unit tests of the function in its build stage, for fn test
+	// --unit, writing JUnit XML reports to TestReportsDir where the test runner can. Empty if the helper has none.
+	TestCmds() []string
+	// SupportsNativeImage indicates whether the helper can compile the function into a GraalVM native image, for
+	// func.yaml build_mode: native, see NativeImageBuild.
+	SupportsNativeImage() bool
+	// NativeImageBuild reports whether the build mode SetBuildMode passed on compiles the function into a GraalVM
+	// native image.
+	NativeImageBuild() bool
+	// NativeImageReflection returns the GraalVM reflection configuration of the classes the runtime loads by name for
+	// the function cmd, which native builds write into the build context as NativeImageReflectionFile.
+	NativeImageReflection(cmd string) ([]byte, error)
+	// Migrate returns the rewrites of the dependency files of the function in dir, such as pom.xml, bringing the FDK
+	// it depends on up to the current version, for fn migrate. Files already up to date have none.
+	Migrate(dir string) ([]Migration, error)
+
+	// setImages records the images ResolveImages resolved for the function, which the helper's own steps run.
+	setImages(images *Images)
+	// setBuildMode records the build mode of the function, see SetBuildMode.
+	setBuildMode(mode string)
+}
+
+const (
+	// FromBuildImage and FromRunImage stand for the build and run images in Stage.From, leaving the generator to
+	// apply the image overrides.
+	FromBuildImage = "$build-image"
+	FromRunImage   = "$run-image"
+)
+
+// TestReportsDir is the directory of the test container, mounted from the host, TestCmds write JUnit XML reports to.
+const TestReportsDir = "/test-reports"
+
+// Stage is a stage of a multi-stage Dockerfile.
+type Stage struct {
+	// Name is what later stages refer to the stage by, in FROM or COPY --from. It can be empty for the last stage.
+	Name string
+	// From is FromBuildImage, FromRunImage or the name of an earlier stage the stage builds on.
+	From string
+	Cmds []string
 }
 
 // BaseHelper is empty implementation of LangHelper for embedding in implementations.
 type BaseHelper struct {
+	images    *Images
+	buildMode string
 }
 
 func (h *BaseHelper) BuildFromImage() string        { return "" }
@@ -80,10 +305,61 @@ func (h *BaseHelper) Entrypoint() string            { return "" }
 func (h *BaseHelper) Cmd() string                   { return "" }
 func (h *BaseHelper) HasPreBuild() bool             { return false }
 func (h *BaseHelper) PreBuild() error               { return nil }
+func (h *BaseHelper) HasAfterBuild() bool           { return false }
 func (h *BaseHelper) AfterBuild() error             { return nil }
 func (h *BaseHelper) HasBoilerplate() bool          { return false }
 func (h *BaseHelper) GenerateBoilerplate() error    { return nil }
 
+func (h *BaseHelper) FDKListenPort() int                        { return defaultFDKListenPort }
+func (h *BaseHelper) StartupGracePeriodSeconds() int            { return 5 }
+func (h *BaseHelper) DockerfileUser() string                    { return os.Getenv("FN_DOCKERFILE_USER") }
+func (h *BaseHelper) ComposeFragment() string                   { return "" }
+func (h *BaseHelper) PrewarmImage() (string, error)             { return "", nil }
+func (h *BaseHelper) BuildParallelism() int                     { return buildParallelism() }
+func (h *BaseHelper) ColdStartClass() string                    { return ColdStartMedium }
+func (h *BaseHelper) DockerfileShell() []string                 { return nil }
+func (h *BaseHelper) DefaultMemory() uint64                     { return defaultMemory }
+func (h *BaseHelper) ExecutableBoilerplateFiles() []string      { return nil }
+func (h *BaseHelper) ImagesToMirror() []string                  { return nil }
+func (h *BaseHelper) MinFDKVersion() string                     { return "" }
+func (h *BaseHelper) DockerfileStopSignal() string              { return "" }
+func (h *BaseHelper) DockerfileSyntax() string                  { return "" }
+func (h *BaseHelper) SupportsLocalBuild() bool                  { return false }
+func (h *BaseHelper) LocalBuild(dir string) error               { return nil }
+func (h *BaseHelper) LocalBuildCopyCmds() []string              { return []string{} }
+func (h *BaseHelper) LogFormat() string                         { return LogFormatText }
+func (h *BaseHelper) ContentTag(dir string) (string, error)     { return contentTag(dir, ".") }
+func (h *BaseHelper) DockerfileStages() []Stage                 { return nil }
+func (h *BaseHelper) RegenerateBoilerplate() error              { return ErrRegenerateUnsupported }
+func (h *BaseHelper) MaxPayloadBytes() int64                    { return defaultMaxPayloadBytes }
+func (h *BaseHelper) BuildInfoResource(string) (string, string) { return "", "" }
+func (h *BaseHelper) IsHermeticBuild() bool                     { return false }
+func (h *BaseHelper) CacheAnalysisDockerfile() string           { return "" }
+func (h *BaseHelper) LooksLikeProject(dir string) bool          { return false }
+func (h *BaseHelper) ArchImages(string) (string, string)        { return "", "" }
+func (h *BaseHelper) SupportsDockerlessBuild(string) bool       { return false }
+func (h *BaseHelper) BuildCacheDirs() []string                  { return nil }
+func (h *BaseHelper) HotReloads([]string) bool                  { return false }
+func (h *BaseHelper) Validate(string) []LintIssue               { return nil }
+func (h *BaseHelper) DependencyManifests() []string             { return nil }
+func (h *BaseHelper) TestCmds() []string                        { return nil }
+func (h *BaseHelper) SupportsNativeImage() bool                 { return false }
+func (h *BaseHelper) Migrate(string) ([]Migration, error)       { return nil, nil }
+func (h *BaseHelper) NativeImageReflection(string) ([]byte, error) {
+	return nil, errors.New("no native image support")
+}
+func (h *BaseHelper) NativeImageBuild() bool   { return h.buildMode == BuildModeNative }
+func (h *BaseHelper) setImages(images *Images) { h.images = images }
+func (h *BaseHelper) setBuildMode(mode string) { h.buildMode = mode }
+
+// resolvedBuildImage returns the build image ResolveImages resolved for the function, image if it wasn't called
+func (h *BaseHelper) resolvedBuildImage(image string) string {
+	if h.images != nil {
+		return h.images.Build
+	}
+	return image
+}
+
 // exists checks if a file exists
 func exists(name string) bool {
 	if _, err := os.Stat(name); err != nil {
@@ -94,6 +370,126 @@ func exists(name string) bool {
 	return true
 }
 
+// ChownCopyCmds makes the COPY and ADD commands hand the files over to user, given as user or user:group, so a
+// function running as a non-root user owns its files. --chown requires Docker 17.09 or later.
+func ChownCopyCmds(cmds []string, user string) []string {
+	if user == "" {
+		return cmds
+	}
+	if !strings.Contains(user, ":") {
+		user = user + ":" + user
+	}
+	r := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		for _, instruction := range []string{"COPY ", "ADD "} {
+			if strings.HasPrefix(cmd, instruction) {
+				cmd = instruction + "--chown=" + user + " " + strings.TrimPrefix(cmd, instruction)
+				break
+			}
+		}
+		r = append(r, cmd)
+	}
+	return r
+}
+
+// composeService renders a docker-compose service running the function image built by fn build, published on the
+// port its FDK listens on.
+func composeService(port int) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	name := filepath.Base(wd)
+	return fmt.Sprintf(`  %s:
+    # build the image first with: fn build
+    image: ${FN_REGISTRY}/%s
+    ports:
+      - "%d:%d"
+`, name, name, port, port)
+}
+
+// packageIndexURL is the internal mirror set with FN_PACKAGE_INDEX_URL that dependency installs resolve through
+func packageIndexURL() string {
+	return os.Getenv("FN_PACKAGE_INDEX_URL")
+}
+
+// buildParallelism reads the FN_BUILD_PARALLELISM hint, building serially when it is unset or invalid
+func buildParallelism() int {
+	n, err := strconv.Atoi(os.Getenv("FN_BUILD_PARALLELISM"))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// buildKitEnabled reports whether FN_BUILDKIT opts into helpers using BuildKit only Dockerfile features
+func buildKitEnabled() bool {
+	return envEnabled("FN_BUILDKIT")
+}
+
+// isDebugBuild reports whether FN_BUILD_VARIANT asks for an unoptimized build that keeps debug symbols.
+// Anything other than "debug" is a regular release build.
+func isDebugBuild() bool {
+	return os.Getenv("FN_BUILD_VARIANT") == "debug"
+}
+
 func dockerBuildError(err error) error {
 	return fmt.Errorf("error running docker build: %v", err)
 }
+
+// CACertCmds returns the Dockerfile steps trusting the custom CA bundle set with FN_CA_BUNDLE, for builds behind TLS
+// intercepting proxies. The bundle path is relative to the function directory, which is the build context. It
+// returns no steps when FN_CA_BUNDLE is unset.
+func CACertCmds() ([]string, error) {
+	bundle := os.Getenv("FN_CA_BUNDLE")
+	if bundle == "" {
+		return nil, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if !exists(filepath.Join(wd, bundle)) {
+		return nil, fmt.Errorf("Could not find the CA bundle %s set in FN_CA_BUNDLE", bundle)
+	}
+	return []string{
+		fmt.Sprintf("COPY %s /usr/local/share/ca-certificates/fn-ca-bundle.crt", filepath.ToSlash(bundle)),
+		"RUN update-ca-certificates",
+	}, nil
+}
+
+// MarkBoilerplateExecutable sets the executable bits on the boilerplate files the helper declares executable.
+func MarkBoilerplateExecutable(lh LangHelper) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	for _, file := range lh.ExecutableBoilerplateFiles() {
+		if err := os.Chmod(filepath.Join(wd, file), os.FileMode(0755)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckFDKVersion returns an error if version is older than the minimum FDK version the helper requires.
+func CheckFDKVersion(lh LangHelper, version string) error {
+	min := lh.MinFDKVersion()
+	if min == "" {
+		return nil
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("Invalid FDK version %s: %v", version, err)
+	}
+	if v.LessThan(*semver.New(min)) {
+		return fmt.Errorf("FDK version %s is older than %s, the minimum this runtime supports", version, min)
+	}
+	return nil
+}
+
+// LocalBuildEnabled reports whether FN_LOCAL_BUILD asks for the function to be built on the host and the helper
+// supports it.
+func LocalBuildEnabled(lh LangHelper) bool {
+	return envEnabled("FN_LOCAL_BUILD") && lh.SupportsLocalBuild()
+}
@@ -0,0 +1,155 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDebugBuildVariant(t *testing.T) {
+	lh := &RustLangHelper{}
+	release := lh.DockerfileBuildCmds()
+
+	os.Setenv("FN_BUILD_VARIANT", "debug")
+	defer os.Unsetenv("FN_BUILD_VARIANT")
+
+	expected := append(rustFetchCmds(),
+		"ADD . /function/src/",
+		"RUN cd /function/src/ && cargo build",
+	)
+	if cmds := lh.DockerfileBuildCmds(); !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("expected debug build commands %v, got %v", expected, cmds)
+	}
+	if cmds := lh.DockerfileCopyCmds(); cmds[0] != "COPY --from=build-stage /function/src/target/debug/func /function/func" {
+		t.Errorf("expected the debug binary to be copied, got %v", cmds)
+	}
+	if reflect.DeepEqual(release, expected) {
+		t.Error("release build should not match the debug build")
+	}
+}
+
+func TestChownCopyCmds(t *testing.T) {
+	cmds := (&JavaLangHelper{version: "1.8"}).DockerfileCopyCmds()
+	if chowned := ChownCopyCmds(cmds, ""); !reflect.DeepEqual(chowned, cmds) {
+		t.Errorf("expected copy commands to be unchanged for the default user, got %v", chowned)
+	}
+
+	expected := []string{"COPY --chown=fn:fn --from=build-stage /function/target/*.jar /function/app/"}
+	if chowned := ChownCopyCmds(cmds, "fn"); !reflect.DeepEqual(chowned, expected) {
+		t.Errorf("expected %v, got %v", expected, chowned)
+	}
+	expected = []string{"COPY --chown=fn:users --from=build-stage /function/target/*.jar /function/app/"}
+	if chowned := ChownCopyCmds(cmds, "fn:users"); !reflect.DeepEqual(chowned, expected) {
+		t.Errorf("expected %v, got %v", expected, chowned)
+	}
+}
+
+func TestBinaryPreBuild(t *testing.T) {
+	_, cleanup := chdirTemp(t)
+	defer cleanup()
+	lh := &BinaryLangHelper{}
+
+	if err := lh.PreBuild(); err == nil {
+		t.Error("expected a missing binary to fail the pre-build")
+	}
+	if err := ioutil.WriteFile("func", []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lh.PreBuild(); err == nil {
+		t.Error("expected a non-executable binary to fail the pre-build")
+	}
+	if err := os.Chmod("func", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := lh.PreBuild(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCACertCmdsMissingBundle(t *testing.T) {
+	_, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if cmds, err := CACertCmds(); err != nil || cmds != nil {
+		t.Errorf("expected no CA steps by default, got %v, %v", cmds, err)
+	}
+	os.Setenv("FN_CA_BUNDLE", "missing.pem")
+	defer os.Unsetenv("FN_CA_BUNDLE")
+	if _, err := CACertCmds(); err == nil {
+		t.Error("expected a missing CA bundle to be rejected")
+	}
+}
+
+func TestColdStartClass(t *testing.T) {
+	for runtime, expected := range map[string]string{
+		"java8":  ColdStartSlow,
+		"node":   ColdStartFast,
+		"go":     ColdStartFast,
+		"dotnet": ColdStartMedium,
+	} {
+		lh, err := GetLangHelper(runtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if class := lh.ColdStartClass(); class != expected {
+			t.Errorf("expected %s to cold start %s, got %s", runtime, expected, class)
+		}
+	}
+}
+
+func TestMaxPayloadBytes(t *testing.T) {
+	if max := (&BaseHelper{}).MaxPayloadBytes(); max != 1<<20 {
+		t.Errorf("expected a 1MiB default, got %d", max)
+	}
+	if max := (&JavaLangHelper{version: "1.8"}).MaxPayloadBytes(); max != 6<<20 {
+		t.Errorf("expected Java to take 6MiB requests, got %d", max)
+	}
+}
+
+func TestIsHermeticBuild(t *testing.T) {
+	if (&BaseHelper{}).IsHermeticBuild() {
+		t.Error("expected builds not to be hermetic by default")
+	}
+	lh := &JavaLangHelper{version: "1.8"}
+	if lh.IsHermeticBuild() {
+		t.Error("expected Maven builds to download their dependencies")
+	}
+
+	os.Setenv(offlineEnv, "true")
+	defer os.Unsetenv(offlineEnv)
+	os.Setenv(mavenLocalRepoEnv, "m2")
+	defer os.Unsetenv(mavenLocalRepoEnv)
+	if !lh.IsHermeticBuild() {
+		t.Error("expected offline Maven builds from a seeded repository to be hermetic")
+	}
+}
+
+type scriptLangHelper struct {
+	BaseHelper
+}
+
+func (lh *scriptLangHelper) GenerateBoilerplate() error {
+	return ioutil.WriteFile("func.sh", []byte("#!/bin/sh\necho Hello World\n"), 0644)
+}
+func (lh *scriptLangHelper) ExecutableBoilerplateFiles() []string { return []string{"func.sh"} }
+
+func TestMarkBoilerplateExecutable(t *testing.T) {
+	_, cleanup := chdirTemp(t)
+	defer cleanup()
+	lh := &scriptLangHelper{}
+
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarkBoilerplateExecutable(lh); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat("func.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected the script handler to be executable, got %v", info.Mode())
+	}
+}
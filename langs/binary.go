@@ -0,0 +1,69 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BinaryLangHelper wraps an already compiled binary into a function image, for polyglot teams building their
+// functions outside of Fn. The binary is read from FN_BINARY_PATH, func by default, and runs on FN_BINARY_IMAGE,
+// debian:stretch by default.
+type BinaryLangHelper struct {
+	BaseHelper
+}
+
+func binaryPath() string {
+	if path := os.Getenv("FN_BINARY_PATH"); path != "" {
+		return path
+	}
+	return "func"
+}
+
+// BuildFromImage returns the Docker image the binary runs on
+func (lh *BinaryLangHelper) BuildFromImage() string {
+	if image := os.Getenv("FN_BINARY_IMAGE"); image != "" {
+		return image
+	}
+	return "debian:stretch"
+}
+
+// IsMultiStage returns false, there is nothing left to build.
+func (lh *BinaryLangHelper) IsMultiStage() bool { return false }
+
+// DockerfileCopyCmds returns the Docker ADD command to add the binary to the image.
+func (lh *BinaryLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		fmt.Sprintf("ADD %s /function/func", filepath.ToSlash(binaryPath())),
+	}
+}
+
+// Cmd runs the binary.
+func (lh *BinaryLangHelper) Cmd() string {
+	return "/function/func"
+}
+
+// ColdStartClass returns ColdStartFast, the binary is started directly.
+func (lh *BinaryLangHelper) ColdStartClass() string { return ColdStartFast }
+
+// HasPreBuild returns whether the binary runtime has a pre-build step.
+func (lh *BinaryLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the configured binary exists and is executable.
+func (lh *BinaryLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	path := binaryPath()
+	info, err := os.Stat(filepath.Join(wd, path))
+	if err != nil {
+		return fmt.Errorf("Could not find the function binary %s - set FN_BINARY_PATH to its location", path)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("The function binary %s is not an executable file", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// checkBoilerplate fails with an ErrBoilerplateExists error naming the first of the files, keyed by their slash
+// separated path relative to dir, that already exists. Checking them all up front means generation never leaves a
+// mix of generated and existing files behind.
+func checkBoilerplate(dir string, files map[string]string) error {
+	for _, file := range sortedKeys(files) {
+		if exists(filepath.Join(dir, filepath.FromSlash(file))) {
+			return &BuildError{
+				Category: ErrBoilerplateExists,
+				Message:  fmt.Sprintf("%s already exists, can't generate boilerplate", file),
+			}
+		}
+	}
+	return nil
+}
+
+// writeBoilerplate writes the files, keyed by their slash separated path relative to dir, creating the directories
+// they are in.
+func writeBoilerplate(dir string, files map[string]string) error {
+	for _, file := range sortedKeys(files) {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+			return err
+		}
+		if err := writeSourceFile(path, files[file]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package langs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cacheMountSyntax is the BuildKit frontend RUN --mount needs
+const cacheMountSyntax = "docker/dockerfile:1.4"
+
+// CacheMountCmds mounts the helper's BuildCacheDirs into the RUN commands of cmds when FN_BUILDKIT is enabled, as
+// BuildKit cache mounts named after the runtime, so that the builds of all its functions share the dependencies
+// they download.
+func CacheMountCmds(lh LangHelper, runtime string, cmds []string) []string {
+	dirs := lh.BuildCacheDirs()
+	if !buildKitEnabled() || len(dirs) == 0 {
+		return cmds
+	}
+	mounts := ""
+	for _, dir := range dirs {
+		mounts += fmt.Sprintf("--mount=type=cache,id=%s,target=%s ", cacheID(runtime, dir), dir)
+	}
+	r := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		r[i] = cmd
+		if strings.HasPrefix(cmd, "RUN ") {
+			r[i] = "RUN " + mounts + strings.TrimPrefix(cmd, "RUN ")
+		}
+	}
+	return r
+}
+
+// CacheVolumeArgs returns the run flags mounting the helper's BuildCacheDirs as named volumes, named like the
+// BuildKit cache mounts of CacheMountCmds, so that containers running the build image, such as fn test --unit, keep
+// the dependencies they download across runs.
+func CacheVolumeArgs(lh LangHelper, runtime string) []string {
+	var args []string
+	for _, dir := range lh.BuildCacheDirs() {
+		args = append(args, "-v", cacheID(runtime, dir)+":"+dir)
+	}
+	return args
+}
+
+// cacheID names the cache of dir shared by the builds of the runtime
+func cacheID(runtime, dir string) string {
+	return "fn-" + runtime + "-" + strings.Trim(strings.Replace(dir, "/", "-", -1), "-")
+}
+
+// DockerfileSyntax returns the BuildKit frontend the helper's Dockerfile needs, for the features the helper uses or
+// for the cache mounts of CacheMountCmds.
+func DockerfileSyntax(lh LangHelper) string {
+	if syntax := lh.DockerfileSyntax(); syntax != "" {
+		return syntax
+	}
+	if buildKitEnabled() && len(lh.BuildCacheDirs()) > 0 {
+		return cacheMountSyntax
+	}
+	return ""
+}
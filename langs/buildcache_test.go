@@ -0,0 +1,38 @@
+package langs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCacheMountCmds(t *testing.T) {
+	lh := &NodeLangHelper{}
+	cmds := []string{"ADD package.json /function/", "RUN npm install"}
+	if mounted := CacheMountCmds(lh, "node", cmds); !reflect.DeepEqual(mounted, cmds) || DockerfileSyntax(lh) != "" {
+		t.Errorf("expected no cache mounts without BuildKit, got %v", mounted)
+	}
+
+	os.Setenv("FN_BUILDKIT", "1")
+	defer os.Unsetenv("FN_BUILDKIT")
+	expected := []string{
+		"ADD package.json /function/",
+		"RUN --mount=type=cache,id=fn-node-root-.npm,target=/root/.npm npm install",
+	}
+	if mounted := CacheMountCmds(lh, "node", cmds); !reflect.DeepEqual(mounted, expected) {
+		t.Errorf("expected %v, got %v", expected, mounted)
+	}
+	if syntax := DockerfileSyntax(lh); syntax != cacheMountSyntax {
+		t.Errorf("expected cache mounts to need the %s frontend, got %q", cacheMountSyntax, syntax)
+	}
+}
+
+func TestCacheVolumeArgs(t *testing.T) {
+	expected := []string{"-v", "fn-rust-usr-local-cargo-registry:/usr/local/cargo/registry"}
+	if args := CacheVolumeArgs(&RustLangHelper{}, "rust"); !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+	if args := CacheVolumeArgs(&RubyLangHelper{}, "ruby"); args != nil {
+		t.Errorf("expected no volumes without cache dirs, got %v", args)
+	}
+}
@@ -0,0 +1,67 @@
+package langs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompileCheck builds the helper's build stage for the function in dir inside Docker, from the build image
+// ResolveImages resolved, without producing a runtime image, and returns an error carrying the build output if it
+// fails. It lets the package's tests, and downstream users, verify that generated boilerplate actually compiles.
+func CompileCheck(images *Images, dir string) error {
+	lh := images.lh
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	// helpers work against the current working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	if lh.HasPreBuild() {
+		if err := lh.PreBuild(); err != nil {
+			return err
+		}
+	}
+
+	mirror, err := LoadImageMirror()
+	if err != nil {
+		return err
+	}
+	lines := []string{
+		fmt.Sprintf("FROM %s", images.Build),
+		"WORKDIR /function",
+	}
+	lines = mirror.Dockerfile(append(lines, lh.DockerfileBuildCmds()...))
+	dockerfile, err := ioutil.TempFile("", "Dockerfile")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dockerfile.Name())
+	_, err = dockerfile.WriteString(strings.Join(lines, "\n") + "\n")
+	dockerfile.Close()
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	cmd := EngineCommand("build", "--rm", "-f", dockerfile.Name(), ".")
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("compile check failed: %v\n%s", err, out.String())
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package langs
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestCompileCheckJavaBoilerplate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping Docker build in short mode")
+	}
+	if err := exec.Command("docker", "version").Run(); err != nil {
+		t.Skip("skipping, Docker is not available")
+	}
+
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	os.Setenv("FN_JAVA_FDK_VERSION", "1.0.56")
+	defer os.Unsetenv("FN_JAVA_FDK_VERSION")
+
+	lh := &JavaLangHelper{version: "1.8"}
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	images, err := ResolveImages(lh, "java8", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CompileCheck(images, tmp); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,61 @@
+package langs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// contentTag hashes the named files and directories under dir, their paths and contents, into an image tag such as
+// sha-0123456789ab. Hidden files and directories, like .git, are left out. Missing paths are skipped.
+func contentTag(dir string, paths ...string) (string, error) {
+	files := []string{}
+	for _, path := range paths {
+		root := filepath.Join(dir, path)
+		if !exists(root) {
+			continue
+		}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(h, filepath.ToSlash(rel)+"\x00")
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return "sha-" + hex.EncodeToString(h.Sum(nil))[:12], nil
+}
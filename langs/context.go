@@ -0,0 +1,39 @@
+package langs
+
+import "context"
+
+// ContextBoilerplateGenerator is implemented by helpers whose boilerplate generation can be cancelled, such as the
+// ones looking the FDK version up over the network.
+type ContextBoilerplateGenerator interface {
+	GenerateBoilerplateContext(ctx context.Context) error
+}
+
+// ContextPreBuilder is implemented by helpers whose pre-build step can be cancelled, such as the ones running
+// external commands.
+type ContextPreBuilder interface {
+	PreBuildContext(ctx context.Context) error
+}
+
+// GenerateBoilerplateContext generates the boilerplate of lh, aborting with the error of ctx once it is done if lh
+// supports cancellation. GenerateBoilerplate is the same with context.Background().
+func GenerateBoilerplateContext(ctx context.Context, lh LangHelper) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if g, ok := lh.(ContextBoilerplateGenerator); ok {
+		return g.GenerateBoilerplateContext(ctx)
+	}
+	return lh.GenerateBoilerplate()
+}
+
+// PreBuildContext runs the pre-build step of lh, aborting with the error of ctx once it is done if lh supports
+// cancellation. PreBuild is the same with context.Background().
+func PreBuildContext(ctx context.Context, lh LangHelper) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p, ok := lh.(ContextPreBuilder); ok {
+		return p.PreBuildContext(ctx)
+	}
+	return lh.PreBuild()
+}
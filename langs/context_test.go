@@ -0,0 +1,48 @@
+package langs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// blockingResolver never resolves a version, only returning once the lookup is cancelled
+type blockingResolver struct{}
+
+func (blockingResolver) LatestVersion(group, artifact string) (string, error) {
+	select {}
+}
+
+func (blockingResolver) LatestVersionContext(ctx context.Context, group, artifact string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestGenerateBoilerplateContextCancelled(t *testing.T) {
+	_, cleanup := chdirTemp(t)
+	defer cleanup()
+	os.Unsetenv("FN_JAVA_FDK_VERSION")
+	resolver := JavaFDKVersionResolver
+	JavaFDKVersionResolver = blockingResolver{}
+	defer func() { JavaFDKVersionResolver = resolver }()
+	lh := &JavaLangHelper{version: "1.8"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := GenerateBoilerplateContext(ctx, lh); err != context.Canceled {
+		t.Errorf("expected a cancelled context to abort generation, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := GenerateBoilerplateContext(ctx, lh)
+	if !errors.Is(err, ErrFDKVersionUnavailable) {
+		t.Errorf("expected the FDK version lookup to fail, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected generation to abort promptly, took %v", elapsed)
+	}
+}
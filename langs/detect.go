@@ -0,0 +1,42 @@
+package langs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DetectRuntime returns the runtime whose helper recognises the project in dir. It fails if none does, or if
+// several do, in which case the runtime has to be chosen explicitly.
+func DetectRuntime(dir string) (string, error) {
+	var found []string
+	for _, entry := range CompletionEntries() {
+		lh, err := GetLangHelper(entry.Name)
+		if err != nil {
+			continue
+		}
+		if lh.LooksLikeProject(dir) {
+			found = append(found, entry.Name)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no supported files found to guess runtime, please set runtime explicitly with --runtime flag")
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found projects for several runtimes, %s, please set runtime explicitly with --runtime flag",
+			strings.Join(found, ", "))
+	}
+}
+
+// anyExists returns whether any of the files, relative to dir, exists
+func anyExists(dir string, files ...string) bool {
+	for _, file := range files {
+		if exists(filepath.Join(dir, file)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectRuntime(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if _, err := DetectRuntime(tmp); err == nil {
+		t.Error("expected an empty directory not to be detected")
+	}
+
+	if err := writeScaffoldFile(filepath.Join(tmp, "pom.xml"), pomFileContent("1.0.56", "1.8")); err != nil {
+		t.Fatal(err)
+	}
+	if runtime, err := DetectRuntime(tmp); err != nil || runtime != "java8" {
+		t.Errorf("expected a Java 8 Maven project, got %v, %v", runtime, err)
+	}
+
+	if err := writeScaffoldFile(filepath.Join(tmp, "go.mod"), "module func\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DetectRuntime(tmp); err == nil {
+		t.Error("expected a directory with a Maven and a Go project to be ambiguous")
+	}
+}
+
+func TestDetectRuntimeSources(t *testing.T) {
+	for file, expected := range map[string]string{
+		"func.cs":     "dotnet",
+		"Func.fs":     "dotnet",
+		"func.java":   "java9",
+		"src/main.rs": "rust",
+	} {
+		func() {
+			tmp, cleanup := chdirTemp(t)
+			defer cleanup()
+			path := filepath.Join(tmp, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeScaffoldFile(path, ""); err != nil {
+				t.Fatal(err)
+			}
+			if runtime, err := DetectRuntime(tmp); err != nil || runtime != expected {
+				t.Errorf("expected %s to be detected as %s, got %v, %v", file, expected, runtime, err)
+			}
+		}()
+	}
+}
@@ -1,9 +1,6 @@
 package langs
 
-import (
-	"os"
-	"os/exec"
-)
+import "context"
 
 type DotNetLangHelper struct {
 	BaseHelper
@@ -12,6 +9,11 @@ type DotNetLangHelper struct {
 func (lh *DotNetLangHelper) BuildFromImage() string {
 	return "microsoft/dotnet:1.0.1-sdk-projectjson"
 }
+
+// LooksLikeProject returns whether dir has a project.json, or a C# or F# function source.
+func (lh *DotNetLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "project.json", "func.cs", "Func.cs", "func.fs", "Func.fs")
+}
 func (lh *DotNetLangHelper) RunFromImage() string {
 	return "microsoft/dotnet:runtime"
 }
@@ -26,23 +28,31 @@ func (lh *DotNetLangHelper) HasPreBuild() bool {
 
 // PreBuild for Go builds the binary so the final image can be as small as possible
 func (lh *DotNetLangHelper) PreBuild() error {
-	wd, err := os.Getwd()
+	return lh.PreBuildContext(context.Background())
+}
+
+// PreBuildContext is PreBuild, killing the restore once ctx is done.
+func (lh *DotNetLangHelper) PreBuildContext(ctx context.Context) error {
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
 
-	cmd := exec.Command(
-		"docker", "run",
+	engine, err := Engine()
+	if err != nil {
+		return err
+	}
+	mirror, err := LoadImageMirror()
+	if err != nil {
+		return err
+	}
+
+	return runPreBuildCmd(ctx,
+		engine.Name(), "run",
 		"--rm", "-v",
-		wd+":/dotnet", "-w", "/dotnet", "microsoft/dotnet:1.0.1-sdk-projectjson",
+		wd+":/dotnet", "-w", "/dotnet", mirror.Image(lh.resolvedBuildImage(lh.BuildFromImage())),
 		"/bin/sh", "-c", "dotnet restore && dotnet publish -c release -b /tmp -o .",
 	)
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
-	if err := cmd.Run(); err != nil {
-		return dockerBuildError(err)
-	}
-	return nil
 }
 
 func (lh *DotNetLangHelper) AfterBuild() error {
@@ -0,0 +1,260 @@
+package langs
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ElixirLangHelper provides a set of helper methods for the lifecycle of Elixir Mix functions, built into a Mix
+// release. The FDK package version can be pinned with FN_ELIXIR_FDK_VERSION.
+type ElixirLangHelper struct {
+	BaseHelper
+}
+
+// elixirFDKPackage is the Hex package of the Elixir FDK
+const elixirFDKPackage = "fdk"
+
+// BuildFromImage returns the Elixir image, on the Debian release of the run image so the release's ERTS links
+// against the same libraries.
+func (lh *ElixirLangHelper) BuildFromImage() string {
+	return "elixir:1.16"
+}
+
+// LooksLikeProject returns whether dir has a mix.exs.
+func (lh *ElixirLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "mix.exs") }
+
+// RunFromImage returns a slim Debian image, the release bundles the Erlang runtime.
+func (lh *ElixirLangHelper) RunFromImage() string {
+	return "debian:bookworm-slim"
+}
+
+func (lh *ElixirLangHelper) HasBoilerplate() bool { return true }
+
+func mixExsContent(fdkVersion string) string {
+	return `defmodule Func.MixProject do
+  use Mix.Project
+
+  def project do
+    [
+      app: :func,
+      version: "0.1.0",
+      elixir: "~> 1.14",
+      start_permanent: Mix.env() == :prod,
+      deps: deps(),
+      releases: [func: [include_executables_for: [:unix]]]
+    ]
+  end
+
+  def application do
+    [extra_applications: [:logger]]
+  end
+
+  defp deps do
+    [
+      {:` + elixirFDKPackage + `, "~> ` + fdkVersion + `"}
+    ]
+  end
+end
+`
+}
+
+const elixirFuncContent = `defmodule Func do
+  @moduledoc """
+  The function, which the FDK calls with the body of each request.
+  """
+
+  def main do
+    Fdk.handle(&handler/2)
+  end
+
+  def handler(input, _ctx), do: hello(String.trim(input))
+
+  def hello(""), do: "Hello World!"
+  def hello(name), do: "Hello #{name}!"
+end
+`
+
+const elixirTestContent = `defmodule FuncTest do
+  use ExUnit.Case
+
+  test "hello world" do
+    assert Func.hello("") == "Hello World!"
+  end
+
+  test "hello name" do
+    assert Func.hello("Johnny") == "Hello Johnny!"
+  end
+end
+`
+
+const elixirFormatterContent = `[
+  inputs: ["{mix,.formatter}.exs", "{lib,test}/**/*.{ex,exs}"]
+]
+`
+
+// GenerateBoilerplate will generate a Mix project with a hello function and its ExUnit tests. It fails without
+// writing anything if any of the files already exists.
+func (lh *ElixirLangHelper) GenerateBoilerplate() error {
+	return lh.GenerateBoilerplateContext(context.Background())
+}
+
+// GenerateBoilerplateContext is GenerateBoilerplate, aborting the FDK version lookup once ctx is done.
+func (lh *ElixirLangHelper) GenerateBoilerplateContext(ctx context.Context) error {
+	return lh.generateBoilerplate(ctx, false)
+}
+
+// FDKVersion returns the version of the FDK package the boilerplate depends on.
+func (lh *ElixirLangHelper) FDKVersion(ctx context.Context) (string, error) {
+	return resolveFDKVersion(ctx, "elixir", "Elixir", ElixirFDKVersionResolver, "", elixirFDKPackage)
+}
+
+// RegenerateBoilerplate generates the boilerplate again, replacing the files already there.
+func (lh *ElixirLangHelper) RegenerateBoilerplate() error {
+	return lh.generateBoilerplate(context.Background(), true)
+}
+
+func (lh *ElixirLangHelper) generateBoilerplate(ctx context.Context, overwrite bool) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"mix.exs":              "", // rendered once the FDK version is known
+		".formatter.exs":       elixirFormatterContent,
+		"lib/func.ex":          elixirFuncContent,
+		"test/func_test.exs":   elixirTestContent,
+		"test/test_helper.exs": "ExUnit.start()\n",
+	}
+	if !overwrite {
+		if err := checkBoilerplate(wd, files); err != nil {
+			return err
+		}
+	}
+
+	fdkVersion, err := lh.FDKVersion(ctx)
+	if err != nil {
+		return err
+	}
+	files["mix.exs"] = mixExsContent(fdkVersion)
+
+	return writeBoilerplate(wd, files)
+}
+
+func (lh *ElixirLangHelper) Entrypoint() string {
+	return "/function/bin/func eval Func.main()"
+}
+
+// DockerfileCopyCmds installs the libraries the release's ERTS needs and copies the release in.
+func (lh *ElixirLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"RUN apt-get update && apt-get install -y --no-install-recommends libstdc++6 libssl3 libncurses6 locales && rm -rf /var/lib/apt/lists/*",
+		"ENV LANG=C.UTF-8",
+		"COPY --from=build-stage /function/src/_build/prod/rel/func /function/",
+	}
+}
+
+// DockerfileBuildCmds fetches the dependencies and builds the release, see DockerfileStages.
+func (lh *ElixirLangHelper) DockerfileBuildCmds() []string {
+	return append(elixirFetchCmds(), elixirReleaseCmds()...)
+}
+
+// DockerfileStages fetches and compiles the dependencies in a stage of their own, before the sources are added, so
+// that mix deps.get only runs again when mix.exs or mix.lock change.
+func (lh *ElixirLangHelper) DockerfileStages() []Stage {
+	return []Stage{
+		{Name: "deps", From: FromBuildImage, Cmds: elixirFetchCmds()},
+		{Name: "build-stage", From: "deps", Cmds: elixirReleaseCmds()},
+		{From: FromRunImage, Cmds: lh.DockerfileCopyCmds()},
+	}
+}
+
+// BuildCacheDirs returns the Hex package cache, shared by the fetch and build stages.
+func (lh *ElixirLangHelper) BuildCacheDirs() []string { return []string{"/root/.hex/packages"} }
+
+// elixirFetchCmds returns the steps fetching and compiling the dependencies of the manifest
+func elixirFetchCmds() []string {
+	return []string{
+		"ENV MIX_ENV=prod",
+		"RUN mix local.hex --force && mix local.rebar --force",
+		"ADD mix.exs mix.lock* /function/src/",
+		"RUN cd /function/src/ && mix deps.get --only prod && mix deps.compile",
+	}
+}
+
+// elixirReleaseCmds returns the steps building the release of the function from its sources
+func elixirReleaseCmds() []string {
+	return []string{"ADD . /function/src/", "RUN cd /function/src/ && mix release --overwrite"}
+}
+
+func (lh *ElixirLangHelper) ColdStartClass() string { return ColdStartMedium }
+
+var mixFDKDependencyPattern = regexp.MustCompile(`\{:` + elixirFDKPackage + `,\s*"([^"]*)"`)
+
+// mixFDKRequirementPattern matches the version of the requirement of the FDK package, after its operator
+var mixFDKRequirementPattern = regexp.MustCompile(`\{:` + elixirFDKPackage + `,\s*"[~>=<! ]*([^"]*)"`)
+
+// mixFDKVersion returns the version of the FDK package a mix.exs depends on, without the requirement operator,
+// empty if it doesn't.
+func mixFDKVersion(mixExs []byte) string {
+	m := mixFDKDependencyPattern.FindSubmatch(mixExs)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimLeft(string(m[1]), "~>=<! "))
+}
+
+// Validate checks that the function is a Mix project depending on the FDK package, at the version pinned if one is.
+func (lh *ElixirLangHelper) Validate(dir string) []LintIssue {
+	if issues := requireFiles(dir, "elixir", "mix.exs", "lib/func.ex"); issues != nil {
+		return issues
+	}
+	mixExs, issues := readLintFile(dir, "mix.exs")
+	if issues != nil {
+		return issues
+	}
+	return checkFDKVersion("elixir", "mix.exs", mixFDKVersion(mixExs))
+}
+
+// Migrate updates the requirement of the FDK package of the mix.exs to the current FDK version, keeping its
+// operator. mix.lock follows on the next deps.get.
+func (lh *ElixirLangHelper) Migrate(dir string) ([]Migration, error) {
+	m, err := migrateFile(dir, "mix.exs", func(mixExs []byte) ([]byte, []string, error) {
+		version, err := lh.FDKVersion(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+		updated, changes := replaceVersions(mixExs, mixFDKRequirementPattern, 1, elixirFDKPackage, version)
+		return updated, changes, nil
+	})
+	return migrations(m), err
+}
+
+// DependencyManifests returns mix.exs and mix.lock, which list the function's dependencies.
+func (lh *ElixirLangHelper) DependencyManifests() []string {
+	return []string{"mix.exs", "mix.lock"}
+}
+
+// TestCmds runs the ExUnit tests, fetching the test dependencies the release left out.
+func (lh *ElixirLangHelper) TestCmds() []string {
+	return []string{"cd /function/src/ && MIX_ENV=test mix do deps.get, test"}
+}
+
+func (lh *ElixirLangHelper) HasPreBuild() bool {
+	return true
+}
+
+func (lh *ElixirLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "mix.exs")) {
+		return notProjectError("Could not find mix.exs - are you sure this is an Elixir Mix project?")
+	}
+
+	return nil
+}
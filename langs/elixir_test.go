@@ -0,0 +1,52 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestElixirBoilerplate(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_ELIXIR_FDK_VERSION", "0.2.1")
+	defer os.Unsetenv("FN_ELIXIR_FDK_VERSION")
+	if err := (&ElixirLangHelper{}).GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	mixExs := readFile(t, filepath.Join(tmp, "mix.exs"))
+	if !strings.Contains(mixExs, `{:fdk, "~> 0.2.1"}`) {
+		t.Errorf("expected the pinned FDK dependency, got:\n%s", mixExs)
+	}
+	if version := mixFDKVersion([]byte(mixExs)); version != "0.2.1" {
+		t.Errorf("expected the FDK version to be read back as 0.2.1, got %q", version)
+	}
+	if fn := readFile(t, filepath.Join(tmp, "lib", "func.ex")); !strings.Contains(fn, "Fdk.handle(&handler/2)") {
+		t.Errorf("expected the function to be called through the FDK, got:\n%s", fn)
+	}
+	if test := readFile(t, filepath.Join(tmp, "test", "func_test.exs")); !strings.Contains(test, "use ExUnit.Case") {
+		t.Errorf("expected the hello function to come with ExUnit tests, got:\n%s", test)
+	}
+	if issues := (&ElixirLangHelper{}).Validate(tmp); len(issues) != 0 {
+		t.Errorf("expected the boilerplate to pass validation, got %v", issues)
+	}
+	if err := (&ElixirLangHelper{}).GenerateBoilerplate(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected the existing boilerplate to be kept, got %v", err)
+	}
+}
+
+func TestElixirStagesFetchBeforeSources(t *testing.T) {
+	stages := (&ElixirLangHelper{}).DockerfileStages()
+	if len(stages) != 3 || stages[0].Name != "deps" || stages[1].From != "deps" {
+		t.Fatalf("expected deps, build and run stages, got %+v", stages)
+	}
+	deps := strings.Join(stages[0].Cmds, "\n")
+	if !strings.Contains(deps, "mix deps.get") || strings.Contains(deps, "ADD . ") {
+		t.Errorf("expected the deps stage to fetch from the manifest alone, got:\n%s", deps)
+	}
+	if build := strings.Join(stages[1].Cmds, "\n"); !strings.Contains(build, "mix release") {
+		t.Errorf("expected the build stage to build a release, got:\n%s", build)
+	}
+}
@@ -0,0 +1,256 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// containerEngineEnv names the container engine images are built, run and pushed with: docker, podman or nerdctl.
+// fn sets it from --container-engine. Unset, the first of them installed is used.
+const containerEngineEnv = "FN_CONTAINER_ENGINE"
+
+const minRequiredDockerVersion = "17.5.0"
+
+// ContainerEngine is a container CLI close enough to the Docker one to build, run and push function images with.
+type ContainerEngine interface {
+	// Name is the name of the engine's executable.
+	Name() string
+	// Command returns the command running the engine with args.
+	Command(args ...string) *exec.Cmd
+	// CheckVersion ensures the engine is installed and recent enough to build functions.
+	CheckVersion() error
+	// BuildArgs returns the arguments, up to the Dockerfile and context, building image for the platforms, or the
+	// host's platform if there are none. push has a build for several platforms push the image, for engines that
+	// can't keep it locally to push afterwards.
+	BuildArgs(image string, platforms []string, push bool) ([]string, error)
+	// BuildPushes reports whether a build for the platforms has to push the image itself.
+	BuildPushes(platforms []string) bool
+	// BuildEnv returns the environment enabling the BuildKit Dockerfile features helpers use.
+	BuildEnv() []string
+	// PushArgs returns the arguments pushing image, built for the platforms.
+	PushArgs(image string, platforms []string) []string
+	// ManifestInspectArgs returns the arguments printing the manifest, or manifest list, of image in its registry.
+	ManifestInspectArgs(image string) []string
+	// CopyArgs returns the arguments copying src, an image the build pushed itself, to dst in another registry.
+	CopyArgs(src, dst string) ([]string, error)
+	// DigestArgs returns the arguments printing the digest image was pushed with, bare or as repository@digest.
+	DigestArgs(image string) []string
+	// ServerArgs returns the run arguments giving the functions server fn start runs access to an engine to start
+	// the function containers with.
+	ServerArgs() []string
+}
+
+var containerEngines = map[string]ContainerEngine{
+	"docker":  &dockerEngine{},
+	"podman":  &podmanEngine{},
+	"nerdctl": &nerdctlEngine{},
+}
+
+// ContainerEngines returns the names of the supported container engines, in the order they are looked for.
+func ContainerEngines() []string { return []string{"docker", "podman", "nerdctl"} }
+
+// Engine returns the container engine set in FN_CONTAINER_ENGINE, or the first one installed. Docker if none is.
+func Engine() (ContainerEngine, error) {
+	if name := os.Getenv(containerEngineEnv); name != "" {
+		engine, ok := containerEngines[name]
+		if !ok {
+			return nil, fmt.Errorf("%q set in %s is not a supported container engine, use one of %s", name,
+				containerEngineEnv, strings.Join(ContainerEngines(), ", "))
+		}
+		return engine, nil
+	}
+	for _, name := range ContainerEngines() {
+		if _, err := exec.LookPath(name); err == nil {
+			return containerEngines[name], nil
+		}
+	}
+	return containerEngines["docker"], nil
+}
+
+// EngineCommand returns the command running the container engine with args, Docker if the engine can't be told.
+func EngineCommand(args ...string) *exec.Cmd {
+	engine, err := Engine()
+	if err != nil {
+		return exec.Command("docker", args...)
+	}
+	return engine.Command(args...)
+}
+
+type dockerEngine struct{}
+
+func (e *dockerEngine) Name() string                        { return "docker" }
+func (e *dockerEngine) Command(args ...string) *exec.Cmd    { return exec.Command("docker", args...) }
+func (e *dockerEngine) BuildPushes(platforms []string) bool { return len(platforms) > 1 }
+func (e *dockerEngine) BuildEnv() []string                  { return []string{"DOCKER_BUILDKIT=1"} }
+
+func (e *dockerEngine) CheckVersion() error {
+	out, err := e.Command("version", "--format", "{{.Server.Version}}").Output()
+	if err != nil {
+		return fmt.Errorf("could not check Docker version: %v", err)
+	}
+	// dev / test builds append '-ce', trim this
+	trimmed := strings.TrimRightFunc(string(out), func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+
+	v, err := semver.NewVersion(trimmed)
+	if err != nil {
+		return fmt.Errorf("could not check Docker version: %v", err)
+	}
+	if v.LessThan(*semver.New(minRequiredDockerVersion)) {
+		return fmt.Errorf("please upgrade your version of Docker to %s or greater", minRequiredDockerVersion)
+	}
+	return nil
+}
+
+// BuildArgs builds with docker buildx for other platforms. Images for several platforms can't be loaded into
+// Docker, so they are pushed by the build or left in the build cache.
+func (e *dockerEngine) BuildArgs(image string, platforms []string, push bool) ([]string, error) {
+	if len(platforms) == 0 {
+		return []string{"build", "-t", image}, nil
+	}
+	if err := e.Command("buildx", "version").Run(); err != nil {
+		return nil, fmt.Errorf("building for other platforms needs docker buildx, could not find it: %v", err)
+	}
+	args := []string{"buildx", "build", "-t", image, "--platform", strings.Join(platforms, ",")}
+	if push {
+		return append(args, "--push"), nil
+	}
+	if len(platforms) == 1 {
+		return append(args, "--load"), nil
+	}
+	fmt.Fprintln(os.Stderr, "Warning: images for several platforms can't be loaded into Docker, they are left in the build cache until pushed with fn deploy")
+	return args, nil
+}
+
+func (e *dockerEngine) PushArgs(image string, platforms []string) []string {
+	return []string{"push", image}
+}
+
+// ManifestInspectArgs has the platform of each manifest printed along with it.
+func (e *dockerEngine) ManifestInspectArgs(image string) []string {
+	return []string{"manifest", "inspect", "--verbose", image}
+}
+
+func (e *dockerEngine) CopyArgs(src, dst string) ([]string, error) {
+	return []string{"buildx", "imagetools", "create", "--tag", dst, src}, nil
+}
+
+// DigestArgs asks the registry, as images for several platforms are pushed by the build and never kept locally.
+func (e *dockerEngine) DigestArgs(image string) []string {
+	return []string{"buildx", "imagetools", "inspect", "--format", "{{.Manifest.Digest}}", image}
+}
+
+func (e *dockerEngine) ServerArgs() []string {
+	return []string{"-v", "/var/run/docker.sock:/var/run/docker.sock"}
+}
+
+type podmanEngine struct{}
+
+func (e *podmanEngine) Name() string                        { return "podman" }
+func (e *podmanEngine) Command(args ...string) *exec.Cmd    { return exec.Command("podman", args...) }
+func (e *podmanEngine) BuildPushes(platforms []string) bool { return false }
+func (e *podmanEngine) BuildEnv() []string                  { return nil }
+
+func (e *podmanEngine) CheckVersion() error {
+	if err := e.Command("version").Run(); err != nil {
+		return fmt.Errorf("could not check Podman version: %v", err)
+	}
+	return nil
+}
+
+// BuildArgs builds images for several platforms into a manifest list named after the image.
+func (e *podmanEngine) BuildArgs(image string, platforms []string, push bool) ([]string, error) {
+	switch len(platforms) {
+	case 0:
+		return []string{"build", "-t", image}, nil
+	case 1:
+		return []string{"build", "-t", image, "--platform", platforms[0]}, nil
+	}
+	// a stale list would keep the images of earlier builds
+	e.Command("manifest", "rm", image).Run()
+	return []string{"build", "--manifest", image, "--platform", strings.Join(platforms, ",")}, nil
+}
+
+func (e *podmanEngine) PushArgs(image string, platforms []string) []string {
+	if len(platforms) > 1 {
+		return []string{"manifest", "push", "--all", image, "docker://" + image}
+	}
+	return []string{"push", image}
+}
+
+// ManifestInspectArgs inspects the image in the registry rather than a local manifest list of the same name.
+func (e *podmanEngine) ManifestInspectArgs(image string) []string {
+	return []string{"manifest", "inspect", "docker://" + image}
+}
+
+// CopyArgs fails, podman builds never push, the image is pushed from its local copy.
+func (e *podmanEngine) CopyArgs(src, dst string) ([]string, error) {
+	return nil, fmt.Errorf("podman can't copy %s to %s between registries, push it from its local copy", src, dst)
+}
+
+// DigestArgs reads the digests push recorded for the image.
+func (e *podmanEngine) DigestArgs(image string) []string {
+	return []string{"image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", image}
+}
+
+// ServerArgs mounts the Podman API socket, which serves the Docker API, in place of the Docker one.
+func (e *podmanEngine) ServerArgs() []string {
+	socket := "/run/podman/podman.sock"
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && os.Geteuid() != 0 {
+		socket = filepath.Join(dir, "podman", "podman.sock")
+	}
+	return []string{"-v", socket + ":/var/run/docker.sock"}
+}
+
+type nerdctlEngine struct{}
+
+func (e *nerdctlEngine) Name() string                        { return "nerdctl" }
+func (e *nerdctlEngine) Command(args ...string) *exec.Cmd    { return exec.Command("nerdctl", args...) }
+func (e *nerdctlEngine) BuildPushes(platforms []string) bool { return false }
+func (e *nerdctlEngine) BuildEnv() []string                  { return nil }
+
+func (e *nerdctlEngine) CheckVersion() error {
+	if err := e.Command("version").Run(); err != nil {
+		return fmt.Errorf("could not check nerdctl version: %v", err)
+	}
+	return nil
+}
+
+// BuildArgs builds with BuildKit, containerd keeps images for several platforms.
+func (e *nerdctlEngine) BuildArgs(image string, platforms []string, push bool) ([]string, error) {
+	args := []string{"build", "-t", image}
+	if len(platforms) > 0 {
+		args = append(args, "--platform", strings.Join(platforms, ","))
+	}
+	return args, nil
+}
+
+func (e *nerdctlEngine) PushArgs(image string, platforms []string) []string {
+	if len(platforms) > 1 {
+		return []string{"push", "--all-platforms", image}
+	}
+	return []string{"push", image}
+}
+
+func (e *nerdctlEngine) ManifestInspectArgs(image string) []string {
+	return []string{"manifest", "inspect", image}
+}
+
+// CopyArgs fails, nerdctl builds never push, the image is pushed from its local copy.
+func (e *nerdctlEngine) CopyArgs(src, dst string) ([]string, error) {
+	return nil, fmt.Errorf("nerdctl can't copy %s to %s between registries, push it from its local copy", src, dst)
+}
+
+// DigestArgs reads the digests of the image, which containerd pushes as it keeps it.
+func (e *nerdctlEngine) DigestArgs(image string) []string {
+	return []string{"image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", image}
+}
+
+// ServerArgs runs the functions server privileged, to start the function containers with Docker in Docker, as
+// containerd doesn't serve the Docker API.
+func (e *nerdctlEngine) ServerArgs() []string { return []string{"--privileged"} }
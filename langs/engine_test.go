@@ -0,0 +1,70 @@
+package langs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	os.Setenv("FN_CONTAINER_ENGINE", "podman")
+	defer os.Unsetenv("FN_CONTAINER_ENGINE")
+	engine, err := Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine.Name() != "podman" {
+		t.Errorf("expected podman, got %s", engine.Name())
+	}
+
+	// podman inspects the registry, not a local manifest list of the same name
+	if args := engine.ManifestInspectArgs("fn/hello:0.0.1"); !reflect.DeepEqual(args, []string{"manifest", "inspect", "docker://fn/hello:0.0.1"}) {
+		t.Errorf("unexpected manifest inspect arguments %q", args)
+	}
+
+	// only Docker builds push images themselves, to copy across registries
+	if _, err := engine.CopyArgs("fn/hello:0.0.1", "registry.example.com/fn/hello:0.0.1"); err == nil {
+		t.Error("expected podman to refuse copying images between registries")
+	}
+
+	os.Setenv("FN_CONTAINER_ENGINE", "rkt")
+	if _, err := Engine(); err == nil {
+		t.Error("expected an unsupported engine to be rejected")
+	}
+}
+
+func TestEngineBuildArgs(t *testing.T) {
+	for _, tc := range []struct {
+		engine    ContainerEngine
+		platforms []string
+		build     []string
+		push      []string
+	}{
+		{
+			&podmanEngine{},
+			[]string{"linux/arm64"},
+			[]string{"build", "-t", "fn/hello:0.0.1", "--platform", "linux/arm64"},
+			[]string{"push", "fn/hello:0.0.1"},
+		},
+		{
+			&nerdctlEngine{},
+			[]string{"linux/amd64", "linux/arm64"},
+			[]string{"build", "-t", "fn/hello:0.0.1", "--platform", "linux/amd64,linux/arm64"},
+			[]string{"push", "--all-platforms", "fn/hello:0.0.1"},
+		},
+	} {
+		build, err := tc.engine.BuildArgs("fn/hello:0.0.1", tc.platforms, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(build, tc.build) {
+			t.Errorf("expected %s to build with %q, got %q", tc.engine.Name(), tc.build, build)
+		}
+		if push := tc.engine.PushArgs("fn/hello:0.0.1", tc.platforms); !reflect.DeepEqual(push, tc.push) {
+			t.Errorf("expected %s to push with %q, got %q", tc.engine.Name(), tc.push, push)
+		}
+		if tc.engine.BuildPushes(tc.platforms) {
+			t.Errorf("expected %s to keep the images locally", tc.engine.Name())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FactorLangHelper provides a set of helper methods for the lifecycle of Factor vocabularies
+type FactorLangHelper struct {
+	BaseHelper
+}
+
+// BuildFromImage returns the Docker image used to deploy the Factor vocabulary
+func (lh *FactorLangHelper) BuildFromImage() string {
+	return "factorcode/factor:0.98"
+}
+
+// LooksLikeProject returns whether dir has a hello vocabulary.
+func (lh *FactorLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, filepath.Join("hello", "hello.factor"))
+}
+
+// RunFromImage returns the Docker image used to run the deployed Factor binary
+func (lh *FactorLangHelper) RunFromImage() string {
+	return "debian:stretch"
+}
+
+// HasBoilerplate returns whether the Factor runtime has boilerplate that can be generated.
+func (lh *FactorLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate a hello vocabulary and its unit tests.
+func (lh *FactorLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToVocab := filepath.Join(wd, "hello", "hello.factor")
+	if exists(pathToVocab) {
+		return ErrBoilerplateExists
+	}
+	if err = os.MkdirAll(filepath.Join(wd, "hello"), os.FileMode(0755)); err != nil {
+		return err
+	}
+	if err := writeSourceFile(pathToVocab, helloFactorSrcBoilerplate); err != nil {
+		return err
+	}
+
+	pathToTests := filepath.Join(wd, "hello", "hello-tests.factor")
+	return writeSourceFile(pathToTests, helloFactorTestBoilerplate)
+}
+
+// Entrypoint returns the deployed Factor binary.
+func (lh *FactorLangHelper) Entrypoint() string {
+	return "/function/hello/hello"
+}
+
+// DockerfileBuildCmds returns the build stage steps to deploy the vocabulary as a standalone binary.
+func (lh *FactorLangHelper) DockerfileBuildCmds() []string {
+	return []string{
+		"ADD . /function/",
+		"RUN factor -roots=/function -e='USING: namespaces tools.deploy tools.deploy.config ; " +
+			"\"/function/build\" deploy-directory set-global \"hello\" deploy'",
+	}
+}
+
+// DockerfileCopyCmds returns the Docker COPY command to copy the deployed binary and its image.
+func (lh *FactorLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"COPY --from=build-stage /function/build/hello /function/hello",
+	}
+}
+
+// HasPreBuild returns whether the Factor runtime has a pre-build step.
+func (lh *FactorLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function is a Factor vocabulary.
+func (lh *FactorLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "hello", "hello.factor")) {
+		return notProjectError("Could not find hello/hello.factor - are you sure this is a Factor vocabulary?")
+	}
+
+	return nil
+}
+
+const (
+	helloFactorSrcBoilerplate = `USING: io kernel sequences ;
+IN: hello
+
+: greeting ( name -- string )
+    [ "World" ] when-empty "Hello " prepend ;
+
+: hello-main ( -- )
+    readln [ "" ] unless* greeting print ;
+
+MAIN: hello-main
+`
+
+	helloFactorTestBoilerplate = `USING: hello tools.test ;
+IN: hello.tests
+
+{ "Hello World" } [ "" greeting ] unit-test
+{ "Hello Johnny" } [ "Johnny" greeting ] unit-test
+`
+)
@@ -0,0 +1,46 @@
+package langs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFactorBoilerplate(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	lh := &FactorLangHelper{}
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if src := readFile(t, filepath.Join(tmp, "hello", "hello.factor")); !strings.Contains(src, "MAIN: hello-main") {
+		t.Errorf("expected the hello vocabulary, got:\n%s", src)
+	}
+	if test := readFile(t, filepath.Join(tmp, "hello", "hello-tests.factor")); !strings.Contains(test, "unit-test") {
+		t.Errorf("expected the hello vocabulary to come with unit tests, got:\n%s", test)
+	}
+	if !lh.LooksLikeProject(tmp) {
+		t.Error("expected the boilerplate to look like a Factor vocabulary")
+	}
+	if err := lh.PreBuild(); err != nil {
+		t.Errorf("expected the boilerplate to pass the pre-build check, got %v", err)
+	}
+	if err := lh.GenerateBoilerplate(); err != ErrBoilerplateExists {
+		t.Errorf("expected the existing boilerplate to be kept, got %v", err)
+	}
+}
+
+func TestFactorDockerfileCmds(t *testing.T) {
+	lh := &FactorLangHelper{}
+	if build := strings.Join(lh.DockerfileBuildCmds(), "\n"); !strings.Contains(build, `"hello" deploy`) {
+		t.Errorf("expected the build stage to deploy the hello vocabulary, got:\n%s", build)
+	}
+	copyCmds := lh.DockerfileCopyCmds()
+	if len(copyCmds) != 1 || !strings.HasSuffix(copyCmds[0], "/function/hello") {
+		t.Errorf("expected the deployed binary to be copied to /function/hello, got %v", copyCmds)
+	}
+	if lh.Entrypoint() != "/function/hello/hello" {
+		t.Errorf("expected the entrypoint to run the deployed binary, got %s", lh.Entrypoint())
+	}
+}
@@ -0,0 +1,283 @@
+package langs
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// offlineEnv keeps the CLI off the network, FDK versions come from the cache of earlier lookups and Maven builds
+// from a pre-seeded repository
+const offlineEnv = "FN_OFFLINE"
+
+// fdkVersionCacheTTL is how long a resolved FDK version is used before it is looked up again. Stale versions are
+// still used when the lookup fails, or when offline.
+const fdkVersionCacheTTL = 24 * time.Hour
+
+// fdkVersionEnv names the variable pinning the FDK version of a runtime, such as FN_JAVA_FDK_VERSION
+func fdkVersionEnv(runtime string) string {
+	return "FN_" + strings.ToUpper(runtime) + "_FDK_VERSION"
+}
+
+// FDKVersionResolver looks up the latest released version of an FDK artifact
+type FDKVersionResolver interface {
+	LatestVersion(group, artifact string) (string, error)
+}
+
+// ContextFDKVersionResolver is implemented by resolvers whose lookups can be cancelled.
+type ContextFDKVersionResolver interface {
+	LatestVersionContext(ctx context.Context, group, artifact string) (string, error)
+}
+
+// MavenMetadataResolver resolves FDK versions from the maven-metadata.xml a Maven repository, such as Maven Central
+// or a mirror of it, publishes for each artifact.
+type MavenMetadataResolver struct {
+	RepositoryURL string
+}
+
+// LatestVersion returns the release version listed in the artifact's maven-metadata.xml
+func (r MavenMetadataResolver) LatestVersion(group, artifact string) (string, error) {
+	return r.LatestVersionContext(context.Background(), group, artifact)
+}
+
+// LatestVersionContext is LatestVersion, aborting the request once ctx is done.
+func (r MavenMetadataResolver) LatestVersionContext(ctx context.Context, group, artifact string) (string, error) {
+	metadataURL := fmt.Sprintf("%s/%s/%s/maven-metadata.xml",
+		strings.TrimSuffix(r.RepositoryURL, "/"), strings.Replace(group, ".", "/", -1), artifact)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", metadataURL, resp.Status)
+	}
+
+	var metadata struct {
+		Release string `xml:"versioning>release"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("could not parse %s: %v", metadataURL, err)
+	}
+	if metadata.Release == "" {
+		return "", fmt.Errorf("%s lists no release", metadataURL)
+	}
+	return metadata.Release, nil
+}
+
+// JavaFDKVersionResolver is where the Java helper looks up the latest FDK version when FN_JAVA_FDK_VERSION does
+// not pin one. Point it at another repository to resolve through a mirror.
+var JavaFDKVersionResolver FDKVersionResolver = MavenMetadataResolver{RepositoryURL: "https://repo1.maven.org/maven2"}
+
+// CratesIOResolver resolves FDK versions from the crates.io API, or a registry serving the same API. Crates have
+// no group, it is ignored.
+type CratesIOResolver struct {
+	APIURL string
+}
+
+// LatestVersion returns the newest stable version of the crate
+func (r CratesIOResolver) LatestVersion(group, artifact string) (string, error) {
+	return r.LatestVersionContext(context.Background(), group, artifact)
+}
+
+// LatestVersionContext is LatestVersion, aborting the request once ctx is done.
+func (r CratesIOResolver) LatestVersionContext(ctx context.Context, group, artifact string) (string, error) {
+	crateURL := fmt.Sprintf("%s/crates/%s", strings.TrimSuffix(r.APIURL, "/"), artifact)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, crateURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// crates.io turns away requests without a user agent
+	req.Header.Set("User-Agent", "fn-cli")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", crateURL, resp.Status)
+	}
+
+	var crate struct {
+		Crate struct {
+			MaxStableVersion string `json:"max_stable_version"`
+		} `json:"crate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&crate); err != nil {
+		return "", fmt.Errorf("could not parse %s: %v", crateURL, err)
+	}
+	if crate.Crate.MaxStableVersion == "" {
+		return "", fmt.Errorf("%s lists no stable version", crateURL)
+	}
+	return crate.Crate.MaxStableVersion, nil
+}
+
+// RustFDKVersionResolver is where the Rust helper looks up the latest FDK version when FN_RUST_FDK_VERSION does
+// not pin one.
+var RustFDKVersionResolver FDKVersionResolver = CratesIOResolver{APIURL: "https://crates.io/api/v1"}
+
+// HexResolver resolves FDK versions from the hex.pm API, or a repository serving the same API. Hex packages have no
+// group, it is ignored.
+type HexResolver struct {
+	APIURL string
+}
+
+// LatestVersion returns the newest stable version of the package
+func (r HexResolver) LatestVersion(group, artifact string) (string, error) {
+	return r.LatestVersionContext(context.Background(), group, artifact)
+}
+
+// LatestVersionContext is LatestVersion, aborting the request once ctx is done.
+func (r HexResolver) LatestVersionContext(ctx context.Context, group, artifact string) (string, error) {
+	packageURL := fmt.Sprintf("%s/packages/%s", strings.TrimSuffix(r.APIURL, "/"), artifact)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, packageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "fn-cli")
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", packageURL, resp.Status)
+	}
+
+	var pkg struct {
+		LatestStableVersion string `json:"latest_stable_version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return "", fmt.Errorf("could not parse %s: %v", packageURL, err)
+	}
+	if pkg.LatestStableVersion == "" {
+		return "", fmt.Errorf("%s lists no stable version", packageURL)
+	}
+	return pkg.LatestStableVersion, nil
+}
+
+// ElixirFDKVersionResolver is where the Elixir helper looks up the latest FDK version when FN_ELIXIR_FDK_VERSION
+// does not pin one.
+var ElixirFDKVersionResolver FDKVersionResolver = HexResolver{APIURL: "https://hex.pm/api"}
+
+// resolvedFDKVersions caches resolved versions by group:artifact for the life of the process, so generating
+// several functions looks each artifact up once
+var (
+	resolvedFDKVersionsMu sync.Mutex
+	resolvedFDKVersions   = map[string]string{}
+)
+
+// cachedFDKVersion is an entry of the FDK version cache file
+type cachedFDKVersion struct {
+	Version    string    `json:"version"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// fdkVersionCacheFile returns where resolved FDK versions are kept across runs, keyed by group:artifact
+var fdkVersionCacheFile = func() string {
+	return StateFile("fdk-versions.json")
+}
+
+func readFDKVersionCache() map[string]cachedFDKVersion {
+	cache := map[string]cachedFDKVersion{}
+	if b, err := ioutil.ReadFile(fdkVersionCacheFile()); err == nil {
+		json.Unmarshal(b, &cache)
+	}
+	return cache
+}
+
+// writeFDKVersionCache saves the cache, on a best effort basis as it only spares lookups
+func writeFDKVersionCache(cache map[string]cachedFDKVersion) {
+	path := fdkVersionCacheFile()
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if path == "" || err != nil || os.MkdirAll(filepath.Dir(path), os.FileMode(0755)) != nil {
+		return
+	}
+	ioutil.WriteFile(path, b, os.FileMode(0644))
+}
+
+// latestFDKVersion returns the latest version of the artifact, from the caches while they are fresh. The lock is
+// released during the lookup, so a slow repository doesn't hold up the lookups of other artifacts.
+func latestFDKVersion(ctx context.Context, resolver FDKVersionResolver, group, artifact string) (string, error) {
+	key := group + ":" + artifact
+	resolvedFDKVersionsMu.Lock()
+	if version, ok := resolvedFDKVersions[key]; ok {
+		resolvedFDKVersionsMu.Unlock()
+		return version, nil
+	}
+	cached, isCached := readFDKVersionCache()[key]
+	if isCached && (envEnabled(offlineEnv) || time.Since(cached.ResolvedAt) < fdkVersionCacheTTL) {
+		resolvedFDKVersions[key] = cached.Version
+		resolvedFDKVersionsMu.Unlock()
+		return cached.Version, nil
+	}
+	resolvedFDKVersionsMu.Unlock()
+	if envEnabled(offlineEnv) {
+		return "", fmt.Errorf("%s has not been resolved before, which %s requires", key, offlineEnv)
+	}
+
+	var version string
+	var err error
+	if r, ok := resolver.(ContextFDKVersionResolver); ok {
+		version, err = r.LatestVersionContext(ctx, group, artifact)
+	} else {
+		version, err = resolver.LatestVersion(group, artifact)
+	}
+
+	resolvedFDKVersionsMu.Lock()
+	defer resolvedFDKVersionsMu.Unlock()
+	if err != nil {
+		if !isCached || ctx.Err() != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v, using %s %s resolved on %s\n", err, key, cached.Version, cached.ResolvedAt.Format("2006-01-02"))
+		version = cached.Version
+	} else {
+		// the cache file is read again, other artifacts may have been resolved meanwhile
+		cache := readFDKVersionCache()
+		cache[key] = cachedFDKVersion{Version: version, ResolvedAt: time.Now()}
+		writeFDKVersionCache(cache)
+	}
+	resolvedFDKVersions[key] = version
+	return version, nil
+}
+
+// resolveFDKVersion returns the FDK version pinned for runtime with FN_<RUNTIME>_FDK_VERSION, or the latest one
+// resolver knows of. name is what errors call the runtime, such as Java.
+func resolveFDKVersion(ctx context.Context, runtime, name string, resolver FDKVersionResolver, group, artifact string) (string, error) {
+	versionEnv := fdkVersionEnv(runtime)
+
+	version := os.Getenv(versionEnv)
+	if version != "" {
+		return version, nil
+	}
+	if frozen() {
+		return "", &BuildError{
+			Category: ErrFDKVersionUnavailable,
+			Message:  fmt.Sprintf("The %s FDK version must be pinned by setting %s, as FN_FROZEN is set", name, versionEnv),
+		}
+	}
+
+	version, err := latestFDKVersion(ctx, resolver, group, artifact)
+	if err != nil {
+		return "", &BuildError{
+			Category: ErrFDKVersionUnavailable,
+			Message: fmt.Sprintf("Failed to fetch latest %s FDK version: %v. Check your network settings or manually override the version by setting %s",
+				name, err, versionEnv),
+		}
+	}
+	return version, nil
+}
@@ -0,0 +1,160 @@
+package langs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// keep the tests off the user's FDK version cache, settings and templates
+	dir, err := ioutil.TempDir("", "fn-state")
+	if err != nil {
+		panic(err)
+	}
+	StateDir = func() string { return dir }
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestMavenMetadataResolverCachesVersion(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/com/example/fdk/api/maven-metadata.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "<metadata><versioning><latest>1.1.0-SNAPSHOT</latest><release>1.0.60</release></versioning></metadata>")
+	}))
+	defer server.Close()
+	resolver := MavenMetadataResolver{RepositoryURL: server.URL}
+
+	for i := 0; i < 2; i++ {
+		if version, err := latestFDKVersion(context.Background(), resolver, "com.example.fdk", "api"); err != nil || version != "1.0.60" {
+			t.Errorf("expected the release version, got %v, %v", version, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the version to be resolved once, got %d requests", requests)
+	}
+
+	if _, err := latestFDKVersion(context.Background(), resolver, "com.example.fdk", "missing"); err == nil {
+		t.Error("expected an unknown artifact to fail to resolve")
+	}
+}
+
+type failingResolver struct{}
+
+func (failingResolver) LatestVersion(group, artifact string) (string, error) {
+	return "", errors.New("no network")
+}
+
+// slowResolver reports on started the lookups it starts, and resolves them once release is closed
+type slowResolver struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r slowResolver) LatestVersion(group, artifact string) (string, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return "1.0.70", nil
+}
+
+func TestLatestFDKVersionConcurrentLookups(t *testing.T) {
+	slow := slowResolver{started: make(chan struct{}, 1), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() {
+		_, err := latestFDKVersion(context.Background(), slow, "com.example.fdk", "slow")
+		done <- err
+	}()
+	<-slow.started
+
+	// the slow lookup doesn't hold up the others
+	fast := slowResolver{started: make(chan struct{}, 1), release: make(chan struct{})}
+	close(fast.release)
+	resolved := make(chan error, 1)
+	go func() {
+		_, err := latestFDKVersion(context.Background(), fast, "com.example.fdk", "fast")
+		resolved <- err
+	}()
+	select {
+	case err := <-resolved:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a lookup not to wait for another artifact's")
+	}
+	close(slow.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	cache := readFDKVersionCache()
+	if cache["com.example.fdk:slow"].Version != "1.0.70" || cache["com.example.fdk:fast"].Version != "1.0.70" {
+		t.Errorf("expected both versions to be cached, got %+v", cache)
+	}
+}
+
+func TestCratesIOResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crates/fdk" || r.Header.Get("User-Agent") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"crate": {"name": "fdk", "max_version": "0.2.0-beta.1", "max_stable_version": "0.1.3"}}`)
+	}))
+	defer server.Close()
+	resolver := CratesIOResolver{APIURL: server.URL}
+
+	if version, err := resolver.LatestVersion("", "fdk"); err != nil || version != "0.1.3" {
+		t.Errorf("expected the stable version, got %v, %v", version, err)
+	}
+	if _, err := resolver.LatestVersion("", "missing"); err == nil {
+		t.Error("expected an unknown crate to fail to resolve")
+	}
+}
+
+func TestHexResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packages/fdk" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"name": "fdk", "latest_version": "0.3.0-rc.1", "latest_stable_version": "0.2.1"}`)
+	}))
+	defer server.Close()
+	resolver := HexResolver{APIURL: server.URL}
+
+	if version, err := resolver.LatestVersion("", "fdk"); err != nil || version != "0.2.1" {
+		t.Errorf("expected the stable version, got %v, %v", version, err)
+	}
+	if _, err := resolver.LatestVersion("", "missing"); err == nil {
+		t.Error("expected an unknown package to fail to resolve")
+	}
+}
+
+func TestFDKVersionCacheFile(t *testing.T) {
+	defer os.Remove(fdkVersionCacheFile())
+	writeFDKVersionCache(map[string]cachedFDKVersion{
+		"com.example.fdk:stale": {Version: "1.0.50", ResolvedAt: time.Now().Add(-2 * fdkVersionCacheTTL)},
+	})
+
+	if version, err := latestFDKVersion(context.Background(), failingResolver{}, "com.example.fdk", "stale"); err != nil || version != "1.0.50" {
+		t.Errorf("expected the stale version when the lookup fails, got %v, %v", version, err)
+	}
+
+	os.Setenv(offlineEnv, "true")
+	defer os.Unsetenv(offlineEnv)
+	if _, err := latestFDKVersion(context.Background(), failingResolver{}, "com.example.fdk", "uncached"); err == nil {
+		t.Error("expected an uncached version to fail offline")
+	}
+}
@@ -0,0 +1,49 @@
+package langs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// frozen reports whether FN_FROZEN is set, requiring every version and image to be pinned up front so builds are
+// reproducible
+func frozen() bool {
+	return envEnabled("FN_FROZEN")
+}
+
+// CheckPinnedImages returns an error in frozen mode if an image the function builds or runs from, as ResolveImages
+// resolved it, including those of the target platforms, is not pinned to a tag other than latest, or to a digest,
+// by the image itself or FN_IMAGE_DIGESTS.
+func CheckPinnedImages(images *Images) error {
+	if !frozen() {
+		return nil
+	}
+	mirror, err := LoadImageMirror()
+	if err != nil {
+		return err
+	}
+	platforms, err := Platforms()
+	if err != nil {
+		return err
+	}
+	check := []string{images.Build, images.Run}
+	for _, platform := range platforms {
+		build, run := images.ArchImages(PlatformArch(platform))
+		check = append(check, build, run)
+	}
+	for _, image := range check {
+		if image != "" && !pinnedImage(mirror.Image(image)) {
+			return fmt.Errorf("The image %s is not pinned to a version, which FN_FROZEN requires", image)
+		}
+	}
+	return nil
+}
+
+func pinnedImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return true
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	return i >= 0 && name[i+1:] != "latest"
+}
@@ -0,0 +1,71 @@
+package langs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFrozenRejectsUnpinnedFDKVersion(t *testing.T) {
+	os.Setenv("FN_FROZEN", "1")
+	defer os.Unsetenv("FN_FROZEN")
+
+	if _, err := getFDKAPIVersion(context.Background()); !errors.Is(err, ErrFDKVersionUnavailable) {
+		t.Errorf("expected the FDK version lookup to be refused in frozen mode, got %v", err)
+	}
+	os.Setenv("FN_JAVA_FDK_VERSION", "1.0.56")
+	defer os.Unsetenv("FN_JAVA_FDK_VERSION")
+	if version, err := getFDKAPIVersion(context.Background()); err != nil || version != "1.0.56" {
+		t.Errorf("expected the pinned FDK version, got %v, %v", version, err)
+	}
+}
+
+func TestFrozenRejectsLatestImages(t *testing.T) {
+	os.Setenv("FN_FROZEN", "1")
+	defer os.Unsetenv("FN_FROZEN")
+
+	check := func(buildImage, runImage string) error {
+		images, err := ResolveImages(&JavaLangHelper{version: "1.8"}, "java8", buildImage, runImage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return CheckPinnedImages(images)
+	}
+	if err := check("", ""); err != nil {
+		t.Errorf("expected the pinned Java images to pass, got %v", err)
+	}
+
+	// the images func.yaml and the overrides set are the ones checked
+	if err := check("maven:latest", ""); err == nil {
+		t.Error("expected the latest build image of func.yaml to be rejected in frozen mode")
+	}
+	os.Setenv("FN_JAVA8_RUN_IMAGE", "example.com/jre")
+	if err := check("", ""); err == nil {
+		t.Error("expected an overridden run image without a tag to be rejected in frozen mode")
+	}
+	os.Setenv(ImageDigestsEnv, "example.com/jre=sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err := check("", ""); err != nil {
+		t.Errorf("expected the overridden run image pinned by FN_IMAGE_DIGESTS to pass, got %v", err)
+	}
+	os.Unsetenv(ImageDigestsEnv)
+	os.Unsetenv("FN_JAVA8_RUN_IMAGE")
+
+	os.Setenv("FN_JAVA_FDK_IMAGE_LATEST", "1")
+	defer os.Unsetenv("FN_JAVA_FDK_IMAGE_LATEST")
+	if err := check("", ""); err == nil {
+		t.Error("expected latest images to be rejected in frozen mode")
+	}
+
+	for image, pinned := range map[string]bool{
+		"debian:stretch":            true,
+		"localhost:5000/func":       false,
+		"localhost:5000/func:1.0":   true,
+		"node:latest":               false,
+		"node@sha256:0123456789abc": true,
+	} {
+		if pinnedImage(image) != pinned {
+			t.Errorf("expected %s pinned to be %v", image, pinned)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// funcDirEnv names the directory, relative to the working directory, the function source lives in when it is a
+// subdirectory of a larger repository. The working directory stays the Docker build context.
+const funcDirEnv = "FN_FUNC_DIR"
+
+// FunctionDir returns the directory helpers generate and look for the function source in, the working directory
+// unless FN_FUNC_DIR is set.
+func FunctionDir() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	dir := os.Getenv(funcDirEnv)
+	if dir == "" {
+		return wd, nil
+	}
+	if filepath.IsAbs(dir) || strings.HasPrefix(filepath.ToSlash(filepath.Clean(dir)), "..") {
+		return "", fmt.Errorf("The function directory %s set in %s must be within the working directory", dir, funcDirEnv)
+	}
+	fullPath := filepath.Join(wd, dir)
+	if fi, err := os.Stat(fullPath); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("Could not find the function directory %s set in %s", dir, funcDirEnv)
+	}
+	return fullPath, nil
+}
+
+// ContextCmds rewrites the sources of the Dockerfile ADD and COPY commands in cmds, which helpers give relative to
+// the function, to be relative to the build context when FN_FUNC_DIR is set. Copies from other stages are kept.
+func ContextCmds(cmds []string) []string {
+	dir := os.Getenv(funcDirEnv)
+	if dir == "" {
+		return cmds
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+
+	r := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		r[i] = cmd
+		fields := strings.Fields(cmd)
+		if len(fields) < 3 || (fields[0] != "ADD" && fields[0] != "COPY") {
+			continue
+		}
+		first, fromStage := 1, false
+		for ; first < len(fields) && strings.HasPrefix(fields[first], "--"); first++ {
+			fromStage = fromStage || strings.HasPrefix(fields[first], "--from=")
+		}
+		if fromStage || first == len(fields) || strings.HasPrefix(fields[first], "[") {
+			continue
+		}
+		for j := first; j < len(fields)-1; j++ {
+			if !strings.Contains(fields[j], "://") {
+				fields[j] = path.Join(dir, fields[j])
+			}
+		}
+		r[i] = strings.Join(fields, " ")
+	}
+	return r
+}
+
+// dockerfileUnsafeChars can't appear in the names of function files, the Dockerfile ADD and COPY commands would
+// split, expand or glob them
+const dockerfileUnsafeChars = " \t\r\n\"'$\\*?[]"
+
+// checkDockerfilePath ensures that path, a file within the function directory, and the function directory name can
+// be embedded in Dockerfile commands.
+func checkDockerfilePath(path string) error {
+	dir, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{filepath.Base(dir), filepath.ToSlash(rel)} {
+		if strings.ContainsAny(name, dockerfileUnsafeChars) {
+			return fmt.Errorf("%q can't be used in Dockerfile commands, rename it without whitespace, quotes or any of $\\*?[]", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFunctionDirSubdirectory(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	if err := os.MkdirAll(filepath.Join(tmp, "functions", "hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeScaffoldFile(filepath.Join(tmp, "functions", "hello", "pom.xml"), "<project/>"); err != nil {
+		t.Fatal(err)
+	}
+	lh := &JavaLangHelper{version: "1.8"}
+	if err := lh.PreBuild(); err == nil {
+		t.Error("expected no pom.xml in the working directory")
+	}
+
+	os.Setenv(funcDirEnv, "functions/missing")
+	defer os.Unsetenv(funcDirEnv)
+	if _, err := FunctionDir(); err == nil {
+		t.Error("expected a missing function directory to be rejected")
+	}
+
+	os.Setenv(funcDirEnv, "functions/hello")
+	if err := lh.PreBuild(); err != nil {
+		t.Errorf("expected the pom.xml to be found in the function directory, got %v", err)
+	}
+	cmds := ContextCmds([]string{
+		"ADD pom.xml /function/pom.xml",
+		"RUN mvn package",
+		"COPY --chown=fn:fn src /function/src",
+		"COPY --from=build-stage /function/target/*.jar /function/app/",
+	})
+	expected := []string{
+		"ADD functions/hello/pom.xml /function/pom.xml",
+		"RUN mvn package",
+		"COPY --chown=fn:fn functions/hello/src /function/src",
+		"COPY --from=build-stage /function/target/*.jar /function/app/",
+	}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("expected %q, got %q", expected, cmds)
+	}
+}
+
+func TestWriteSourceFileLineEndingsAndPaths(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if err := writeSourceFile(filepath.Join(tmp, "func.py"), "import fdk\r\nprint('hi')\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	if src := readFile(t, filepath.Join(tmp, "func.py")); src != "import fdk\nprint('hi')\n" {
+		t.Errorf("expected LF line endings, got %q", src)
+	}
+
+	for _, name := range []string{"my func.py", "$HOME.py"} {
+		if err := writeSourceFile(filepath.Join(tmp, name), ""); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
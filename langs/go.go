@@ -1,8 +1,9 @@
 package langs
 
 import (
-	"io/ioutil"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 )
 
@@ -14,10 +15,22 @@ func (lh *GoLangHelper) BuildFromImage() string {
 	return "funcy/go:dev"
 }
 
+// LooksLikeProject returns whether dir has a go.mod, or a func.go handler.
+func (lh *GoLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "go.mod", "func.go") }
+
 func (lh *GoLangHelper) RunFromImage() string {
 	return "funcy/go"
 }
 
+// ArchImages returns the official Go and Alpine images for the architectures the funcy/go images aren't published
+// for.
+func (lh *GoLangHelper) ArchImages(arch string) (string, string) {
+	if arch == "amd64" {
+		return "", ""
+	}
+	return "golang:1.9-alpine", "alpine:3.6"
+}
+
 func (h *GoLangHelper) DockerfileBuildCmds() []string {
 	r := []string{}
 	// more info on Go multi-stage builds: https://medium.com/travis-on-docker/multi-stage-docker-builds-for-creating-tiny-go-images-e0e1867efe5a
@@ -31,24 +44,94 @@ func (h *GoLangHelper) DockerfileBuildCmds() []string {
 	// 	"RUN cd /src && dep ensure",
 	// )
 	// }
-	r = append(r, "RUN cd /go/src/func/ && go build -o func")
+	build := "RUN cd /go/src/func/ && go build"
+	if n := h.BuildParallelism(); n > 1 {
+		build += fmt.Sprintf(" -p %d", n)
+	}
+	if isDebugBuild() {
+		// disable optimizations and inlining so the binary can be stepped through with a debugger
+		build += " -gcflags '-N -l'"
+	}
+	r = append(r, build+" -o func")
 	return r
 }
 
+// BuildCacheDirs returns the Go build cache.
+func (h *GoLangHelper) BuildCacheDirs() []string { return []string{"/root/.cache/go-build"} }
+
 func (h *GoLangHelper) DockerfileCopyCmds() []string {
 	return []string{
 		"COPY --from=build-stage /go/src/func/func /function/",
 	}
 }
 
+// SupportsLocalBuild returns whether a Go toolchain is installed on the host.
+func (lh *GoLangHelper) SupportsLocalBuild() bool {
+	_, err := exec.LookPath("go")
+	return err == nil
+}
+
+// LocalBuild cross compiles a static Linux binary of the function on the host.
+func (lh *GoLangHelper) LocalBuild(dir string) error {
+	arch := os.Getenv("FN_TARGET_ARCH")
+	if arch == "" {
+		arch = "amd64"
+	}
+	cmd := exec.Command("go", "build", "-o", "func")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+arch, "CGO_ENABLED=0")
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error building the function locally: %v", err)
+	}
+	return nil
+}
+
+func (lh *GoLangHelper) LocalBuildCopyCmds() []string {
+	return []string{
+		"ADD func /function/",
+	}
+}
+
+// HasAfterBuild returns whether a locally built binary needs cleaning up.
+func (lh *GoLangHelper) HasAfterBuild() bool { return LocalBuildEnabled(lh) }
+
+// AfterBuild removes the locally built binary now that it is in the image.
+func (lh *GoLangHelper) AfterBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(wd, "func"))
+}
+
+// Validate checks that the function has a func.go handler or a go.mod.
+func (lh *GoLangHelper) Validate(dir string) []LintIssue {
+	if !anyExists(dir, "func.go", "go.mod") {
+		return []LintIssue{lintError("func.go", "func.go is missing, the go runtime builds from it or a go.mod")}
+	}
+	return nil
+}
+
+// DependencyManifests returns go.mod and go.sum, which list the function's dependencies.
+func (lh *GoLangHelper) DependencyManifests() []string {
+	return []string{"go.mod", "go.sum"}
+}
+
+// TestCmds runs go test on the packages of the function.
+func (lh *GoLangHelper) TestCmds() []string { return []string{"cd /go/src/func/ && go test -v ./..."} }
+
 func (lh *GoLangHelper) Entrypoint() string {
 	return "./func"
 }
 
+func (lh *GoLangHelper) ColdStartClass() string { return ColdStartFast }
+
 func (lh *GoLangHelper) HasBoilerplate() bool { return true }
 
 func (lh *GoLangHelper) GenerateBoilerplate() error {
-	wd, err := os.Getwd()
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
@@ -61,11 +144,11 @@ func (lh *GoLangHelper) GenerateBoilerplate() error {
 		return ErrBoilerplateExists
 	}
 
-	if err := ioutil.WriteFile(codeFile, []byte(helloGoSrcBoilerplate), os.FileMode(0644)); err != nil {
+	if err := writeSourceFile(codeFile, helloGoSrcBoilerplate); err != nil {
 		return err
 	}
 
-	if err := ioutil.WriteFile(testFile, []byte(goTestBoilerPlate), os.FileMode(0644)); err != nil {
+	if err := writeSourceFile(testFile, goTestBoilerPlate); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,58 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoLocalBuild(t *testing.T) {
+	lh := &GoLangHelper{}
+	if !lh.SupportsLocalBuild() {
+		t.Skip("go is not installed")
+	}
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// module-aware toolchains need a go.mod, GOPATH mode ignores it
+	if err := ioutil.WriteFile("go.mod", []byte("module func\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lh.LocalBuild(tmp); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(tmp, "func")) {
+		t.Error("expected the local build to produce the func binary")
+	}
+}
+
+func TestGoAfterBuildCleansUpLocalBuild(t *testing.T) {
+	_, cleanup := chdirTemp(t)
+	defer cleanup()
+	lh := &GoLangHelper{}
+
+	if lh.HasAfterBuild() {
+		t.Error("expected no after-build step for Docker builds")
+	}
+	os.Setenv("FN_LOCAL_BUILD", "1")
+	defer os.Unsetenv("FN_LOCAL_BUILD")
+	if !lh.SupportsLocalBuild() {
+		t.Skip("go is not installed")
+	}
+	if !lh.HasAfterBuild() {
+		t.Fatal("expected an after-build step for local builds")
+	}
+	if err := ioutil.WriteFile("func", []byte{}, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := lh.AfterBuild(); err != nil {
+		t.Fatal(err)
+	}
+	if exists("func") {
+		t.Error("expected the local binary to be removed")
+	}
+}
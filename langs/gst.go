@@ -0,0 +1,110 @@
+package langs
+
+import (
+	"path/filepath"
+)
+
+// GnuSmalltalkLangHelper provides a set of helper methods for the lifecycle of GNU Smalltalk functions. Unlike
+// image based Smalltalks, the sources are filed in by gst every time the function starts.
+type GnuSmalltalkLangHelper struct {
+	BaseHelper
+}
+
+// BuildFromImage returns the Docker image gst is installed on
+func (lh *GnuSmalltalkLangHelper) BuildFromImage() string {
+	return "debian:bullseye-slim"
+}
+
+// LooksLikeProject returns whether dir has a func.st handler.
+func (lh *GnuSmalltalkLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "func.st") }
+
+// IsMultiStage returns false, the scripts run on the image gst is installed on.
+func (lh *GnuSmalltalkLangHelper) IsMultiStage() bool { return false }
+
+// DockerfileBuildCmds installs GNU Smalltalk.
+func (lh *GnuSmalltalkLangHelper) DockerfileBuildCmds() []string {
+	return []string{
+		"RUN apt-get update && apt-get install -y --no-install-recommends gnu-smalltalk && rm -rf /var/lib/apt/lists/*",
+	}
+}
+
+// DockerfileCopyCmds adds the sources and files in the Greeting class, failing the build if it does not load.
+func (lh *GnuSmalltalkLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"ADD *.st /function/",
+		"RUN gst -q Greeting.st",
+	}
+}
+
+// Cmd files in the Greeting class and runs the handler script.
+func (lh *GnuSmalltalkLangHelper) Cmd() string {
+	return "gst -q Greeting.st func.st"
+}
+
+// ColdStartClass returns ColdStartFast, gst starts from its small kernel image.
+func (lh *GnuSmalltalkLangHelper) ColdStartClass() string { return ColdStartFast }
+
+// HasBoilerplate returns whether the GNU Smalltalk runtime has boilerplate that can be generated.
+func (lh *GnuSmalltalkLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate a func.st handler, a Greeting class and a TestGreeting script asserting it.
+func (lh *GnuSmalltalkLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToHandler := filepath.Join(wd, "func.st")
+	if exists(pathToHandler) {
+		return ErrBoilerplateExists
+	}
+	files := map[string]string{
+		pathToHandler:                        helloGstSrcBoilerplate,
+		filepath.Join(wd, "Greeting.st"):     helloGstGreetingBoilerplate,
+		filepath.Join(wd, "TestGreeting.st"): helloGstTestBoilerplate,
+	}
+	for path, content := range files {
+		if err := writeSourceFile(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPreBuild returns whether the GNU Smalltalk runtime has a pre-build step.
+func (lh *GnuSmalltalkLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function has a handler script.
+func (lh *GnuSmalltalkLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "func.st")) {
+		return notProjectError("Could not find func.st - are you sure this is a GNU Smalltalk function?")
+	}
+
+	return nil
+}
+
+const (
+	helloGstSrcBoilerplate = `| input |
+input := stdin upToEnd trimSeparators.
+(Greeting for: input) displayNl.
+`
+
+	helloGstGreetingBoilerplate = `Object subclass: Greeting [
+    Greeting class >> for: aName [
+        ^aName isEmpty
+            ifTrue: ['Hello World']
+            ifFalse: ['Hello ', aName]
+    ]
+]
+`
+
+	// tests run with: gst -q Greeting.st TestGreeting.st
+	helloGstTestBoilerplate = `self assert: (Greeting for: '') = 'Hello World'.
+self assert: (Greeting for: 'Johnny') = 'Hello Johnny'.
+`
+)
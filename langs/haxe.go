@@ -0,0 +1,165 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// HaxeLangHelper provides a set of helper methods for the lifecycle of Haxe projects. The compilation target is
+// chosen with FN_HAXE_TARGET, either cpp (the default) or js, which runs the output on Node.js.
+type HaxeLangHelper struct {
+	BaseHelper
+}
+
+func haxeTarget() string {
+	if os.Getenv("FN_HAXE_TARGET") == "js" {
+		return "js"
+	}
+	return "cpp"
+}
+
+// BuildFromImage returns the Docker image used to compile the Haxe project
+func (lh *HaxeLangHelper) BuildFromImage() string {
+	return "haxe:3.4"
+}
+
+// LooksLikeProject returns whether dir has a build.hxml.
+func (lh *HaxeLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "build.hxml") }
+
+// RunFromImage returns the Docker image used to run the compiled function for the chosen target
+func (lh *HaxeLangHelper) RunFromImage() string {
+	if haxeTarget() == "js" {
+		return "funcy/node"
+	}
+	return "debian:stretch"
+}
+
+// HasBoilerplate returns whether the Haxe runtime has boilerplate that can be generated.
+func (lh *HaxeLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate a build.hxml, a Main class and its tests.
+func (lh *HaxeLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToHxml := filepath.Join(wd, "build.hxml")
+	if exists(pathToHxml) {
+		return ErrBoilerplateExists
+	}
+	if err := ioutil.WriteFile(pathToHxml, []byte(haxeBuildHxml), os.FileMode(0644)); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(wd, "test.hxml"), []byte(haxeTestHxml), os.FileMode(0644)); err != nil {
+		return err
+	}
+
+	mkDirAndWriteFile := func(dir, filename, content string) error {
+		fullPath := filepath.Join(wd, dir)
+		if err = os.MkdirAll(fullPath, os.FileMode(0755)); err != nil {
+			return err
+		}
+		return writeSourceFile(filepath.Join(fullPath, filename), content)
+	}
+
+	if err := mkDirAndWriteFile("src", "Main.hx", helloHaxeSrcBoilerplate); err != nil {
+		return err
+	}
+	return mkDirAndWriteFile("test", "TestMain.hx", helloHaxeTestBoilerplate)
+}
+
+// Entrypoint returns the command running the compiled function for the chosen target.
+func (lh *HaxeLangHelper) Entrypoint() string {
+	if haxeTarget() == "js" {
+		return "node func.js"
+	}
+	return "./Main"
+}
+
+// DockerfileBuildCmds returns the build stage steps to compile build.hxml for the chosen target.
+func (lh *HaxeLangHelper) DockerfileBuildCmds() []string {
+	if haxeTarget() == "js" {
+		return []string{
+			"RUN haxelib install hxnodejs",
+			"ADD . /function/",
+			"RUN haxe build.hxml -lib hxnodejs -js /function/out/func.js",
+		}
+	}
+	return []string{
+		"RUN haxelib install hxcpp",
+		"ADD . /function/",
+		"RUN haxe build.hxml -cpp /function/out",
+	}
+}
+
+// DockerfileCopyCmds returns the Docker COPY command to copy the compiled output.
+func (lh *HaxeLangHelper) DockerfileCopyCmds() []string {
+	if haxeTarget() == "js" {
+		return []string{
+			"COPY --from=build-stage /function/out/func.js /function/",
+		}
+	}
+	return []string{
+		"COPY --from=build-stage /function/out/Main /function/",
+	}
+}
+
+// HasPreBuild returns whether the Haxe runtime has a pre-build step.
+func (lh *HaxeLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function is a Haxe project.
+func (lh *HaxeLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "build.hxml")) {
+		return notProjectError("Could not find build.hxml - are you sure this is a Haxe project?")
+	}
+
+	return nil
+}
+
+const (
+	haxeBuildHxml = `-cp src
+-main Main
+`
+
+	haxeTestHxml = `-cp src
+-cp test
+-main TestMain
+--interp
+`
+
+	helloHaxeSrcBoilerplate = `class Main {
+    public static function greeting(name:String):String {
+        if (name == null || name == "") {
+            name = "World";
+        }
+        return "Hello " + name;
+    }
+
+    static function main() {
+        var input = try Sys.stdin().readAll().toString() catch (e:Dynamic) "";
+        Sys.println(greeting(StringTools.trim(input)));
+    }
+}
+`
+
+	helloHaxeTestBoilerplate = `class TestMain extends haxe.unit.TestCase {
+    public function testGreeting() {
+        assertEquals("Hello World", Main.greeting(""));
+        assertEquals("Hello Johnny", Main.greeting("Johnny"));
+    }
+
+    static function main() {
+        var runner = new haxe.unit.TestRunner();
+        runner.add(new TestMain());
+        Sys.exit(runner.run() ? 0 : 1);
+    }
+}
+`
+)
@@ -0,0 +1,58 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHaxeBoilerplate(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	lh := &HaxeLangHelper{}
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if hxml := readFile(t, filepath.Join(tmp, "build.hxml")); !strings.Contains(hxml, "-main Main") {
+		t.Errorf("expected build.hxml to compile Main, got:\n%s", hxml)
+	}
+	if src := readFile(t, filepath.Join(tmp, "src", "Main.hx")); !strings.Contains(src, "static function main()") {
+		t.Errorf("expected the Main class, got:\n%s", src)
+	}
+	if test := readFile(t, filepath.Join(tmp, "test", "TestMain.hx")); !strings.Contains(test, "haxe.unit.TestCase") {
+		t.Errorf("expected the Main class to come with tests, got:\n%s", test)
+	}
+	if !lh.LooksLikeProject(tmp) {
+		t.Error("expected the boilerplate to look like a Haxe project")
+	}
+	if err := lh.PreBuild(); err != nil {
+		t.Errorf("expected the boilerplate to pass the pre-build check, got %v", err)
+	}
+	if err := lh.GenerateBoilerplate(); err != ErrBoilerplateExists {
+		t.Errorf("expected the existing boilerplate to be kept, got %v", err)
+	}
+}
+
+func TestHaxeTargets(t *testing.T) {
+	lh := &HaxeLangHelper{}
+	if build := strings.Join(lh.DockerfileBuildCmds(), "\n"); !strings.Contains(build, "-cpp /function/out") {
+		t.Errorf("expected the cpp target by default, got:\n%s", build)
+	}
+	if lh.RunFromImage() != "debian:stretch" || lh.Entrypoint() != "./Main" {
+		t.Errorf("expected the native binary to run on debian, got %s running %s", lh.RunFromImage(), lh.Entrypoint())
+	}
+
+	os.Setenv("FN_HAXE_TARGET", "js")
+	defer os.Unsetenv("FN_HAXE_TARGET")
+	if build := strings.Join(lh.DockerfileBuildCmds(), "\n"); !strings.Contains(build, "-js /function/out/func.js") {
+		t.Errorf("expected the js target, got:\n%s", build)
+	}
+	if copyCmds := lh.DockerfileCopyCmds(); len(copyCmds) != 1 || !strings.Contains(copyCmds[0], "func.js") {
+		t.Errorf("expected func.js to be copied, got %v", copyCmds)
+	}
+	if lh.RunFromImage() != "funcy/node" || lh.Entrypoint() != "node func.js" {
+		t.Errorf("expected func.js to run on node, got %s running %s", lh.RunFromImage(), lh.Entrypoint())
+	}
+}
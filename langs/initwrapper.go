@@ -0,0 +1,79 @@
+package langs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InitWrapperFile is the entrypoint wrapper FN_USE_INIT writes into the function directory for the build
+const InitWrapperFile = ".fn-init.sh"
+
+// the wrapper is PID 1 of the container, as tini is with docker run --init: it runs the function as its child,
+// forwards it the signals the container gets and, waiting for it, reaps the orphaned processes the kernel reparents
+// to it, which the JVM and other runtimes leave as zombies otherwise. The function reads the stdin of the container,
+// not the /dev/null background commands get.
+const initWrapper = `#!/bin/sh
+exec 3<&0
+"$@" <&3 3<&- &
+child=$!
+exec 3<&-
+for sig in HUP INT QUIT TERM USR1 USR2; do
+	trap "kill -$sig $child 2>/dev/null" $sig
+done
+while :; do
+	wait $child
+	status=$?
+	kill -0 $child 2>/dev/null || break
+done
+exit $status
+`
+
+// UseInitWrapper reports whether FN_USE_INIT asks for the function to be started through the wrapper.
+func UseInitWrapper() bool {
+	return envEnabled("FN_USE_INIT")
+}
+
+// WriteInitWrapper writes the executable entrypoint wrapper into dir.
+func WriteInitWrapper(dir string) error {
+	return ioutil.WriteFile(filepath.Join(dir, InitWrapperFile), []byte(initWrapper), os.FileMode(0755))
+}
+
+// imageEntrypoint returns the entrypoint of image, pulling it if it isn't there yet.
+var imageEntrypoint = func(image string) ([]string, error) {
+	inspect := func() ([]byte, error) {
+		return EngineCommand("image", "inspect", "--format", "{{json .Config.Entrypoint}}", image).Output()
+	}
+	out, err := inspect()
+	if err != nil {
+		pull := EngineCommand("pull", image)
+		pull.Stdout, pull.Stderr = os.Stderr, os.Stderr
+		if err := pull.Run(); err != nil {
+			return nil, fmt.Errorf("pulling %s for its entrypoint: %v", image, err)
+		}
+		if out, err = inspect(); err != nil {
+			return nil, fmt.Errorf("inspecting the entrypoint of %s: %v", image, err)
+		}
+	}
+	var entrypoint []string
+	if err := json.Unmarshal(out, &entrypoint); err != nil {
+		return nil, fmt.Errorf("inspecting the entrypoint of %s: %v", image, err)
+	}
+	return entrypoint, nil
+}
+
+// InitWrapperEntrypoint returns the ENTRYPOINT starting the function through the wrapper: the wrapper followed by the
+// entrypoint of func.yaml, or, for functions that only set a cmd, by the entrypoint of the image they run from.
+func InitWrapperEntrypoint(entrypoint, image string) ([]string, error) {
+	args := strings.Fields(entrypoint)
+	if len(args) == 0 {
+		var err error
+		if args, err = imageEntrypoint(image); err != nil {
+			return nil, err
+		}
+	}
+	return append([]string{"/fn-init.sh"}, args...), nil
+}
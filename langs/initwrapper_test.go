@@ -0,0 +1,52 @@
+package langs
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInitWrapper(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	if err := WriteInitWrapper(tmp); err != nil {
+		t.Fatal(err)
+	}
+	wrapper := filepath.Join(tmp, InitWrapperFile)
+
+	// the function reads the input of the container
+	cmd := exec.Command(wrapper, "cat")
+	cmd.Stdin = strings.NewReader(`{"name": "Johnny"}`)
+	out, err := cmd.Output()
+	if err != nil || string(out) != `{"name": "Johnny"}` {
+		t.Errorf("expected the input to reach the function, got %q, %v", out, err)
+	}
+
+	cmd = exec.Command(wrapper, "sh", "-c", "exit 3")
+	if err := cmd.Run(); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("expected the exit status of the function, got %v", err)
+	}
+}
+
+func TestInitWrapperEntrypoint(t *testing.T) {
+	defer func(f func(string) ([]string, error)) { imageEntrypoint = f }(imageEntrypoint)
+	imageEntrypoint = func(image string) ([]string, error) {
+		if image != "fnproject/fn-java-fdk:1.0.56" {
+			t.Errorf("unexpected image %s", image)
+		}
+		return []string{"/usr/bin/java", "-cp", "/function/app/*", "com.fnproject.fn.runtime.EntryPoint"}, nil
+	}
+
+	entrypoint, err := InitWrapperEntrypoint("./func", "fnproject/fn-java-fdk:1.0.56")
+	if err != nil || !reflect.DeepEqual(entrypoint, []string{"/fn-init.sh", "./func"}) {
+		t.Errorf("expected the wrapper to start the entrypoint of func.yaml, got %v, %v", entrypoint, err)
+	}
+	// functions only setting a cmd keep the entrypoint of their image
+	entrypoint, err = InitWrapperEntrypoint("", "fnproject/fn-java-fdk:1.0.56")
+	expected := []string{"/fn-init.sh", "/usr/bin/java", "-cp", "/function/app/*", "com.fnproject.fn.runtime.EntryPoint"}
+	if err != nil || !reflect.DeepEqual(entrypoint, expected) {
+		t.Errorf("expected the wrapper to start the entrypoint of the image, got %v, %v", entrypoint, err)
+	}
+}
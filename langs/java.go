@@ -2,12 +2,10 @@ package langs
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,69 +17,132 @@ type JavaLangHelper struct {
 	version string
 }
 
+// javaFDKImageVersion is the known-good release of the Java FDK images the helper builds and runs on. Bump it
+// together with the FDK changelog; FN_JAVA_FDK_IMAGE_LATEST=1 opts into the moving latest tags instead.
+const javaFDKImageVersion = "1.0.56"
+
+// javaMinFDKAPIVersion is the first stable release of the FDK API, unlike javaFDKImageVersion, which tags the images
+const javaMinFDKAPIVersion = "1.0.0"
+
+// javaFDKImageTag returns the FDK image tag for the helper's Java version
+func (lh *JavaLangHelper) javaFDKImageTag() string {
+	version := javaFDKImageVersion
+	if envEnabled("FN_JAVA_FDK_IMAGE_LATEST") {
+		version = "latest"
+	}
+	if lh.version == "9" {
+		return "jdk9-" + version
+	}
+	return version
+}
+
 // BuildFromImage returns the Docker image used to compile the Maven function project
 func (lh *JavaLangHelper) BuildFromImage() string {
-	if lh.version == "1.8" {
-		return "fnproject/fn-java-fdk-build:latest"
-	} else if lh.version == "9" {
-		return "fnproject/fn-java-fdk-build:jdk9-latest"
-	} else {
-		return ""
+	if lh.version == "1.8" || lh.version == "9" {
+		return "fnproject/fn-java-fdk-build:" + lh.javaFDKImageTag()
 	}
+	return ""
 }
 
-// RunFromImage returns the Docker image used to run the Java function.
+// RunFromImage returns the Docker image used to run the Java function, a distroless one for native images.
 func (lh *JavaLangHelper) RunFromImage() string {
-	if lh.version == "1.8" {
-		return "fnproject/fn-java-fdk:latest"
-	} else if lh.version == "9" {
-		return "fnproject/fn-java-fdk:jdk9-latest"
-	} else {
-		return ""
+	if lh.NativeImageBuild() {
+		return nativeImageRunImage
+	}
+	if lh.version == "1.8" || lh.version == "9" {
+		return "fnproject/fn-java-fdk:" + lh.javaFDKImageTag()
+	}
+	return ""
+}
+
+// PrewarmImage returns the build image, which ships a Maven repository primed with the FDK dependencies that
+// mavenOpts points builds at.
+func (lh *JavaLangHelper) PrewarmImage() (string, error) {
+	image := lh.BuildFromImage()
+	if image == "" {
+		return "", fmt.Errorf("no build image for Java version %v", lh.version)
+	}
+	return image, nil
+}
+
+// LooksLikeProject returns whether dir has a pom.xml compiling for the helper's Java version, or for any version if
+// this is the default one and the pom.xml leaves it out. A function source without a pom.xml is for the default
+// version too.
+func (lh *JavaLangHelper) LooksLikeProject(dir string) bool {
+	pom, err := ioutil.ReadFile(filepath.Join(dir, "pom.xml"))
+	if err != nil {
+		return lh.version == defaultJavaSupportedVersion && anyExists(dir, "func.java", "Func.java")
+	}
+	if !bytes.Contains(pom, []byte("<source>")) {
+		return lh.version == defaultJavaSupportedVersion
 	}
+	return bytes.Contains(pom, []byte("<source>"+lh.version+"</source>"))
 }
 
 // HasBoilerplate returns whether the Java runtime has boilerplate that can be generated.
 func (lh *JavaLangHelper) HasBoilerplate() bool { return true }
 
 // GenerateBoilerplate will generate function boilerplate for a Java runtime. The default boilerplate is for a Maven
-// project.
+// project. It fails without writing anything if any of the files already exists.
 func (lh *JavaLangHelper) GenerateBoilerplate() error {
-	wd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
+	return lh.GenerateBoilerplateContext(context.Background())
+}
 
-	pathToPomFile := filepath.Join(wd, "pom.xml")
-	if exists(pathToPomFile) {
-		return ErrBoilerplateExists
-	}
+// GenerateBoilerplateContext is GenerateBoilerplate, aborting the FDK version lookup once ctx is done.
+func (lh *JavaLangHelper) GenerateBoilerplateContext(ctx context.Context) error {
+	return lh.generateBoilerplate(ctx, false)
+}
+
+// FDKVersion returns the version of the FDK API the boilerplate depends on.
+func (lh *JavaLangHelper) FDKVersion(ctx context.Context) (string, error) {
+	return getFDKAPIVersion(ctx)
+}
 
-	apiVersion, err := getFDKAPIVersion()
+// RegenerateBoilerplate generates the boilerplate again, replacing the files already there.
+func (lh *JavaLangHelper) RegenerateBoilerplate() error {
+	return lh.generateBoilerplate(context.Background(), true)
+}
+
+func (lh *JavaLangHelper) generateBoilerplate(ctx context.Context, overwrite bool) error {
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
 
-	if err := ioutil.WriteFile(pathToPomFile, []byte(pomFileContent(apiVersion, lh.version)), os.FileMode(0644)); err != nil {
-		return err
+	src := helloJavaSrcBoilerplate
+	if lh.LogFormat() == LogFormatJSON {
+		src = helloJavaJSONLoggingSrcBoilerplate
 	}
-
-	mkDirAndWriteFile := func(dir, filename, content string) error {
-		fullPath := filepath.Join(wd, dir)
-		if err = os.MkdirAll(fullPath, os.FileMode(0755)); err != nil {
+	files := map[string]string{
+		"pom.xml": "", // rendered once the FDK version is known
+		"src/main/java/com/example/fn/HelloFunction.java":     src,
+		"src/test/java/com/example/fn/HelloFunctionTest.java": helloJavaTestBoilerplate,
+	}
+	if lh.LogFormat() == LogFormatJSON {
+		files["src/main/resources/logback.xml"] = logbackJSONConfig
+	}
+	infoPath, _ := lh.BuildInfoResource("")
+	files[infoPath] = "" // rendered once the FDK version is known
+	if settings := mavenMirrorSettingsContent(); settings != "" {
+		files[mavenMirrorSettings] = settings
+	}
+	if !overwrite {
+		if err := checkBoilerplate(wd, files); err != nil {
 			return err
 		}
-
-		fullFilePath := filepath.Join(fullPath, filename)
-		return ioutil.WriteFile(fullFilePath, []byte(content), os.FileMode(0644))
 	}
 
-	err = mkDirAndWriteFile("src/main/java/com/example/fn", "HelloFunction.java", helloJavaSrcBoilerplate)
+	apiVersion, err := getFDKAPIVersion(ctx)
 	if err != nil {
 		return err
 	}
+	if err := CheckFDKVersion(lh, apiVersion); err != nil {
+		return err
+	}
+	files["pom.xml"] = pomFileContent(apiVersion, lh.version)
+	_, files[infoPath] = lh.BuildInfoResource(apiVersion)
 
-	return mkDirAndWriteFile("src/test/java/com/example/fn", "HelloFunctionTest.java", helloJavaTestBoilerplate)
+	return writeBoilerplate(wd, files)
 }
 
 // Cmd returns the Java runtime Docker entrypoint that will be executed when the function is executed.
@@ -89,23 +150,207 @@ func (lh *JavaLangHelper) Cmd() string {
 	return "com.example.fn.HelloFunction::handleRequest"
 }
 
+// StartupGracePeriodSeconds allows for the JVM boot and class loading before the function is ready.
+func (lh *JavaLangHelper) StartupGracePeriodSeconds() int { return 30 }
+
+// ColdStartClass returns ColdStartSlow, the JVM needs to boot and warm up, unless the function is a native image.
+func (lh *JavaLangHelper) ColdStartClass() string {
+	if lh.NativeImageBuild() {
+		return ColdStartFast
+	}
+	return ColdStartSlow
+}
+
+// MinFDKVersion returns the first stable release of the FDK API, which the runtime of the FDK images implements.
+// Forks of the FDK set with FN_FDK_GROUP or FN_FDK_ARTIFACT are versioned on their own, and left unchecked.
+func (lh *JavaLangHelper) MinFDKVersion() string {
+	if os.Getenv("FN_FDK_GROUP") != "" || os.Getenv("FN_FDK_ARTIFACT") != "" {
+		return ""
+	}
+	return javaMinFDKAPIVersion
+}
+
+// DockerfileStopSignal returns SIGTERM, on which the JVM runs its shutdown hooks.
+func (lh *JavaLangHelper) DockerfileStopSignal() string { return "SIGTERM" }
+
+// DockerfileSyntax returns the BuildKit frontend when FN_BUILDKIT is enabled.
+func (lh *JavaLangHelper) DockerfileSyntax() string {
+	if buildKitEnabled() {
+		return "docker/dockerfile:1.4"
+	}
+	return ""
+}
+
+// LogFormat returns LogFormatJSON when the function was scaffolded with FN_LOG_FORMAT=json, logging JSON lines
+// through Logback.
+func (lh *JavaLangHelper) LogFormat() string { return javaLogFormat() }
+
+func javaLogFormat() string {
+	if os.Getenv("FN_LOG_FORMAT") == LogFormatJSON {
+		return LogFormatJSON
+	}
+	return LogFormatText
+}
+
+// ContentTag hashes the pom.xml, the Maven settings and the sources, leaving out build output such as target/.
+func (lh *JavaLangHelper) ContentTag(dir string) (string, error) {
+	return contentTag(dir, "pom.xml", mavenMirrorSettings, "src")
+}
+
+// DefaultMemory leaves the JVM room for its heap on top of its own footprint.
+func (lh *JavaLangHelper) DefaultMemory() uint64 { return 256 }
+
+// BuildInfoResource returns a properties file on the classpath, packaged into the function jar, naming the runtime
+// and the FDK version pom.xml depends on. The git sha is a placeholder for CI to fill in.
+func (lh *JavaLangHelper) BuildInfoResource(fdkVersion string) (string, string) {
+	runtime := "java" + strings.TrimPrefix(lh.version, "1.")
+	return "src/main/resources/build-info.properties", fmt.Sprintf(buildInfoProperties, runtime, fdkVersion)
+}
+
+// IsHermeticBuild returns whether Maven runs offline, which requires a repository seeded with the dependencies.
+func (lh *JavaLangHelper) IsHermeticBuild() bool {
+	return envEnabled(offlineEnv) && os.Getenv(mavenLocalRepoEnv) != ""
+}
+
+// MaxPayloadBytes allows for larger requests, which the FDK buffers in the heap DefaultMemory makes room for.
+func (lh *JavaLangHelper) MaxPayloadBytes() int64 { return 6 << 20 }
+
+// ComposeFragment returns a docker-compose service for wiring the function into a local stack.
+func (lh *JavaLangHelper) ComposeFragment() string {
+	return composeService(lh.FDKListenPort())
+}
+
 // DockerfileCopyCmds returns the Docker COPY command to copy the compiled Java function jar and dependencies.
 func (lh *JavaLangHelper) DockerfileCopyCmds() []string {
-	return []string{
+	r := []string{
 		"COPY --from=build-stage /function/target/*.jar /function/app/",
 	}
+	if flags := javaGCFlags[os.Getenv(javaGCEnv)]; flags != "" {
+		// JAVA_TOOL_OPTIONS is picked up by any JVM, whatever entrypoint the FDK image uses
+		r = append(r, fmt.Sprintf("ENV JAVA_TOOL_OPTIONS %s", flags))
+	}
+	return r
 }
 
 // DockerfileBuildCmds returns the build stage steps to compile the Maven function project.
 func (lh *JavaLangHelper) DockerfileBuildCmds() []string {
-	return []string{
-		fmt.Sprintf("ENV MAVEN_OPTS %s", mavenOpts()),
+	return append(lh.mavenDepsCmds(), lh.mavenPackageCmds()...)
+}
+
+// DockerfileStages resolves the dependencies in a stage of its own, which only depends on pom.xml, so that it stays
+// cached while the sources change. Native builds compile the jars into a native image next.
+func (lh *JavaLangHelper) DockerfileStages() []Stage {
+	stages := []Stage{
+		{Name: "deps", From: FromBuildImage, Cmds: lh.mavenDepsCmds()},
+		{Name: "build-stage", From: "deps", Cmds: lh.mavenPackageCmds()},
+	}
+	if lh.NativeImageBuild() {
+		return append(stages, nativeImageStages("/function/target/*.jar")...)
+	}
+	return append(stages, Stage{From: FromRunImage, Cmds: lh.DockerfileCopyCmds()})
+}
+
+// CacheAnalysisDockerfile isolates dependency resolution from compiling the sources.
+func (lh *JavaLangHelper) CacheAnalysisDockerfile() string {
+	var b bytes.Buffer
+	b.WriteString("# Build with docker build --progress=plain -f <this file> . to see which steps are CACHED\n\n")
+	fmt.Fprintf(&b, "# Step 1: dependencies, rebuilt when pom.xml changes\nFROM %s AS deps\nWORKDIR /function\n", lh.resolvedBuildImage(lh.BuildFromImage()))
+	b.WriteString(strings.Join(CacheMountCmds(lh, "java", lh.mavenDepsCmds()), "\n") + "\n\n")
+	b.WriteString("# Step 2: sources, rebuilt when anything under src changes\nFROM deps AS sources\n")
+	b.WriteString(strings.Join(CacheMountCmds(lh, "java", lh.mavenPackageCmds()), "\n") + "\n")
+	return b.String()
+}
+
+// mvnCmd returns the JSON array form of the Maven command line, up to its goals
+func (lh *JavaLangHelper) mvnCmd() string {
+	mvn := "\"mvn\""
+	if n := lh.BuildParallelism(); n > 1 {
+		mvn = fmt.Sprintf("\"mvn\", \"-T\", \"%d\"", n)
+	}
+	if envEnabled(offlineEnv) {
+		mvn += ", \"--offline\""
+	}
+	if exists(mavenMirrorSettings) {
+		mvn += ", \"-s\", \"/function/" + mavenMirrorSettings + "\""
+	}
+	return mvn
+}
+
+// mavenDepsCmds returns the steps resolving the function dependencies from pom.xml
+func (lh *JavaLangHelper) mavenDepsCmds() []string {
+	r := []string{fmt.Sprintf("ENV MAVEN_OPTS %s", mavenOpts())}
+	if repo := os.Getenv(mavenLocalRepoEnv); repo != "" {
+		r = append(r, fmt.Sprintf("ADD %s %s", filepath.ToSlash(repo), mavenSeededRepository))
+	}
+	if exists(mavenMirrorSettings) {
+		r = append(r, "ADD "+mavenMirrorSettings+" /function/"+mavenMirrorSettings)
+	}
+	return append(r, []string{
 		"ADD pom.xml /function/pom.xml",
-		"RUN [\"mvn\", \"package\", \"dependency:copy-dependencies\", \"-DincludeScope=runtime\", " +
+		"RUN [" + lh.mvnCmd() + ", \"package\", \"dependency:copy-dependencies\", \"-DincludeScope=runtime\", " +
 			"\"-DskipTests=true\", \"-Dmdep.prependGroupId=true\", \"-DoutputDirectory=target\", \"--fail-never\"]",
+	}...)
+}
+
+// mavenPackageCmds returns the steps compiling and packaging the function sources
+func (lh *JavaLangHelper) mavenPackageCmds() []string {
+	return []string{
 		"ADD src /function/src",
-		"RUN [\"mvn\", \"package\"]",
+		"RUN [" + lh.mvnCmd() + ", \"package\"]",
+	}
+}
+
+// Validate checks that the function is a Maven project depending on the FDK API, at the version pinned if one is.
+func (lh *JavaLangHelper) Validate(dir string) []LintIssue {
+	if issues := requireFiles(dir, "java", "pom.xml"); issues != nil {
+		return issues
+	}
+	pom, issues := readLintFile(dir, "pom.xml")
+	if issues != nil {
+		return issues
+	}
+	group, artifact := fdkCoordinates()
+	version, err := pomDependencyVersion(pom, group, artifact)
+	if err != nil {
+		return []LintIssue{lintError("pom.xml", "pom.xml is not valid XML: %v", err)}
+	}
+	if strings.HasPrefix(version, "${") {
+		// set by a property, which the pom can take from anywhere
+		return nil
 	}
+	return checkFDKVersion("java", "pom.xml", version)
+}
+
+// Migrate updates the FDK API and testing dependencies of the pom.xml to the current FDK version.
+func (lh *JavaLangHelper) Migrate(dir string) ([]Migration, error) {
+	m, err := migrateFile(dir, "pom.xml", func(pom []byte) ([]byte, []string, error) {
+		version, err := getFDKAPIVersion(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+		group, artifact := fdkCoordinates()
+		updated, changes := migratePom(pom, group, artifact, version)
+		return updated, changes, nil
+	})
+	return migrations(m), err
+}
+
+// DependencyManifests returns the pom.xml, which list the function's dependencies.
+func (lh *JavaLangHelper) DependencyManifests() []string {
+	return []string{"pom.xml"}
+}
+
+// SupportsNativeImage returns true, the FDK runs in GraalVM native images.
+func (lh *JavaLangHelper) SupportsNativeImage() bool { return true }
+
+// NativeImageReflection registers the function class, which the FDK loads by name.
+func (lh *JavaLangHelper) NativeImageReflection(cmd string) ([]byte, error) {
+	return reflectionConfig(cmd)
+}
+
+// TestCmds runs the tests with Maven, Surefire writing the JUnit XML reports.
+func (lh *JavaLangHelper) TestCmds() []string {
+	return []string{"mvn test -Dsurefire.reportsDirectory=" + TestReportsDir}
 }
 
 // HasPreBuild returns whether the Java Maven runtime has a pre-build step.
@@ -113,78 +358,183 @@ func (lh *JavaLangHelper) HasPreBuild() bool { return true }
 
 // PreBuild ensures that the expected the function is based is a maven project.
 func (lh *JavaLangHelper) PreBuild() error {
-	wd, err := os.Getwd()
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
 
 	if !exists(filepath.Join(wd, "pom.xml")) {
-		return errors.New("Could not find pom.xml - are you sure this is a Maven project?")
+		return notProjectError("Could not find pom.xml - are you sure this is a Maven project?")
+	}
+
+	if gc := os.Getenv(javaGCEnv); gc != "" && javaGCFlags[gc] == "" {
+		return fmt.Errorf("Unknown garbage collector %q in %s, expected g1 or serial", gc, javaGCEnv)
+	}
+
+	repo := os.Getenv(mavenLocalRepoEnv)
+	if repo == "" {
+		if envEnabled(offlineEnv) {
+			return fmt.Errorf("%s is set but %s is not, an offline build needs a pre-seeded Maven repository", offlineEnv, mavenLocalRepoEnv)
+		}
+	} else if fi, err := os.Stat(filepath.Join(wd, repo)); err != nil || !fi.IsDir() {
+		return fmt.Errorf("Could not find the Maven repository %s set in %s, it must be a directory within the function", repo, mavenLocalRepoEnv)
 	}
 
 	return nil
 }
 
-func mavenOpts() string {
-	var opts bytes.Buffer
+// javaGCEnv selects the garbage collector of the function JVM, leaving the JVM's own choice when unset
+const javaGCEnv = "FN_JAVA_GC"
 
-	if parsedURL, err := url.Parse(os.Getenv("http_proxy")); err == nil {
-		opts.WriteString(fmt.Sprintf("-Dhttp.proxyHost=%s ", parsedURL.Hostname()))
-		opts.WriteString(fmt.Sprintf("-Dhttp.proxyPort=%s ", parsedURL.Port()))
-	}
+// javaGCFlags holds the collectors the JDK 8 and 9 images support, ZGC only arrived with JDK 11
+var javaGCFlags = map[string]string{
+	"g1":     "-XX:+UseG1GC",
+	"serial": "-XX:+UseSerialGC",
+}
+
+const (
+	// mavenLocalRepoEnv names a pre-seeded Maven repository, relative to the function, that is copied into the
+	// build stage and used in place of the one primed in the build image
+	mavenLocalRepoEnv = "FN_MAVEN_LOCAL_REPO"
 
-	if parsedURL, err := url.Parse(os.Getenv("https_proxy")); err == nil {
-		opts.WriteString(fmt.Sprintf("-Dhttps.proxyHost=%s ", parsedURL.Hostname()))
-		opts.WriteString(fmt.Sprintf("-Dhttps.proxyPort=%s ", parsedURL.Port()))
+	mavenSeededRepository = "/function/.m2/repository"
+	mavenCacheDir         = "/root/.m2"
+)
+
+// BuildCacheDirs returns the Maven repository, shared by the deps and build stages, unless a seeded repository
+// takes its place, which already holds the dependencies.
+func (lh *JavaLangHelper) BuildCacheDirs() []string {
+	if os.Getenv(mavenLocalRepoEnv) != "" {
+		return nil
 	}
+	return []string{mavenCacheDir}
+}
 
-	nonProxyHost := os.Getenv("no_proxy")
-	opts.WriteString(fmt.Sprintf("-Dhttp.nonProxyHosts=%s ", strings.Replace(nonProxyHost, ",", "|", -1)))
+// mavenCacheMount returns whether Maven runs with a BuildKit cache mount of BuildCacheDirs, persisting downloaded
+// dependencies across builds.
+func mavenCacheMount() bool {
+	return buildKitEnabled() && os.Getenv(mavenLocalRepoEnv) == ""
+}
 
-	opts.WriteString("-Dmaven.repo.local=/usr/share/maven/ref/repository")
+func mavenOpts() string {
+	repo := "/usr/share/maven/ref/repository"
+	if os.Getenv(mavenLocalRepoEnv) != "" {
+		repo = mavenSeededRepository
+	} else if mavenCacheMount() {
+		repo = mavenCacheDir + "/repository"
+	}
+	return jvmProxyFlags() + "-Dmaven.repo.local=" + repo
+}
 
-	return opts.String()
+// jvmProxyFlags returns the JVM system properties, each followed by a space, routing JVM build tools through the
+// proxies of the ProxyConfig. Builds check the configuration first, so an invalid one gives no flags.
+func jvmProxyFlags() string {
+	cfg, err := LoadProxyConfig()
+	if err != nil {
+		return ""
+	}
+	return cfg.JVMFlags()
 }
 
 /*    TODO temporarily generate maven project boilerplate from hardcoded values.
 Will eventually move to using a maven archetype.
 */
 func pomFileContent(APIversion, javaVersion string) string {
-	return fmt.Sprintf(pomFile, APIversion, APIversion, javaVersion, javaVersion)
+	group, artifact := fdkCoordinates()
+	return fmt.Sprintf(pomFile, pomProjectInfo(), group, artifact, APIversion, group, APIversion, pomLoggingDependencies(), javaVersion, javaVersion)
 }
 
-func getFDKAPIVersion() (string, error) {
-	const versionURL = "https://api.bintray.com/search/packages/maven?repo=fnproject&g=com.fnproject.fn&a=fdk"
-	const versionEnv = "FN_JAVA_FDK_VERSION"
-	fetchError := fmt.Errorf("Failed to fetch latest Java FDK version from %v. Check your network settings or manually override the version by setting %s", versionURL, versionEnv)
-
-	type parsedResponse struct {
-		Version string `json:"latest_version"`
+// pomLoggingDependencies returns the Logback and JSON encoder dependencies when logging JSON
+func pomLoggingDependencies() string {
+	if javaLogFormat() != LogFormatJSON {
+		return ""
 	}
-	version := os.Getenv(versionEnv)
-	if version != "" {
-		return version, nil
+	return `
+        <dependency>
+            <groupId>ch.qos.logback</groupId>
+            <artifactId>logback-classic</artifactId>
+            <version>1.2.3</version>
+        </dependency>
+        <dependency>
+            <groupId>net.logstash.logback</groupId>
+            <artifactId>logstash-logback-encoder</artifactId>
+            <version>5.2</version>
+        </dependency>`
+}
+
+// pomProjectInfo returns the project description placeholder and url, derived from the origin git remote, when
+// FN_PROJECT_URL_FROM_GIT is enabled. The url is left as a placeholder outside of a git repository with an origin.
+func pomProjectInfo() string {
+	if !envEnabled("FN_PROJECT_URL_FROM_GIT") {
+		return ""
 	}
-	resp, err := http.Get(versionURL)
-	if err != nil || resp.StatusCode != 200 {
-		return "", fetchError
+	url := "https://example.com/your-function"
+	if wd, err := os.Getwd(); err == nil {
+		if remote := browsableURL(gitOriginURL(wd)); remote != "" {
+			url = remote
+		}
 	}
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(url))
+	return fmt.Sprintf(`
+    <description>TODO: describe your function</description>
+    <url>%s</url>`, escaped.String())
+}
 
-	buf := bytes.Buffer{}
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return "", fetchError
+// mavenMirrorSettings is the Maven settings file generated with the boilerplate when FN_PACKAGE_INDEX_URL is set.
+// Builds of functions that have one run Maven with it.
+const mavenMirrorSettings = "settings.xml"
+
+// mavenMirrorSettingsContent returns Maven settings routing the downloads of every repository, plugins included,
+// through the FN_PACKAGE_INDEX_URL mirror, or nothing without a mirror.
+func mavenMirrorSettingsContent() string {
+	mirror := packageIndexURL()
+	if mirror == "" {
+		return ""
 	}
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(mirror))
+	return fmt.Sprintf(mavenSettingsFile, escaped.String())
+}
 
-	parsedResp := make([]parsedResponse, 1)
-	err = json.Unmarshal(buf.Bytes(), &parsedResp)
-	if err != nil {
-		return "", fetchError
+// fdkCoordinates returns the Maven group and artifact of the FDK API dependency, which teams building against a
+// fork of the FDK can override with FN_FDK_GROUP and FN_FDK_ARTIFACT.
+func fdkCoordinates() (group, artifact string) {
+	group, artifact = "com.fnproject.fn", "api"
+	if g := os.Getenv("FN_FDK_GROUP"); g != "" {
+		group = g
+	}
+	if a := os.Getenv("FN_FDK_ARTIFACT"); a != "" {
+		artifact = a
 	}
-	return parsedResp[0].Version, nil
+	return group, artifact
+}
+
+func getFDKAPIVersion(ctx context.Context) (string, error) {
+	group, artifact := fdkCoordinates()
+	return resolveFDKVersion(ctx, "java", "Java", JavaFDKVersionResolver, group, artifact)
 }
 
 const (
+	buildInfoProperties = `git.sha=unknown
+runtime=%s
+fdk.version=%s
+`
+
+	mavenSettingsFile = `<?xml version="1.0" encoding="UTF-8"?>
+<settings xmlns="http://maven.apache.org/SETTINGS/1.0.0"
+          xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+          xsi:schemaLocation="http://maven.apache.org/SETTINGS/1.0.0 http://maven.apache.org/xsd/settings-1.0.0.xsd">
+    <mirrors>
+        <mirror>
+            <id>mirror</id>
+            <mirrorOf>*</mirrorOf>
+            <url>%s</url>
+        </mirror>
+    </mirrors>
+</settings>
+`
+
 	pomFile = `<?xml version="1.0" encoding="UTF-8"?>
 <project xmlns="http://maven.apache.org/POM/4.0.0"
          xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
@@ -195,7 +545,7 @@ const (
     </properties>
     <groupId>com.example.fn</groupId>
     <artifactId>hello</artifactId>
-    <version>1.0.0</version>
+    <version>1.0.0</version>%s
 
     <repositories>
         <repository>
@@ -212,12 +562,12 @@ const (
 
     <dependencies>
         <dependency>
-            <groupId>com.fnproject.fn</groupId>
-            <artifactId>api</artifactId>
+            <groupId>%s</groupId>
+            <artifactId>%s</artifactId>
             <version>%s</version>
         </dependency>
         <dependency>
-            <groupId>com.fnproject.fn</groupId>
+            <groupId>%s</groupId>
             <artifactId>testing</artifactId>
             <version>%s</version>
             <scope>test</scope>
@@ -227,7 +577,7 @@ const (
             <artifactId>junit</artifactId>
             <version>4.12</version>
             <scope>test</scope>
-        </dependency>
+        </dependency>%s
     </dependencies>
 
     <build>
@@ -258,6 +608,37 @@ public class HelloFunction {
 
 }`
 
+	helloJavaJSONLoggingSrcBoilerplate = `package com.example.fn;
+
+import org.slf4j.Logger;
+import org.slf4j.LoggerFactory;
+
+public class HelloFunction {
+
+    private static final Logger log = LoggerFactory.getLogger(HelloFunction.class);
+
+    public String handleRequest(String input) {
+        String name = (input == null || input.isEmpty()) ? "world"  : input;
+
+        log.info("Greeting {}", name);
+        return "Hello, " + name + "!";
+    }
+
+}`
+
+	// logs go to stderr, stdout carries the function response
+	logbackJSONConfig = `<configuration>
+    <appender name="STDERR" class="ch.qos.logback.core.ConsoleAppender">
+        <target>System.err</target>
+        <encoder class="net.logstash.logback.encoder.LogstashEncoder"/>
+    </appender>
+
+    <root level="INFO">
+        <appender-ref ref="STDERR"/>
+    </root>
+</configuration>
+`
+
 	helloJavaTestBoilerplate = `package com.example.fn;
 
 import com.fnproject.fn.testing.*;
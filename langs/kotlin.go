@@ -0,0 +1,284 @@
+package langs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// KotlinLangHelper provides a set of helper methods for the lifecycle of Kotlin functions built with Gradle and run
+// on the Java FDK.
+type KotlinLangHelper struct {
+	BaseHelper
+}
+
+// BuildFromImage returns the Gradle image used to compile the function
+func (lh *KotlinLangHelper) BuildFromImage() string {
+	return "gradle:5.0-jdk8"
+}
+
+// LooksLikeProject returns whether dir has a build.gradle.kts.
+func (lh *KotlinLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "build.gradle.kts")
+}
+
+// RunFromImage returns the Java 8 FDK image the compiled function runs on, a distroless one for native images
+func (lh *KotlinLangHelper) RunFromImage() string {
+	if lh.NativeImageBuild() {
+		return nativeImageRunImage
+	}
+	return "fnproject/fn-java-fdk:" + (&JavaLangHelper{version: "1.8"}).javaFDKImageTag()
+}
+
+// Cmd returns the Java FDK entrypoint, the class and method handling calls.
+func (lh *KotlinLangHelper) Cmd() string {
+	return "com.example.fn.HelloFunction::handleRequest"
+}
+
+// DockerfileBuildCmds returns the build stage steps, resolving the dependencies in a layer of their own before
+// building and testing the function.
+func (lh *KotlinLangHelper) DockerfileBuildCmds() []string {
+	r := []string{}
+	if opts := strings.TrimSpace(jvmProxyFlags()); opts != "" {
+		r = append(r, fmt.Sprintf("ENV GRADLE_OPTS %s", opts))
+	}
+	return append(r,
+		"ADD *.gradle.kts /function/",
+		"RUN gradle --no-daemon dependencies",
+		"ADD src /function/src",
+		"RUN gradle --no-daemon build",
+	)
+}
+
+// BuildCacheDirs returns the Gradle dependency cache, shared by the dependencies and build steps.
+func (lh *KotlinLangHelper) BuildCacheDirs() []string { return []string{"/root/.gradle/caches"} }
+
+// DockerfileCopyCmds copies the function jar and its dependencies, which the build gathers in build/libs.
+func (lh *KotlinLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"COPY --from=build-stage /function/build/libs/*.jar /function/app/",
+	}
+}
+
+// DockerfileStages compiles the jars of the build into a native image for native builds, the other builds use the
+// build and copy steps.
+func (lh *KotlinLangHelper) DockerfileStages() []Stage {
+	if !lh.NativeImageBuild() {
+		return nil
+	}
+	return append([]Stage{{Name: "build-stage", From: FromBuildImage, Cmds: lh.DockerfileBuildCmds()}},
+		nativeImageStages("/function/build/libs/*.jar")...)
+}
+
+// SupportsNativeImage returns true, the function runs on the Java FDK, which runs in GraalVM native images.
+func (lh *KotlinLangHelper) SupportsNativeImage() bool { return true }
+
+// NativeImageReflection registers the function class, which the FDK loads by name, and the Kotlin metadata
+// annotation Kotlin reads the class's signatures from.
+func (lh *KotlinLangHelper) NativeImageReflection(cmd string) ([]byte, error) {
+	return reflectionConfig(cmd, "kotlin.Metadata")
+}
+
+// StartupGracePeriodSeconds allows for the JVM boot and class loading before the function is ready.
+func (lh *KotlinLangHelper) StartupGracePeriodSeconds() int { return 30 }
+
+// ColdStartClass returns ColdStartSlow, the JVM needs to boot and warm up, unless the function is a native image.
+func (lh *KotlinLangHelper) ColdStartClass() string {
+	if lh.NativeImageBuild() {
+		return ColdStartFast
+	}
+	return ColdStartSlow
+}
+
+// DefaultMemory leaves the JVM room for its heap on top of its own footprint.
+func (lh *KotlinLangHelper) DefaultMemory() uint64 { return 256 }
+
+// DockerfileStopSignal returns SIGTERM, on which the JVM runs its shutdown hooks.
+func (lh *KotlinLangHelper) DockerfileStopSignal() string { return "SIGTERM" }
+
+// HasBoilerplate returns whether the Kotlin runtime has boilerplate that can be generated.
+func (lh *KotlinLangHelper) HasBoilerplate() bool { return true }
+
+// FDKVersion returns the version of the FDK API the boilerplate depends on.
+func (lh *KotlinLangHelper) FDKVersion(ctx context.Context) (string, error) {
+	return getFDKAPIVersion(ctx)
+}
+
+// GenerateBoilerplate will generate a Gradle project with a handler and its test.
+func (lh *KotlinLangHelper) GenerateBoilerplate() error {
+	return lh.GenerateBoilerplateContext(context.Background())
+}
+
+// GenerateBoilerplateContext is GenerateBoilerplate, aborting the FDK version lookup once ctx is done.
+func (lh *KotlinLangHelper) GenerateBoilerplateContext(ctx context.Context) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToBuildFile := filepath.Join(wd, "build.gradle.kts")
+	if exists(pathToBuildFile) {
+		return ErrBoilerplateExists
+	}
+
+	apiVersion, err := getFDKAPIVersion(ctx)
+	if err != nil {
+		return err
+	}
+	group, artifact := fdkCoordinates()
+
+	files := map[string]string{
+		pathToBuildFile:                                            fmt.Sprintf(gradleBuildFile, group, artifact, apiVersion, group, apiVersion),
+		filepath.Join(wd, "settings.gradle.kts"):                   gradleSettingsFile,
+		filepath.Join(wd, "src", "main", "kotlin", "Hello.kt"):     helloKotlinSrcBoilerplate,
+		filepath.Join(wd, "src", "test", "kotlin", "HelloTest.kt"): helloKotlinTestBoilerplate,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+			return err
+		}
+		if err := writeSourceFile(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that the function is a Gradle project depending on the FDK API, at the version pinned if one is.
+func (lh *KotlinLangHelper) Validate(dir string) []LintIssue {
+	if issues := requireFiles(dir, "kotlin", "build.gradle.kts"); issues != nil {
+		return issues
+	}
+	build, issues := readLintFile(dir, "build.gradle.kts")
+	if issues != nil {
+		return issues
+	}
+	group, artifact := fdkCoordinates()
+	version := ""
+	dependency := regexp.MustCompile(regexp.QuoteMeta(group+":"+artifact+":") + `([^"']+)`)
+	if m := dependency.FindSubmatch(build); m != nil {
+		version = string(m[1])
+	}
+	return checkFDKVersion("kotlin", "build.gradle.kts", version)
+}
+
+// Migrate updates the FDK API and testing dependencies of the build.gradle.kts to the current FDK version.
+func (lh *KotlinLangHelper) Migrate(dir string) ([]Migration, error) {
+	m, err := migrateFile(dir, "build.gradle.kts", func(build []byte) ([]byte, []string, error) {
+		version, err := getFDKAPIVersion(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+		group, artifact := fdkCoordinates()
+		var changes []string
+		for _, a := range []string{artifact, "testing"} {
+			dependency := regexp.MustCompile(regexp.QuoteMeta(group+":"+a+":") + `([^"']+)`)
+			var c []string
+			build, c = replaceVersions(build, dependency, 1, group+":"+a, version)
+			changes = append(changes, c...)
+		}
+		return build, changes, nil
+	})
+	return migrations(m), err
+}
+
+// DependencyManifests returns the build.gradle.kts, which list the function's dependencies.
+func (lh *KotlinLangHelper) DependencyManifests() []string {
+	return []string{"build.gradle.kts"}
+}
+
+// TestCmds runs the tests with Gradle and copies its JUnit XML reports, failed tests included.
+func (lh *KotlinLangHelper) TestCmds() []string {
+	return []string{
+		"gradle --no-daemon test || status=$?",
+		"cp -r build/test-results/test/. " + TestReportsDir + "/ || true",
+		"exit ${status:-0}",
+	}
+}
+
+// HasPreBuild returns whether the Kotlin runtime has a pre-build step.
+func (lh *KotlinLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function has a Gradle build file.
+func (lh *KotlinLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "build.gradle.kts")) {
+		return notProjectError("Could not find build.gradle.kts - are you sure this is a Kotlin Gradle project?")
+	}
+
+	return nil
+}
+
+const (
+	gradleBuildFile = `plugins {
+    kotlin("jvm") version "1.3.10"
+}
+
+group = "com.example.fn"
+version = "1.0.0"
+
+repositories {
+    mavenCentral()
+}
+
+dependencies {
+    implementation(kotlin("stdlib"))
+    implementation("%s:%s:%s")
+    testImplementation("%s:testing:%s")
+    testImplementation("junit:junit:4.12")
+}
+
+// gather the runtime dependencies next to the function jar, the FDK puts everything in /function/app on the
+// classpath
+val copyDependencies by tasks.registering(Copy::class) {
+    from(configurations.runtimeClasspath)
+    into("$buildDir/libs")
+}
+
+tasks.named("assemble") {
+    dependsOn(copyDependencies)
+}
+`
+
+	gradleSettingsFile = `rootProject.name = "hello"
+`
+
+	helloKotlinSrcBoilerplate = `package com.example.fn
+
+class HelloFunction {
+    fun handleRequest(input: String?): String {
+        val name = if (input.isNullOrEmpty()) "world" else input
+        return "Hello, $name!"
+    }
+}
+`
+
+	helloKotlinTestBoilerplate = `package com.example.fn
+
+import com.fnproject.fn.testing.FnTestingRule
+import org.junit.Assert.assertEquals
+import org.junit.Rule
+import org.junit.Test
+
+class HelloFunctionTest {
+
+    @get:Rule
+    val testing: FnTestingRule = FnTestingRule.createDefault()
+
+    @Test
+    fun shouldReturnGreeting() {
+        testing.givenEvent().enqueue()
+        testing.thenRun(HelloFunction::class.java, "handleRequest")
+
+        assertEquals("Hello, world!", testing.getOnlyResult().getBodyAsString())
+    }
+}
+`
+)
@@ -0,0 +1,58 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKotlinGenerateBoilerplate(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	os.Setenv("FN_JAVA_FDK_VERSION", javaFDKImageVersion)
+	defer os.Unsetenv("FN_JAVA_FDK_VERSION")
+	lh := &KotlinLangHelper{}
+
+	if err := lh.PreBuild(); err == nil {
+		t.Error("expected the pre-build to require build.gradle.kts")
+	}
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if build := readFile(t, filepath.Join(tmp, "build.gradle.kts")); !strings.Contains(build, `implementation("com.fnproject.fn:api:1.0.56")`) {
+		t.Errorf("expected the FDK dependency, got:\n%s", build)
+	}
+	if !exists(filepath.Join(tmp, "src", "main", "kotlin", "Hello.kt")) {
+		t.Error("expected the handler source to be generated")
+	}
+	if err := lh.PreBuild(); err != nil {
+		t.Error(err)
+	}
+	if err := lh.GenerateBoilerplate(); err != ErrBoilerplateExists {
+		t.Errorf("expected ErrBoilerplateExists, got %v", err)
+	}
+}
+
+func TestKotlinGradleProxyOptions(t *testing.T) {
+	if cmds := (&KotlinLangHelper{}).DockerfileBuildCmds(); strings.HasPrefix(cmds[0], "ENV GRADLE_OPTS") {
+		t.Errorf("expected no GRADLE_OPTS without proxies, got %v", cmds)
+	}
+
+	os.Setenv("https_proxy", "http://proxy:3128")
+	defer os.Unsetenv("https_proxy")
+	if cmds := (&KotlinLangHelper{}).DockerfileBuildCmds(); cmds[0] != "ENV GRADLE_OPTS -Dhttps.proxyHost=proxy -Dhttps.proxyPort=3128" {
+		t.Errorf("expected the proxy to be passed to Gradle, got %v", cmds)
+	}
+}
+
+func TestKotlinBuildKitCacheMount(t *testing.T) {
+	os.Setenv("FN_BUILDKIT", "true")
+	defer os.Unsetenv("FN_BUILDKIT")
+	lh := &KotlinLangHelper{}
+	for _, cmd := range CacheMountCmds(lh, "kotlin", lh.DockerfileBuildCmds()) {
+		if strings.HasPrefix(cmd, "RUN ") && !strings.HasPrefix(cmd, "RUN --mount=type=cache,id=fn-kotlin-root-.gradle-caches,target=/root/.gradle/caches gradle") {
+			t.Errorf("expected Gradle to run with its cache mounted, got %q", cmd)
+		}
+	}
+}
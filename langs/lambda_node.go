@@ -16,12 +16,14 @@ func (lh *LambdaNodeHelper) Cmd() string {
 	return "func.handler"
 }
 
+func (lh *LambdaNodeHelper) ColdStartClass() string { return ColdStartFast }
+
 func (h *LambdaNodeHelper) DockerfileBuildCmds() []string {
 	r := []string{}
 	if exists("package.json") {
 		r = append(r,
 			"ADD package.json /function/",
-			"RUN npm install",
+			npmInstallCmd(),
 		)
 	}
 	// single stage build for this one, so add files
@@ -0,0 +1,66 @@
+package langs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lineCommentMarkers maps source file extensions to the marker starting a line comment in that language
+var lineCommentMarkers = map[string]string{
+	".go":     "//",
+	".java":   "//",
+	".rs":     "//",
+	".js":     "//",
+	".cs":     "//",
+	".hx":     "//",
+	".sol":    "//",
+	".php":    "//",
+	".kt":     "//",
+	".kts":    "//",
+	".rb":     "#",
+	".py":     "#",
+	".m":      "%",
+	".factor": "!",
+}
+
+// writeSourceFile writes a generated source file, prefixed with the license header read from the file set with
+// FN_LICENSE_HEADER commented out for the file's language. Files in languages without line comments are written
+// as is. Line endings are normalized to LF, whatever the host, so that generated files check in the same everywhere.
+func writeSourceFile(path, content string) error {
+	if err := checkDockerfilePath(path); err != nil {
+		return err
+	}
+	header, err := licenseHeader(filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	content = strings.Replace(header+content, "\r\n", "\n", -1)
+	return ioutil.WriteFile(path, []byte(content), os.FileMode(0644))
+}
+
+func licenseHeader(ext string) (string, error) {
+	headerFile := os.Getenv("FN_LICENSE_HEADER")
+	marker, ok := lineCommentMarkers[ext]
+	if headerFile == "" || !ok {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(headerFile)
+	if err != nil {
+		return "", err
+	}
+
+	var header bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			header.WriteString(marker + "\n")
+		} else {
+			header.WriteString(marker + " " + line + "\n")
+		}
+	}
+	header.WriteString("\n")
+	return header.String(), nil
+}
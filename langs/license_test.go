@@ -0,0 +1,35 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLicenseHeader(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile("HEADER", []byte("Copyright 2018 Example Corp\n\nLicensed under the Apache License, Version 2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("FN_LICENSE_HEADER", filepath.Join(tmp, "HEADER"))
+	defer os.Unsetenv("FN_LICENSE_HEADER")
+
+	if err := (&OctaveLangHelper{}).GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "% Copyright 2018 Example Corp\n%\n% Licensed under the Apache License, Version 2.0\n\n"
+	if src := readFile(t, filepath.Join(tmp, "func.m")); !strings.HasPrefix(src, expected+helloOctaveSrcBoilerplate[:5]) {
+		t.Errorf("expected func.m to start with the commented license header, got:\n%s", src)
+	}
+
+	if err := writeSourceFile(filepath.Join(tmp, "package.json"), "{}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if src := readFile(t, filepath.Join(tmp, "package.json")); src != "{}\n" {
+		t.Errorf("expected files without line comments to be left without a header, got:\n%s", src)
+	}
+}
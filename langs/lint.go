@@ -0,0 +1,99 @@
+package langs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// The severities of lint issues. Errors fail fn lint, they would fail the build or the function.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a problem fn lint found with a function.
+type LintIssue struct {
+	Severity string `json:"severity"`
+	// File is the file the issue is in, relative to the function directory, empty if it isn't about one.
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+}
+
+func lintError(file, format string, args ...interface{}) LintIssue {
+	return LintIssue{Severity: LintError, File: file, Message: fmt.Sprintf(format, args...)}
+}
+
+func lintWarning(file, format string, args ...interface{}) LintIssue {
+	return LintIssue{Severity: LintWarning, File: file, Message: fmt.Sprintf(format, args...)}
+}
+
+// requireFiles reports the files missing from dir, which the runtime can't build without.
+func requireFiles(dir, runtime string, files ...string) []LintIssue {
+	var issues []LintIssue
+	for _, file := range files {
+		if !exists(filepath.Join(dir, file)) {
+			issues = append(issues, lintError(file, "%s is missing, the %s runtime builds from it", file, runtime))
+		}
+	}
+	return issues
+}
+
+// checkFDKVersion warns when the FDK version a project depends on isn't the one pinned with FN_<RUNTIME>_FDK_VERSION,
+// which builds and generated boilerplate use.
+func checkFDKVersion(runtime, file, version string) []LintIssue {
+	if version == "" {
+		return []LintIssue{lintWarning(file, "%s does not depend on the FDK", file)}
+	}
+	versionEnv := fdkVersionEnv(runtime)
+	if pinned := os.Getenv(versionEnv); pinned != "" && pinned != version {
+		return []LintIssue{lintWarning(file, "%s depends on FDK %s, but %s pins %s", file, version, versionEnv, pinned)}
+	}
+	return nil
+}
+
+// pomDependencyVersion returns the version of the group:artifact dependency of a pom.xml, empty if it has none.
+func pomDependencyVersion(pom []byte, group, artifact string) (string, error) {
+	var project struct {
+		Dependencies []struct {
+			GroupID    string `xml:"groupId"`
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(pom, &project); err != nil {
+		return "", err
+	}
+	for _, dep := range project.Dependencies {
+		if dep.GroupID == group && dep.ArtifactID == artifact {
+			return dep.Version, nil
+		}
+	}
+	return "", nil
+}
+
+var cargoFDKDependencyPattern = regexp.MustCompile(`(?m)^` + rustFDKCrate + `\s*=\s*(?:"([^"]*)"|\{[^}]*version\s*=\s*"([^"]*)")`)
+
+// cargoFDKVersion returns the version of the FDK crate a Cargo.toml depends on, empty if it doesn't.
+func cargoFDKVersion(cargoToml []byte) string {
+	m := cargoFDKDependencyPattern.FindSubmatch(cargoToml)
+	if m == nil {
+		return ""
+	}
+	if len(m[1]) > 0 {
+		return string(m[1])
+	}
+	return string(m[2])
+}
+
+// readLintFile reads a file of dir for validation, reporting it as an issue if it can't be.
+func readLintFile(dir, file string) ([]byte, []LintIssue) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, []LintIssue{lintError(file, "could not read %s: %v", file, err)}
+	}
+	return b, nil
+}
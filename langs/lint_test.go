@@ -0,0 +1,59 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPomDependencyVersion(t *testing.T) {
+	pom := []byte(pomFileContent("1.0.60", "1.8"))
+	version, err := pomDependencyVersion(pom, "com.fnproject.fn", "api")
+	if err != nil || version != "1.0.60" {
+		t.Errorf("expected the FDK API version 1.0.60, got %q, %v", version, err)
+	}
+	if version, _ := pomDependencyVersion(pom, "com.fnproject.fn", "runtime"); version != "" {
+		t.Errorf("expected no version of a dependency the pom doesn't have, got %q", version)
+	}
+}
+
+func TestCargoFDKVersion(t *testing.T) {
+	for cargoToml, expected := range map[string]string{
+		cargoTomlContent("test", "0.2.1"):                       "0.2.1",
+		"[dependencies]\nfdk = { version = \"0.3\" }\n":         "0.3",
+		"[dependencies]\nfdk-macros = \"0.1\"\nserde = \"1\"\n": "",
+	} {
+		if version := cargoFDKVersion([]byte(cargoToml)); version != expected {
+			t.Errorf("expected FDK version %q of %q, got %q", expected, cargoToml, version)
+		}
+	}
+}
+
+func TestRustValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lh := &RustLangHelper{}
+
+	if issues := lh.Validate(dir); len(issues) != 2 || issues[0].Severity != LintError {
+		t.Errorf("expected Cargo.toml and src/main.rs to be reported missing, got %+v", issues)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "src", "main.rs"), []byte(mainContent()), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(cargoTomlContent("test", "0.2.1")), 0644)
+	if issues := lh.Validate(dir); len(issues) != 0 {
+		t.Errorf("expected the boilerplate to pass, got %+v", issues)
+	}
+
+	os.Setenv("FN_RUST_FDK_VERSION", "0.3.0")
+	defer os.Unsetenv("FN_RUST_FDK_VERSION")
+	if issues := lh.Validate(dir); len(issues) != 1 || issues[0].Severity != LintWarning {
+		t.Errorf("expected a warning about the pinned FDK version, got %+v", issues)
+	}
+}
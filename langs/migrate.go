@@ -0,0 +1,95 @@
+package langs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Migration is the rewrite of a file of a function bringing it up to date, for fn migrate.
+type Migration struct {
+	// File is relative to the function directory.
+	File string
+	Old  []byte
+	New  []byte
+	// Changes describe the updates, such as "com.fnproject.fn:api 1.0.56 -> 1.0.98".
+	Changes []string
+}
+
+// migrateFile rewrites the file of dir with rewrite, returning nil if it is missing or up to date.
+func migrateFile(dir, file string, rewrite func([]byte) ([]byte, []string, error)) (*Migration, error) {
+	old, err := ioutil.ReadFile(filepath.Join(dir, file))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	updated, changes, err := rewrite(old)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &Migration{File: file, Old: old, New: updated, Changes: changes}, nil
+}
+
+// migrations returns the migrations that aren't nil
+func migrations(ms ...*Migration) []Migration {
+	var found []Migration
+	for _, m := range ms {
+		if m != nil {
+			found = append(found, *m)
+		}
+	}
+	return found
+}
+
+// replaceVersions sets the group-th submatch of each match of re in b, the version of name, to version. It returns
+// the changes it made, as "name old -> new".
+func replaceVersions(b []byte, re *regexp.Regexp, group int, name, version string) ([]byte, []string) {
+	var out []byte
+	var changes []string
+	last := 0
+	for _, m := range re.FindAllSubmatchIndex(b, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		old := string(b[start:end])
+		if old == version {
+			continue
+		}
+		out = append(out, b[last:start]...)
+		out = append(out, version...)
+		last = end
+		changes = append(changes, fmt.Sprintf("%s %s -> %s", name, old, version))
+	}
+	if len(changes) == 0 {
+		return b, nil
+	}
+	return append(out, b[last:]...), changes
+}
+
+// migratePom updates the versions of the FDK API and testing dependencies of a pom.xml to version. Versions set by a
+// property are updated where the pom defines the property.
+func migratePom(pom []byte, group, artifact, version string) ([]byte, []string) {
+	var changes []string
+	for _, a := range []string{artifact, "testing"} {
+		dependency := regexp.MustCompile(`<groupId>\s*` + regexp.QuoteMeta(group) + `\s*</groupId>\s*<artifactId>\s*` +
+			regexp.QuoteMeta(a) + `\s*</artifactId>\s*<version>\s*([^<\s]+)\s*</version>`)
+		m := dependency.FindSubmatch(pom)
+		if m == nil {
+			continue
+		}
+		re := dependency
+		if property := string(m[1]); strings.HasPrefix(property, "${") && strings.HasSuffix(property, "}") {
+			name := regexp.QuoteMeta(strings.TrimSuffix(strings.TrimPrefix(property, "${"), "}"))
+			re = regexp.MustCompile(`<` + name + `>\s*([^<\s]+)\s*</` + name + `>`)
+		}
+		var c []string
+		pom, c = replaceVersions(pom, re, 1, group+":"+a, version)
+		changes = append(changes, c...)
+	}
+	return pom, changes
+}
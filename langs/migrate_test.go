@@ -0,0 +1,75 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigratePom(t *testing.T) {
+	pom := pomFileContent("1.0.56", "1.8")
+	updated, changes := migratePom([]byte(pom), "com.fnproject.fn", "api", "1.0.98")
+	if len(changes) != 2 || changes[0] != "com.fnproject.fn:api 1.0.56 -> 1.0.98" {
+		t.Errorf("expected the api and testing dependencies to be updated, got %v", changes)
+	}
+	if strings.Contains(string(updated), "1.0.56") || strings.Count(string(updated), "1.0.98") != 2 {
+		t.Errorf("expected the FDK dependencies at 1.0.98, got\n%s", updated)
+	}
+	if _, changes := migratePom(updated, "com.fnproject.fn", "api", "1.0.98"); changes != nil {
+		t.Errorf("expected an up to date pom.xml to be left alone, got %v", changes)
+	}
+
+	property := `<project>
+    <properties>
+        <fdk.version>1.0.56</fdk.version>
+    </properties>
+    <dependencies>
+        <dependency>
+            <groupId>com.fnproject.fn</groupId>
+            <artifactId>api</artifactId>
+            <version>${fdk.version}</version>
+        </dependency>
+    </dependencies>
+</project>`
+	updated, changes = migratePom([]byte(property), "com.fnproject.fn", "api", "1.0.98")
+	if len(changes) != 1 || !strings.Contains(string(updated), "<fdk.version>1.0.98</fdk.version>") {
+		t.Errorf("expected the fdk.version property to be updated, got %v\n%s", changes, updated)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("FN_RUST_FDK_VERSION", "0.3.0")
+	defer os.Unsetenv("FN_RUST_FDK_VERSION")
+	os.Setenv("FN_ELIXIR_FDK_VERSION", "0.2.1")
+	defer os.Unsetenv("FN_ELIXIR_FDK_VERSION")
+
+	ioutil.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(cargoTomlContent("fn", "0.1.2")), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "mix.exs"), []byte(`defp deps do
+    [{:fdk, "~> 0.1"}]
+  end`), 0644)
+
+	migrations, err := (&RustLangHelper{}).Migrate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || cargoFDKVersion(migrations[0].New) != "0.3.0" || migrations[0].Changes[0] != "fdk 0.1.2 -> 0.3.0" {
+		t.Errorf("expected Cargo.toml to depend on FDK 0.3.0, got %+v", migrations)
+	}
+	migrations, err = (&ElixirLangHelper{}).Migrate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || !strings.Contains(string(migrations[0].New), `{:fdk, "~> 0.2.1"}`) {
+		t.Errorf("expected mix.exs to require FDK ~> 0.2.1, got %+v", migrations)
+	}
+	if migrations, err := (&JavaLangHelper{}).Migrate(dir); migrations != nil || err != nil {
+		t.Errorf("expected nothing to migrate without a pom.xml, got %v %v", migrations, err)
+	}
+}
@@ -0,0 +1,28 @@
+package langs
+
+import "sort"
+
+// AllImagesToMirror returns every image the built-in and registered runtimes pull, sorted and without duplicates,
+// for operators pre-pulling them into an air-gapped registry.
+func AllImagesToMirror() []string {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, runtime := range SupportedRuntimes() {
+		lh, err := GetLangHelper(runtime)
+		if err != nil {
+			continue
+		}
+		resolved, err := ResolveImages(lh, runtime, "", "")
+		if err != nil {
+			continue
+		}
+		for _, image := range append([]string{resolved.Build, resolved.Run}, lh.ImagesToMirror()...) {
+			if image != "" && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	sort.Strings(images)
+	return images
+}
@@ -0,0 +1,96 @@
+package langs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// The build modes of func.yaml build_mode, which FN_BUILD_MODE sets for the functions whose func.yaml has none.
+const (
+	BuildModeEnv    = "FN_BUILD_MODE"
+	BuildModeJVM    = "jvm"
+	BuildModeNative = "native"
+)
+
+// NativeImageReflectionFile is the GraalVM reflection configuration native builds write into the function directory
+const NativeImageReflectionFile = ".fn-reflection.json"
+
+// nativeImageBuildImage is the GraalVM image, with the FDK's native libraries, native images are compiled in
+const nativeImageBuildImage = "fnproject/fn-java-native:" + javaFDKImageVersion
+
+// nativeImageRunImage runs native images, it has the glibc they link against and nothing else
+const nativeImageRunImage = "gcr.io/distroless/base-debian12"
+
+// SetBuildMode checks mode, a build mode of func.yaml, and passes it on to the helper.
+func SetBuildMode(lh LangHelper, runtime, mode string) error {
+	if err := CheckBuildMode(lh, runtime, mode); err != nil {
+		return err
+	}
+	lh.setBuildMode(mode)
+	return nil
+}
+
+// CheckBuildMode returns an error if mode isn't a build mode of the helper.
+func CheckBuildMode(lh LangHelper, runtime, mode string) error {
+	switch mode {
+	case "", BuildModeJVM:
+		return nil
+	case BuildModeNative:
+		if !lh.SupportsNativeImage() {
+			return fmt.Errorf("the %s runtime can't be built into a native image, build_mode %s is for JVM runtimes", runtime, BuildModeNative)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown build_mode %q, use %s or %s", mode, BuildModeJVM, BuildModeNative)
+}
+
+// WriteNativeImageReflection writes the reflection configuration of the helper for the function cmd into dir.
+func WriteNativeImageReflection(lh LangHelper, dir, cmd string) error {
+	config, err := lh.NativeImageReflection(cmd)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, NativeImageReflectionFile), config, 0644)
+}
+
+// reflectionConfig returns the GraalVM reflection configuration of the class of the FDK cmd, class::method, which
+// the FDK loads by name, and of extra classes.
+func reflectionConfig(cmd string, extra ...string) ([]byte, error) {
+	parts := strings.SplitN(cmd, "::", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("cmd %q is not a class::method the native image can be configured for", cmd)
+	}
+	type class struct {
+		Name                    string `json:"name"`
+		AllDeclaredConstructors bool   `json:"allDeclaredConstructors"`
+		AllDeclaredMethods      bool   `json:"allDeclaredMethods"`
+		AllDeclaredFields       bool   `json:"allDeclaredFields"`
+	}
+	classes := []class{{Name: parts[0], AllDeclaredConstructors: true, AllDeclaredMethods: true, AllDeclaredFields: true}}
+	for _, name := range extra {
+		classes = append(classes, class{Name: name, AllDeclaredMethods: true})
+	}
+	return json.MarshalIndent(classes, "", "  ")
+}
+
+// nativeImageStages returns the stages compiling the jars, the function's and its dependencies, that the
+// build-stage leaves at jars into a native image, and running it on a distroless image.
+func nativeImageStages(jars string) []Stage {
+	return []Stage{
+		{Name: "native-build", From: nativeImageBuildImage, Cmds: []string{
+			"WORKDIR /function",
+			fmt.Sprintf("COPY --from=build-stage %s target/", jars),
+			fmt.Sprintf("ADD %s reflection.json", NativeImageReflectionFile),
+			"RUN /usr/local/graalvm/bin/native-image --no-fallback -H:Name=func -H:ReflectionConfigurationFiles=reflection.json " +
+				"-classpath 'target/*' com.fnproject.fn.runtime.EntryPoint",
+		}},
+		{From: FromRunImage, Cmds: []string{
+			"COPY --from=native-build /function/func /function/func",
+			"COPY --from=native-build /function/runtime/lib/* /function/",
+			`ENTRYPOINT ["/function/func", "-XX:MaximumHeapSizePercent=80", "-Djava.library.path=/function"]`,
+		}},
+	}
+}
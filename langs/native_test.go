@@ -0,0 +1,62 @@
+package langs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReflectionConfig(t *testing.T) {
+	config, err := reflectionConfig("com.example.fn.HelloFunction::handleRequest", "kotlin.Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var classes []map[string]interface{}
+	if err := json.Unmarshal(config, &classes); err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 2 || classes[0]["name"] != "com.example.fn.HelloFunction" || classes[0]["allDeclaredMethods"] != true || classes[1]["name"] != "kotlin.Metadata" {
+		t.Errorf("unexpected reflection configuration %s", config)
+	}
+	if _, err := reflectionConfig("handleRequest"); err == nil {
+		t.Error("expected an error for a cmd without a class")
+	}
+}
+
+func TestCheckBuildMode(t *testing.T) {
+	if err := CheckBuildMode(&JavaLangHelper{}, "java", BuildModeNative); err != nil {
+		t.Error(err)
+	}
+	if err := CheckBuildMode(&GoLangHelper{}, "go", BuildModeNative); err == nil {
+		t.Error("expected an error for a native go build")
+	}
+	if err := CheckBuildMode(&GoLangHelper{}, "go", BuildModeJVM); err != nil {
+		t.Error(err)
+	}
+	if err := CheckBuildMode(&JavaLangHelper{}, "java", "aot"); err == nil {
+		t.Error("expected an error for an unknown build mode")
+	}
+}
+
+func TestNativeStages(t *testing.T) {
+	kt := &KotlinLangHelper{}
+	if stages := kt.DockerfileStages(); stages != nil {
+		t.Errorf("expected the JVM build of kotlin to keep the default stages, got %v", stages)
+	}
+
+	if err := SetBuildMode(kt, "kotlin", BuildModeNative); err != nil {
+		t.Fatal(err)
+	}
+	stages := kt.DockerfileStages()
+	if len(stages) != 3 || stages[0].Name != "build-stage" || stages[1].Name != "native-build" || stages[2].From != FromRunImage {
+		t.Fatalf("expected build, native-build and run stages, got %v", stages)
+	}
+	java := &JavaLangHelper{}
+	java.setBuildMode(BuildModeNative)
+	stages = java.DockerfileStages()
+	if len(stages) != 4 || stages[2].Name != "native-build" {
+		t.Fatalf("expected deps, build, native-build and run stages, got %v", stages)
+	}
+	if java.RunFromImage() != nativeImageRunImage {
+		t.Errorf("expected native images to run on %s, got %s", nativeImageRunImage, java.RunFromImage())
+	}
+}
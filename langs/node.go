@@ -1,5 +1,10 @@
 package langs
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type NodeLangHelper struct {
 	BaseHelper
 }
@@ -7,25 +12,73 @@ type NodeLangHelper struct {
 func (lh *NodeLangHelper) BuildFromImage() string {
 	return "funcy/node:dev"
 }
+
+// LooksLikeProject returns whether dir has a package.json or func.js handler, leaving out the Node.js handlers of
+// Solidity functions.
+func (lh *NodeLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "package.json", "func.js") && !anyExists(dir, "hardhat.config.js", "foundry.toml")
+}
+
 func (lh *NodeLangHelper) RunFromImage() string {
 	return "funcy/node"
 }
 
+// SupportsDockerlessBuild returns whether the function has no dependencies to install, none or vendored in
+// node_modules.
+func (lh *NodeLangHelper) SupportsDockerlessBuild(dir string) bool {
+	return !anyExists(dir, "package.json") || anyExists(dir, "node_modules")
+}
+
+// HotReloads returns whether the changes leave package.json alone, the sources are copied into the image as they are.
+func (lh *NodeLangHelper) HotReloads(changed []string) bool {
+	return !changesAny(changed, "package.json", "package-lock.json")
+}
+
+// Validate checks that the function has a func.js handler and that its package.json, if any, parses.
+func (lh *NodeLangHelper) Validate(dir string) []LintIssue {
+	issues := requireFiles(dir, "node", "func.js")
+	if !anyExists(dir, "package.json") {
+		return issues
+	}
+	pkg, readIssues := readLintFile(dir, "package.json")
+	if readIssues != nil {
+		return append(issues, readIssues...)
+	}
+	var v interface{}
+	if err := json.Unmarshal(pkg, &v); err != nil {
+		issues = append(issues, lintError("package.json", "package.json is not valid JSON: %v", err))
+	}
+	return issues
+}
+
+// DependencyManifests returns package.json and package-lock.json, which list the function's dependencies.
+func (lh *NodeLangHelper) DependencyManifests() []string {
+	return []string{"package.json", "package-lock.json"}
+}
+
+// TestCmds runs the test script of package.json.
+func (lh *NodeLangHelper) TestCmds() []string { return []string{"npm test"} }
+
 func (lh *NodeLangHelper) Entrypoint() string {
 	return "node func.js"
 }
 
+func (lh *NodeLangHelper) ColdStartClass() string { return ColdStartFast }
+
 func (h *NodeLangHelper) DockerfileBuildCmds() []string {
 	r := []string{}
 	if exists("package.json") {
 		r = append(r,
 			"ADD package.json /function/",
-			"RUN npm install",
+			npmInstallCmd(),
 		)
 	}
 	return r
 }
 
+// BuildCacheDirs returns the npm cache.
+func (h *NodeLangHelper) BuildCacheDirs() []string { return []string{"/root/.npm"} }
+
 func (h *NodeLangHelper) DockerfileCopyCmds() []string {
 	r := []string{"ADD . /function/"}
 	if exists("package.json") {
@@ -33,3 +86,11 @@ func (h *NodeLangHelper) DockerfileCopyCmds() []string {
 	}
 	return r
 }
+
+// npmInstallCmd installs the function dependencies, through the FN_PACKAGE_INDEX_URL registry mirror when set
+func npmInstallCmd() string {
+	if mirror := packageIndexURL(); mirror != "" {
+		return fmt.Sprintf("RUN npm install --registry %s", mirror)
+	}
+	return "RUN npm install"
+}
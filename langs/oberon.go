@@ -0,0 +1,150 @@
+package langs
+
+import (
+	"path/filepath"
+)
+
+// OberonLangHelper provides a set of helper methods for the lifecycle of Oberon-07 functions, compiled to a native
+// binary with OBNC.
+type OberonLangHelper struct {
+	BaseHelper
+}
+
+// BuildFromImage returns the Docker image used to compile the Oberon modules
+func (lh *OberonLangHelper) BuildFromImage() string {
+	return "debian:bullseye"
+}
+
+// LooksLikeProject returns whether dir has a Func.obn module.
+func (lh *OberonLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "Func.obn") }
+
+// RunFromImage returns the Docker image used to run the compiled binary
+func (lh *OberonLangHelper) RunFromImage() string {
+	return "debian:bullseye-slim"
+}
+
+// Entrypoint returns the compiled binary.
+func (lh *OberonLangHelper) Entrypoint() string {
+	return "./func"
+}
+
+// ColdStartClass returns ColdStartFast, the function is a native binary.
+func (lh *OberonLangHelper) ColdStartClass() string { return ColdStartFast }
+
+// DockerfileBuildCmds returns the build stage steps installing OBNC and compiling the Func module, along with
+// the modules it imports.
+func (lh *OberonLangHelper) DockerfileBuildCmds() []string {
+	return []string{
+		"RUN apt-get update && apt-get install -y --no-install-recommends obnc",
+		"ADD . /function/",
+		"RUN obnc Func.obn",
+	}
+}
+
+// DockerfileCopyCmds installs the garbage collector the binary links against and copies the binary.
+func (lh *OberonLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"RUN apt-get update && apt-get install -y --no-install-recommends libgc1 && rm -rf /var/lib/apt/lists/*",
+		"COPY --from=build-stage /function/Func /function/func",
+	}
+}
+
+// HasBoilerplate returns whether the Oberon runtime has boilerplate that can be generated.
+func (lh *OberonLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate the Func module, a Greeting module and a TestGreeting module asserting it.
+func (lh *OberonLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToFunc := filepath.Join(wd, "Func.obn")
+	if exists(pathToFunc) {
+		return ErrBoilerplateExists
+	}
+	files := map[string]string{
+		pathToFunc:                            helloOberonFuncBoilerplate,
+		filepath.Join(wd, "Greeting.obn"):     helloOberonGreetingBoilerplate,
+		filepath.Join(wd, "TestGreeting.obn"): helloOberonTestBoilerplate,
+	}
+	for path, content := range files {
+		if err := writeSourceFile(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPreBuild returns whether the Oberon runtime has a pre-build step.
+func (lh *OberonLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function has a Func module to compile.
+func (lh *OberonLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "Func.obn")) {
+		return notProjectError("Could not find Func.obn - are you sure this is an Oberon function?")
+	}
+
+	return nil
+}
+
+const (
+	helloOberonFuncBoilerplate = `MODULE Func;
+  IMPORT In, Out, Greeting;
+
+  VAR name, msg: ARRAY 256 OF CHAR; ch: CHAR; n: INTEGER;
+
+BEGIN
+  n := 0;
+  In.Char(ch);
+  WHILE In.Done & (ch # 0AX) & (n < LEN(name) - 1) DO
+    name[n] := ch; INC(n); In.Char(ch)
+  END;
+  name[n] := 0X;
+  Greeting.Of(name, msg);
+  Out.String(msg); Out.Ln
+END Func.
+`
+
+	helloOberonGreetingBoilerplate = `MODULE Greeting;
+
+  PROCEDURE Append(src: ARRAY OF CHAR; VAR dst: ARRAY OF CHAR; VAR n: INTEGER);
+    VAR i: INTEGER;
+  BEGIN
+    i := 0;
+    WHILE (i < LEN(src)) & (src[i] # 0X) & (n < LEN(dst) - 1) DO
+      dst[n] := src[i]; INC(n); INC(i)
+    END;
+    dst[n] := 0X
+  END Append;
+
+  PROCEDURE Of*(name: ARRAY OF CHAR; VAR msg: ARRAY OF CHAR);
+    VAR n: INTEGER;
+  BEGIN
+    n := 0;
+    Append("Hello ", msg, n);
+    IF name[0] = 0X THEN Append("World", msg, n) ELSE Append(name, msg, n) END
+  END Of;
+
+END Greeting.
+`
+
+	// tests run with: obnc TestGreeting.obn && ./TestGreeting
+	helloOberonTestBoilerplate = `MODULE TestGreeting;
+  IMPORT Greeting;
+
+  VAR msg: ARRAY 64 OF CHAR;
+
+BEGIN
+  Greeting.Of("", msg);
+  ASSERT(msg = "Hello World");
+  Greeting.Of("Johnny", msg);
+  ASSERT(msg = "Hello Johnny")
+END TestGreeting.
+`
+)
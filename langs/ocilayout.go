@@ -0,0 +1,505 @@
+package langs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	mediaTypeOCIIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCILayer          = "application/vnd.oci.image.layer.v1.tar+gzip"
+	mediaTypeDockerList        = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest    = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayer       = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	defaultRegistry            = "registry-1.docker.io"
+	dockerlessDefaultPlatform  = "linux/amd64"
+	dockerlessFunctionDir      = "function"
+	dockerlessLayerCreatedBy   = "fn build --no-docker"
+	annotationRefName          = "org.opencontainers.image.ref.name"
+	annotationContainerdImage  = "io.containerd.image.name"
+	registryManifestAcceptList = mediaTypeOCIIndex + ", " + mediaTypeDockerList + ", " + mediaTypeOCIManifest + ", " + mediaTypeDockerManifest
+)
+
+// registryHTTPClient pulls the base images of builds without Docker. It is a variable so tests can trust their
+// registry's certificate.
+var registryHTTPClient = http.DefaultClient
+
+// ImageTarball is an image assembled without Docker, the function directory added onto a base image pulled
+// straight from its registry, for helpers whose image build only copies the function.
+type ImageTarball struct {
+	// BaseImage is the image the function is added onto, for Platform, linux/amd64 if empty.
+	BaseImage string
+	Platform  string
+	// Dir is the function directory, added to /function. Hidden files and Exclude, paths relative to Dir, are
+	// left out.
+	Dir     string
+	Exclude []string
+	// Name is the name and tag the image is loaded as.
+	Name       string
+	Entrypoint []string
+	Cmd        []string
+	User       string
+	StopSignal string
+	Port       int
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *ociPlatform      `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+// WriteTo writes the image to w as an OCI image layout tarball, which docker load, podman load and skopeo read.
+func (t *ImageTarball) WriteTo(ctx context.Context, w io.Writer) error {
+	platform := t.Platform
+	if platform == "" {
+		platform = dockerlessDefaultPlatform
+	}
+	ref, err := parseImageRef(t.BaseImage)
+	if err != nil {
+		return err
+	}
+	reg := &registryClient{ref: ref}
+	manifest, err := reg.manifest(ctx, platform)
+	if err != nil {
+		return err
+	}
+	configBlob, err := reg.blobBytes(ctx, manifest.Config)
+	if err != nil {
+		return err
+	}
+
+	layer, diffID, err := t.functionLayer()
+	if err != nil {
+		return err
+	}
+	layerType := mediaTypeOCILayer
+	if manifest.MediaType == mediaTypeDockerManifest {
+		layerType = mediaTypeDockerLayer
+	}
+	layerDesc := ociDescriptor{MediaType: layerType, Digest: digestOf(layer), Size: int64(len(layer))}
+
+	configBlob, err = t.config(configBlob, diffID)
+	if err != nil {
+		return err
+	}
+	manifest.Config.Digest, manifest.Config.Size = digestOf(configBlob), int64(len(configBlob))
+	baseLayers := manifest.Layers
+	manifest.Layers = append(append([]ociDescriptor{}, baseLayers...), layerDesc)
+	manifestBlob, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestType := manifest.MediaType
+	if manifestType == "" {
+		manifestType = mediaTypeOCIManifest
+	}
+	index, err := json.Marshal(ociIndex{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeOCIIndex,
+		Manifests: []ociDescriptor{{
+			MediaType: manifestType,
+			Digest:    digestOf(manifestBlob),
+			Size:      int64(len(manifestBlob)),
+			Annotations: map[string]string{
+				annotationRefName:         imageTag(t.Name),
+				annotationContainerdImage: t.Name,
+			},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarFile(tw, "oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return err
+	}
+	for _, desc := range baseLayers {
+		if err := reg.copyBlob(ctx, tw, desc); err != nil {
+			return err
+		}
+	}
+	for _, blob := range [][]byte{layer, configBlob, manifestBlob} {
+		if err := writeTarFile(tw, blobPath(digestOf(blob)), blob); err != nil {
+			return err
+		}
+	}
+	if err := writeTarFile(tw, "index.json", index); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// functionLayer returns the gzipped tar of the function directory and the digest of the uncompressed tar. Entries
+// get a fixed time so that unchanged functions produce the same layer.
+func (t *ImageTarball) functionLayer() ([]byte, string, error) {
+	var layer bytes.Buffer
+	diffID := sha256.New()
+	gz := gzip.NewWriter(&layer)
+	tw := tar.NewWriter(io.MultiWriter(gz, diffID))
+
+	err := filepath.Walk(t.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.Dir, path)
+		if err != nil {
+			return err
+		}
+		if path != t.Dir && (strings.HasPrefix(info.Name(), ".") || containsString(t.Exclude, filepath.ToSlash(rel))) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = dockerlessFunctionDir
+		if path != t.Dir {
+			hdr.Name += "/" + filepath.ToSlash(rel)
+		}
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.ModTime = time.Unix(0, 0)
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, "", err
+	}
+	return layer.Bytes(), "sha256:" + hex.EncodeToString(diffID.Sum(nil)), nil
+}
+
+// config returns the base image config with the function's entrypoint, workdir, port and layer, keeping the fields
+// it doesn't know about.
+func (t *ImageTarball) config(base []byte, diffID string) ([]byte, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(base, &config); err != nil {
+		return nil, fmt.Errorf("could not parse the config of %s: %v", t.BaseImage, err)
+	}
+	runConfig, _ := config["config"].(map[string]interface{})
+	if runConfig == nil {
+		runConfig = map[string]interface{}{}
+	}
+	runConfig["WorkingDir"] = "/" + dockerlessFunctionDir
+	if len(t.Entrypoint) > 0 {
+		runConfig["Entrypoint"] = t.Entrypoint
+		// like Dockerfile ENTRYPOINT, a new entrypoint drops the base image's command
+		runConfig["Cmd"] = nil
+	}
+	if len(t.Cmd) > 0 {
+		runConfig["Cmd"] = t.Cmd
+	}
+	if t.User != "" {
+		runConfig["User"] = t.User
+	}
+	if t.StopSignal != "" {
+		runConfig["StopSignal"] = t.StopSignal
+	}
+	if t.Port > 0 {
+		ports, _ := runConfig["ExposedPorts"].(map[string]interface{})
+		if ports == nil {
+			ports = map[string]interface{}{}
+		}
+		ports[fmt.Sprintf("%d/tcp", t.Port)] = map[string]interface{}{}
+		runConfig["ExposedPorts"] = ports
+	}
+	config["config"] = runConfig
+
+	rootfs, _ := config["rootfs"].(map[string]interface{})
+	if rootfs == nil {
+		return nil, fmt.Errorf("the config of %s lists no layers", t.BaseImage)
+	}
+	diffIDs, _ := rootfs["diff_ids"].([]interface{})
+	rootfs["diff_ids"] = append(diffIDs, diffID)
+	history, _ := config["history"].([]interface{})
+	config["history"] = append(history, map[string]interface{}{
+		"created":    time.Unix(0, 0).UTC().Format(time.RFC3339),
+		"created_by": dockerlessLayerCreatedBy,
+	})
+	return json.Marshal(config)
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+// imageTag returns the tag of an image name, latest if it has none
+func imageTag(name string) string {
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		return name[i+1:]
+	}
+	return "latest"
+}
+
+// imageRef is an image reference split into where its registry API is
+type imageRef struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or digest
+	Reference string
+}
+
+// parseImageRef splits image the way Docker does: a first component that looks like a host is the registry,
+// images without one are on Docker Hub, official images under library/.
+func parseImageRef(image string) (imageRef, error) {
+	if !imageRefPattern.MatchString(image) {
+		return imageRef{}, fmt.Errorf("%q is not a valid image reference", image)
+	}
+	ref := imageRef{Registry: defaultRegistry, Reference: "latest"}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, name = parts[0], parts[1]
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// registryClient pulls from the registry of an image, with the anonymous bearer token registries like Docker Hub
+// hand out for public images.
+type registryClient struct {
+	ref   imageRef
+	token string
+}
+
+func (c *registryClient) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", c.ref.Registry, c.ref.Repository, path)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := registryHTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := c.authenticate(ctx, challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s returned %s", u, resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+// authenticate gets a token for pulling from the realm of a Bearer challenge
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("%s requires authentication, pull %s with credentials first", c.ref.Registry, c.ref.Repository)
+	}
+	params := map[string]string{}
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if kv := strings.SplitN(strings.TrimSpace(param), "=", 2); len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("%s sent an authentication challenge without a realm", c.ref.Registry)
+	}
+	query := url.Values{}
+	query.Set("service", params["service"])
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", c.ref.Repository)
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := registryHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not get a token to pull %s: %s", c.ref.Repository, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
+
+// manifest returns the image manifest for platform, picking it out of the index of multi-platform images
+func (c *registryClient) manifest(ctx context.Context, platform string) (*ociManifest, error) {
+	reference := c.ref.Reference
+	for {
+		resp, err := c.get(ctx, "manifests/"+reference, registryManifestAcceptList)
+		if err != nil {
+			return nil, err
+		}
+		var m struct {
+			ociManifest
+			Manifests []ociDescriptor `json:"manifests"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&m)
+		mediaType := resp.Header.Get("Content-Type")
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the manifest of %s: %v", c.ref.Repository, err)
+		}
+		if m.MediaType == "" {
+			m.MediaType = mediaType
+		}
+		if m.MediaType != mediaTypeOCIIndex && m.MediaType != mediaTypeDockerList {
+			return &m.ociManifest, nil
+		}
+		reference = ""
+		for _, desc := range m.Manifests {
+			if desc.Platform != nil && platformMatches(desc.Platform, platform) {
+				reference = desc.Digest
+				break
+			}
+		}
+		if reference == "" {
+			return nil, fmt.Errorf("%s:%s is not published for %s", c.ref.Repository, c.ref.Reference, platform)
+		}
+	}
+}
+
+func platformMatches(p *ociPlatform, platform string) bool {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || p.OS != parts[0] || p.Architecture != parts[1] {
+		return false
+	}
+	return len(parts) < 3 || p.Variant == parts[2]
+}
+
+func (c *registryClient) blobBytes(ctx context.Context, desc ociDescriptor) ([]byte, error) {
+	var b bytes.Buffer
+	if err := c.blob(ctx, desc, &b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// copyBlob streams a blob into the layout tarball
+func (c *registryClient) copyBlob(ctx context.Context, tw *tar.Writer, desc ociDescriptor) error {
+	if err := tw.WriteHeader(&tar.Header{Name: blobPath(desc.Digest), Mode: 0644, Size: desc.Size, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	return c.blob(ctx, desc, tw)
+}
+
+// blob copies a blob to w, checking it against its size and digest
+func (c *registryClient) blob(ctx context.Context, desc ociDescriptor, w io.Writer) error {
+	resp, err := c.get(ctx, "blobs/"+desc.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(resp.Body, desc.Size))
+	if err != nil {
+		return err
+	}
+	if n != desc.Size || "sha256:"+hex.EncodeToString(h.Sum(nil)) != desc.Digest {
+		return fmt.Errorf("the blob %s of %s does not match its digest", desc.Digest, c.ref.Repository)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package langs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseImageRef(t *testing.T) {
+	for image, expected := range map[string]imageRef{
+		"node":                           {defaultRegistry, "library/node", "latest"},
+		"funcy/node:dev":                 {defaultRegistry, "funcy/node", "dev"},
+		"localhost:5000/fn/node:8":       {"localhost:5000", "fn/node", "8"},
+		"ghcr.io/foundry-rs/foundry:1.0": {"ghcr.io", "foundry-rs/foundry", "1.0"},
+	} {
+		ref, err := parseImageRef(image)
+		if err != nil || ref != expected {
+			t.Errorf("expected %s to parse to %+v, got %+v, %v", image, expected, ref, err)
+		}
+	}
+}
+
+// fakeRegistry serves a multi-platform image made of a single empty layer
+func fakeRegistry(t *testing.T) *httptest.Server {
+	layer := gzipped(t, nil)
+	config := []byte(`{"architecture":"arm64","os":"linux","config":{"Env":["PATH=/usr/bin"],"Cmd":["sh"]},` +
+		`"rootfs":{"type":"layers","diff_ids":["sha256:base"]}}`)
+	manifest, _ := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeDockerManifest,
+		Config:        ociDescriptor{MediaType: "application/vnd.docker.container.image.v1+json", Digest: digestOf(config), Size: int64(len(config))},
+		Layers:        []ociDescriptor{{MediaType: mediaTypeDockerLayer, Digest: digestOf(layer), Size: int64(len(layer))}},
+	})
+	index, _ := json.Marshal(ociIndex{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeDockerList,
+		Manifests: []ociDescriptor{
+			{MediaType: mediaTypeDockerManifest, Digest: "sha256:amd64", Size: 1, Platform: &ociPlatform{OS: "linux", Architecture: "amd64"}},
+			{MediaType: mediaTypeDockerManifest, Digest: digestOf(manifest), Size: int64(len(manifest)), Platform: &ociPlatform{OS: "linux", Architecture: "arm64"}},
+		},
+	})
+	blobs := map[string][]byte{
+		"/v2/fn/node/manifests/8":                     index,
+		"/v2/fn/node/manifests/" + digestOf(manifest): manifest,
+		"/v2/fn/node/blobs/" + digestOf(config):       config,
+		"/v2/fn/node/blobs/" + digestOf(layer):        layer,
+	}
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := blobs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+}
+
+func gzipped(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := io.Copy(gz, tarball(t, files)); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	return b.Bytes()
+}
+
+func untar(t *testing.T, r io.Reader) map[string][]byte {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = b
+	}
+}
+
+func TestImageTarball(t *testing.T) {
+	server := fakeRegistry(t)
+	defer server.Close()
+	defer func(client *http.Client) { registryHTTPClient = client }(registryHTTPClient)
+	registryHTTPClient = server.Client()
+
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	for _, name := range []string{"func.js", ".env", "hello.oci.tar"} {
+		if err := ioutil.WriteFile(filepath.Join(tmp, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	image := &ImageTarball{
+		BaseImage:  strings.TrimPrefix(server.URL, "https://") + "/fn/node:8",
+		Platform:   "linux/arm64",
+		Dir:        tmp,
+		Exclude:    []string{"hello.oci.tar"},
+		Name:       "fn/hello:0.0.2",
+		Entrypoint: []string{"node", "func.js"},
+		Port:       8080,
+	}
+	var out bytes.Buffer
+	if err := image.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	layout := untar(t, &out)
+
+	var index ociIndex
+	if err := json.Unmarshal(layout["index.json"], &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 1 || index.Manifests[0].Annotations[annotationContainerdImage] != "fn/hello:0.0.2" {
+		t.Fatalf("expected the index to name the image, got %+v", index)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(layout[blobPath(index.Manifests[0].Digest)], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Layers) != 2 || manifest.Layers[1].MediaType != mediaTypeDockerLayer {
+		t.Fatalf("expected the function layer on top of the base one, got %+v", manifest.Layers)
+	}
+	for _, desc := range append(manifest.Layers, manifest.Config) {
+		if _, ok := layout[blobPath(desc.Digest)]; !ok {
+			t.Errorf("expected the layout to hold %s", desc.Digest)
+		}
+	}
+
+	var config struct {
+		Config struct {
+			Entrypoint   []string
+			Cmd          []string
+			WorkingDir   string
+			Env          []string
+			ExposedPorts map[string]struct{}
+		} `json:"config"`
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		} `json:"rootfs"`
+	}
+	if err := json.Unmarshal(layout[blobPath(manifest.Config.Digest)], &config); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(config.Config.Entrypoint, " ") != "node func.js" || config.Config.Cmd != nil ||
+		config.Config.WorkingDir != "/function" || len(config.Config.Env) != 1 || len(config.Config.ExposedPorts) != 1 {
+		t.Errorf("expected the function entrypoint on the base config, got %+v", config.Config)
+	}
+	if len(config.RootFS.DiffIDs) != 2 {
+		t.Errorf("expected the function layer in the rootfs, got %v", config.RootFS.DiffIDs)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(layout[blobPath(manifest.Layers[1].Digest)]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := untar(t, gz)
+	if string(files["function/func.js"]) != "func.js" {
+		t.Errorf("expected the function layer to add func.js, got %v", files)
+	}
+	if _, ok := files["function/.env"]; ok {
+		t.Error("expected hidden files to be left out")
+	}
+	if _, ok := files["function/hello.oci.tar"]; ok {
+		t.Error("expected the excluded tarball to be left out")
+	}
+}
@@ -0,0 +1,98 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// OctaveLangHelper provides a set of helper methods for the lifecycle of GNU Octave (MATLAB compatible) functions.
+// The image can be overridden with FN_OCTAVE_IMAGE.
+type OctaveLangHelper struct {
+	BaseHelper
+}
+
+// BuildFromImage returns the Docker image the function runs on
+func (lh *OctaveLangHelper) BuildFromImage() string {
+	if image := os.Getenv("FN_OCTAVE_IMAGE"); image != "" {
+		return image
+	}
+	return "gnuoctave/octave:6.4.0"
+}
+
+// LooksLikeProject returns whether dir has a func.m handler.
+func (lh *OctaveLangHelper) LooksLikeProject(dir string) bool { return anyExists(dir, "func.m") }
+
+// IsMultiStage returns false, Octave scripts are interpreted so there is nothing to build.
+func (lh *OctaveLangHelper) IsMultiStage() bool { return false }
+
+// DockerfileCopyCmds returns the Docker ADD command to add the scripts to the image.
+func (lh *OctaveLangHelper) DockerfileCopyCmds() []string {
+	return []string{
+		"ADD *.m /function/",
+	}
+}
+
+// Cmd runs the handler script without the GUI.
+func (lh *OctaveLangHelper) Cmd() string {
+	return "octave --no-gui --quiet func.m"
+}
+
+// HasBoilerplate returns whether the Octave runtime has boilerplate that can be generated.
+func (lh *OctaveLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate a func.m handler and a greeting.m function carrying its tests.
+func (lh *OctaveLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToHandler := filepath.Join(wd, "func.m")
+	if exists(pathToHandler) {
+		return ErrBoilerplateExists
+	}
+	if err := writeSourceFile(pathToHandler, helloOctaveSrcBoilerplate); err != nil {
+		return err
+	}
+	return writeSourceFile(filepath.Join(wd, "greeting.m"), helloOctaveGreetingBoilerplate)
+}
+
+// Validate checks that the function has a func.m handler.
+func (lh *OctaveLangHelper) Validate(dir string) []LintIssue {
+	return requireFiles(dir, "octave", "func.m")
+}
+
+// HasPreBuild returns whether the Octave runtime has a pre-build step.
+func (lh *OctaveLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function has a handler script.
+func (lh *OctaveLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, "func.m")) {
+		return notProjectError("Could not find func.m - are you sure this is an Octave function?")
+	}
+
+	return nil
+}
+
+const (
+	helloOctaveSrcBoilerplate = `input = strtrim(fread(stdin, Inf, "char=>char")');
+disp(greeting(input));
+`
+
+	// tests run with: octave --eval "test greeting"
+	helloOctaveGreetingBoilerplate = `function msg = greeting(name)
+  if isempty(name)
+    name = "World";
+  end
+  msg = ["Hello " name];
+end
+
+%!assert (greeting (""), "Hello World")
+%!assert (greeting ("Johnny"), "Hello Johnny")
+`
+)
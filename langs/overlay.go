@@ -0,0 +1,93 @@
+package langs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DockerfileOverlayDir is the directory of a function whose files add instructions to the Dockerfile generated for
+// it, a file per extension point: pre-build, post-build, pre-run and post-run. Several files can add to a point as
+// <point>.<anything>, such as pre-run.certs, in the order of their names.
+const DockerfileOverlayDir = "Dockerfile.d"
+
+// DockerfileExtensions are Dockerfile instructions injected at the extension points of the Dockerfile generated for
+// a runtime, set in func.yaml under build_extra or in the Dockerfile.d overlay.
+type DockerfileExtensions struct {
+	// PreBuild and PostBuild go before and after the build commands of the build stages. Functions built in a single
+	// stage get them in the one that runs them too.
+	PreBuild  []string `yaml:"pre_build,omitempty" json:"pre_build,omitempty"`
+	PostBuild []string `yaml:"post_build,omitempty" json:"post_build,omitempty"`
+	// PreRun and PostRun go before and after the function is copied into the image that runs it, before it switches
+	// to the helper's user.
+	PreRun  []string `yaml:"pre_run,omitempty" json:"pre_run,omitempty"`
+	PostRun []string `yaml:"post_run,omitempty" json:"post_run,omitempty"`
+}
+
+// IsEmpty reports whether there are no instructions to inject.
+func (e *DockerfileExtensions) IsEmpty() bool {
+	return len(e.PreBuild)+len(e.PostBuild)+len(e.PreRun)+len(e.PostRun) == 0
+}
+
+func (e *DockerfileExtensions) point(name string) *[]string {
+	switch name {
+	case "pre-build":
+		return &e.PreBuild
+	case "post-build":
+		return &e.PostBuild
+	case "pre-run":
+		return &e.PreRun
+	case "post-run":
+		return &e.PostRun
+	}
+	return nil
+}
+
+// LoadDockerfileExtensions returns the instructions of func.yaml, extra, followed by the ones of the Dockerfile.d
+// overlay of dir. Instructions can't start stages of their own, the generated Dockerfile lays the stages out.
+func LoadDockerfileExtensions(dir string, extra *DockerfileExtensions) (*DockerfileExtensions, error) {
+	ext := &DockerfileExtensions{}
+	if extra != nil {
+		*ext = *extra
+	}
+	overlay := filepath.Join(dir, DockerfileOverlayDir)
+	infos, err := ioutil.ReadDir(overlay)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		point := ext.point(strings.SplitN(name, ".", 2)[0])
+		if point == nil {
+			return nil, fmt.Errorf("%s/%s is not named after an extension point, use pre-build, post-build, pre-run or post-run",
+				DockerfileOverlayDir, name)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(overlay, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "\n") {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				*point = append(*point, line)
+			}
+		}
+	}
+
+	for _, lines := range [][]string{ext.PreBuild, ext.PostBuild, ext.PreRun, ext.PostRun} {
+		for _, line := range lines {
+			if fields := strings.Fields(line); len(fields) > 0 && strings.EqualFold(fields[0], "FROM") {
+				return nil, fmt.Errorf("%q can't be injected into the generated Dockerfile, build from a Dockerfile of your own to change its stages", line)
+			}
+		}
+	}
+	return ext, nil
+}
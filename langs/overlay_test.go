@@ -0,0 +1,57 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDockerfileExtensions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	overlay := filepath.Join(dir, DockerfileOverlayDir)
+	if err := os.MkdirAll(overlay, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"pre-run":       "# runtime packages\nRUN apk add --no-cache libpq\n",
+		"pre-run.certs": "COPY certs/ /usr/local/share/ca-certificates/\n\nRUN update-ca-certificates\n",
+		"post-build":    "RUN make test\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(overlay, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ext, err := LoadDockerfileExtensions(dir, &DockerfileExtensions{PreRun: []string{"ENV TZ=UTC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DockerfileExtensions{
+		PostBuild: []string{"RUN make test"},
+		PreRun: []string{
+			"ENV TZ=UTC",
+			"RUN apk add --no-cache libpq",
+			"COPY certs/ /usr/local/share/ca-certificates/",
+			"RUN update-ca-certificates",
+		},
+	}
+	if !reflect.DeepEqual(ext, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ext)
+	}
+
+	ioutil.WriteFile(filepath.Join(overlay, "prerun"), []byte("RUN true\n"), 0644)
+	if _, err := LoadDockerfileExtensions(dir, nil); err == nil {
+		t.Error("expected overlay files named after no extension point to fail")
+	}
+	os.Remove(filepath.Join(overlay, "prerun"))
+
+	if _, err := LoadDockerfileExtensions(dir, &DockerfileExtensions{PostRun: []string{"FROM scratch"}}); err == nil {
+		t.Error("expected instructions starting stages to fail")
+	}
+}
@@ -0,0 +1,93 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// imageRefPattern loosely matches a Docker image reference: an optional registry host and port, a repository
+// path, and an optional tag and digest
+var imageRefPattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*` +
+	`(:[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// ImageOverrides returns the build and run images set for a runtime with FN_<RUNTIME>_BUILD_IMAGE and
+// FN_<RUNTIME>_RUN_IMAGE, e.g. FN_JAVA8_BUILD_IMAGE, for pulling through a registry mirror or pinning digests.
+// Images not overridden are returned empty. It fails on overrides that are blank or not image references.
+func ImageOverrides(runtime string) (buildImage, runImage string, err error) {
+	prefix := "FN_" + nonAlphanumeric.ReplaceAllString(strings.ToUpper(runtime), "_")
+	if buildImage, err = imageOverride(prefix + "_BUILD_IMAGE"); err != nil {
+		return "", "", err
+	}
+	if runImage, err = imageOverride(prefix + "_RUN_IMAGE"); err != nil {
+		return "", "", err
+	}
+	return buildImage, runImage, nil
+}
+
+// Images are the build and run images of a function, as every step building or checking it uses them.
+type Images struct {
+	// Build and Run are the images func.yaml sets, else those of the FN_<RUNTIME>_BUILD_IMAGE and
+	// FN_<RUNTIME>_RUN_IMAGE overrides, else the helper's.
+	Build, Run string
+
+	lh LangHelper
+	// buildSet and runSet report images func.yaml or an override set, which the helper's images for other
+	// architectures don't replace
+	buildSet, runSet bool
+}
+
+// ResolveImages returns the images of a function of the runtime built by lh, whose func.yaml sets buildImage and
+// runImage, empty if it doesn't. The helper's own steps, such as pre-build containers, run the images resolved.
+func ResolveImages(lh LangHelper, runtime, buildImage, runImage string) (*Images, error) {
+	overrideBuild, overrideRun, err := ImageOverrides(runtime)
+	if err != nil {
+		return nil, err
+	}
+	images := &Images{Build: buildImage, Run: runImage, lh: lh}
+	if images.Build == "" {
+		images.Build = overrideBuild
+	}
+	if images.Run == "" {
+		images.Run = overrideRun
+	}
+	images.buildSet, images.runSet = images.Build != "", images.Run != ""
+	if images.Build == "" {
+		images.Build = lh.BuildFromImage()
+	}
+	if images.Run == "" {
+		images.Run = lh.RunFromImage()
+	}
+	lh.setImages(images)
+	return images, nil
+}
+
+// ArchImages returns the build and run images for an architecture, such as arm64: the helper's images for it,
+// unless func.yaml or an override set the image. Empty when the image is the same for every architecture.
+func (images *Images) ArchImages(arch string) (string, string) {
+	build, run := images.lh.ArchImages(arch)
+	if images.buildSet {
+		build = ""
+	}
+	if images.runSet {
+		run = ""
+	}
+	return build, run
+}
+
+func imageOverride(env string) (string, error) {
+	image, ok := os.LookupEnv(env)
+	if !ok {
+		return "", nil
+	}
+	if strings.TrimSpace(image) == "" {
+		return "", fmt.Errorf("%s is set but empty, unset it to use the default image", env)
+	}
+	if !imageRefPattern.MatchString(image) {
+		return "", fmt.Errorf("%s is not a valid image reference: %q", env, image)
+	}
+	return image, nil
+}
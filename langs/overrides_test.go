@@ -0,0 +1,64 @@
+package langs
+
+import (
+	"os"
+	"testing"
+)
+
+func TestImageOverrides(t *testing.T) {
+	defer os.Unsetenv("FN_JAVA8_BUILD_IMAGE")
+	defer os.Unsetenv("FN_LAMBDA_NODEJS4_3_RUN_IMAGE")
+
+	if build, run, err := ImageOverrides("java8"); err != nil || build != "" || run != "" {
+		t.Errorf("expected no overrides by default, got %q, %q, %v", build, run, err)
+	}
+
+	os.Setenv("FN_JAVA8_BUILD_IMAGE", "mirror.example.com:5000/fnproject/fn-java-fdk-build:1.0.56")
+	if build, _, err := ImageOverrides("java8"); err != nil || build != "mirror.example.com:5000/fnproject/fn-java-fdk-build:1.0.56" {
+		t.Errorf("expected the build image override, got %q, %v", build, err)
+	}
+	os.Setenv("FN_LAMBDA_NODEJS4_3_RUN_IMAGE", "node@sha256:"+
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if _, run, err := ImageOverrides("lambda-nodejs4.3"); err != nil || run == "" {
+		t.Errorf("expected the digest pinned run image override, got %q, %v", run, err)
+	}
+
+	for _, invalid := range []string{" ", "Not An Image", "fnproject/fn-java-fdk:"} {
+		os.Setenv("FN_JAVA8_BUILD_IMAGE", invalid)
+		if _, _, err := ImageOverrides("java8"); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+		if _, err := ResolveImages(&JavaLangHelper{version: "1.8"}, "java8", "", ""); err == nil {
+			t.Errorf("expected %q to be rejected by ResolveImages too", invalid)
+		}
+	}
+}
+
+func TestResolveImages(t *testing.T) {
+	defer os.Unsetenv("FN_PHP_BUILD_IMAGE")
+
+	lh := &PhpLangHelper{}
+	images, err := ResolveImages(lh, "php", "", "")
+	if err != nil || images.Build != "funcy/php:dev" || images.Run != lh.RunFromImage() {
+		t.Errorf("expected the images of the helper, got %+v, %v", images, err)
+	}
+
+	os.Setenv("FN_PHP_BUILD_IMAGE", "mirror.example.com/funcy/php:dev")
+	if images, err = ResolveImages(lh, "php", "", ""); err != nil || images.Build != "mirror.example.com/funcy/php:dev" {
+		t.Errorf("expected the overridden build image, got %+v, %v", images, err)
+	}
+	if image := lh.resolvedBuildImage(lh.BuildFromImage()); image != "mirror.example.com/funcy/php:dev" {
+		t.Errorf("expected the pre-build container to run the overridden build image, got %s", image)
+	}
+
+	// func.yaml takes precedence over the overrides, and keeps its image for every architecture
+	if images, err = ResolveImages(lh, "php", "example.com/php:7", "example.com/php:7-slim"); err != nil {
+		t.Fatal(err)
+	}
+	if images.Build != "example.com/php:7" || images.Run != "example.com/php:7-slim" {
+		t.Errorf("expected the images of func.yaml, got %+v", images)
+	}
+	if build, run := images.ArchImages("arm64"); build != "" || run != "" {
+		t.Errorf("expected no images for other architectures, got %s, %s", build, run)
+	}
+}
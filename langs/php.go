@@ -1,9 +1,8 @@
 package langs
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -15,16 +14,34 @@ type PhpLangHelper struct {
 func (lh *PhpLangHelper) BuildFromImage() string {
 	return "funcy/php:dev"
 }
+
+// LooksLikeProject returns whether dir has a func.php handler or composer.json.
+func (lh *PhpLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "func.php", "composer.json")
+}
+
+// DependencyManifests returns composer.json and composer.lock, which list the function's dependencies.
+func (lh *PhpLangHelper) DependencyManifests() []string {
+	return []string{"composer.json", "composer.lock"}
+}
+
 func (lh *PhpLangHelper) Entrypoint() string {
 	return "php func.php"
 }
 
+func (lh *PhpLangHelper) ColdStartClass() string { return ColdStartFast }
+
 func (lh *PhpLangHelper) HasPreBuild() bool {
 	return true
 }
 
 func (lh *PhpLangHelper) PreBuild() error {
-	wd, err := os.Getwd()
+	return lh.PreBuildContext(context.Background())
+}
+
+// PreBuildContext is PreBuild, killing composer once ctx is done.
+func (lh *PhpLangHelper) PreBuildContext(ctx context.Context) error {
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
@@ -33,18 +50,18 @@ func (lh *PhpLangHelper) PreBuild() error {
 		return nil
 	}
 
-	pbcmd := fmt.Sprintf("docker run --rm -v %s:/worker -w /worker funcy/php:dev composer install", wd)
-	fmt.Println("Running prebuild command:", pbcmd)
-	parts := strings.Fields(pbcmd)
-	head := parts[0]
-	parts = parts[1:len(parts)]
-	cmd := exec.Command(head, parts...)
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
-	if err := cmd.Run(); err != nil {
-		return dockerBuildError(err)
+	engine, err := Engine()
+	if err != nil {
+		return err
 	}
-	return nil
+	mirror, err := LoadImageMirror()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"run", "--rm", "-v", wd + ":/worker", "-w", "/worker", mirror.Image(lh.resolvedBuildImage(lh.BuildFromImage())), "composer", "install"}
+	fmt.Println("Running prebuild command:", engine.Name(), strings.Join(args, " "))
+	return runPreBuildCmd(ctx, engine.Name(), args...)
 }
 
 func (lh *PhpLangHelper) AfterBuild() error {
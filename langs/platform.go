@@ -0,0 +1,67 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// platformEnv lists the platforms to build function images for with docker buildx, comma separated, such as
+// linux/amd64,linux/arm64. fn build and fn deploy set it from --platform.
+const platformEnv = "FN_PLATFORM"
+
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9]+(/[a-z0-9]+)?$`)
+
+// Platforms returns the platforms set in FN_PLATFORM, none to build for the platform of the Docker host.
+func Platforms() ([]string, error) {
+	value := strings.TrimSpace(os.Getenv(platformEnv))
+	if value == "" {
+		return nil, nil
+	}
+	var platforms []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if !platformPattern.MatchString(p) {
+			return nil, fmt.Errorf("%q set in %s is not a platform, such as linux/arm64", p, platformEnv)
+		}
+		if !containsString(platforms, p) {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms, nil
+}
+
+// PlatformArch returns the architecture of a platform, arm64 for linux/arm64/v8, as buildx sets it in TARGETARCH.
+func PlatformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return platform
+	}
+	return parts[1]
+}
+
+// ArchImage returns the image the stage name builds FROM for the platforms, image itself unless archImage gives
+// another one for any of their architectures. The Dockerfile then starts with a stage per architecture, given in
+// the returned lines, and the stage builds FROM the one buildx selects with TARGETARCH.
+func ArchImage(platforms []string, name, image string, archImage func(arch string) string) ([]string, string) {
+	var lines, archs []string
+	differs := false
+	for _, p := range platforms {
+		arch := PlatformArch(p)
+		if containsString(archs, arch) {
+			continue
+		}
+		archs = append(archs, arch)
+		img := archImage(arch)
+		if img == "" {
+			img = image
+		}
+		differs = differs || img != image
+		lines = append(lines, fmt.Sprintf("FROM %s as %s-%s", img, name, arch))
+	}
+	if !differs {
+		return nil, image
+	}
+	return lines, name + "-${TARGETARCH}"
+}
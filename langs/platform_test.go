@@ -0,0 +1,46 @@
+package langs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPlatforms(t *testing.T) {
+	os.Setenv("FN_PLATFORM", "linux/amd64, linux/arm64,linux/amd64")
+	defer os.Unsetenv("FN_PLATFORM")
+	platforms, err := Platforms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"linux/amd64", "linux/arm64"}; !reflect.DeepEqual(platforms, expected) {
+		t.Errorf("expected %q, got %q", expected, platforms)
+	}
+
+	os.Setenv("FN_PLATFORM", "arm64")
+	if _, err := Platforms(); err == nil {
+		t.Error("expected an architecture without an OS to be rejected")
+	}
+}
+
+func TestArchImage(t *testing.T) {
+	lh := &GoLangHelper{}
+	buildImage := func(arch string) string {
+		image, _ := lh.ArchImages(arch)
+		return image
+	}
+
+	lines, image := ArchImage([]string{"linux/amd64"}, "build-image", lh.BuildFromImage(), buildImage)
+	if lines != nil || image != "funcy/go:dev" {
+		t.Errorf("expected the default image for amd64, got %q, %q", lines, image)
+	}
+
+	lines, image = ArchImage([]string{"linux/amd64", "linux/arm64/v8"}, "build-image", lh.BuildFromImage(), buildImage)
+	expected := []string{
+		"FROM funcy/go:dev as build-image-amd64",
+		"FROM golang:1.9-alpine as build-image-arm64",
+	}
+	if !reflect.DeepEqual(lines, expected) || image != "build-image-${TARGETARCH}" {
+		t.Errorf("expected a stage per architecture, got %q, %q", lines, image)
+	}
+}
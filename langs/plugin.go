@@ -0,0 +1,122 @@
+package langs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// pluginPrefix starts the names of the executables providing external runtimes, fn-lang-<runtime> on the PATH.
+//
+// A plugin is run with a single argument naming what to do, in the function directory:
+//
+//	describe  prints a pluginDescription as JSON
+//	init      generates the function boilerplate, if the description sets boilerplate, and marks the
+//	          executable_files of the description executable
+//	prebuild  runs before the image is built, if the description sets prebuild
+const pluginPrefix = "fn-lang-"
+
+// pluginDescription is what a plugin prints for describe
+type pluginDescription struct {
+	BuildImage      string   `json:"build_image"`
+	RunImage        string   `json:"run_image"`
+	SingleStage     bool     `json:"single_stage"`
+	BuildCmds       []string `json:"build_cmds"`
+	CopyCmds        []string `json:"copy_cmds"`
+	Entrypoint      string   `json:"entrypoint"`
+	Cmd             string   `json:"cmd"`
+	Boilerplate     bool     `json:"boilerplate"`
+	PreBuild        bool     `json:"prebuild"`
+	ExecutableFiles []string `json:"executable_files"`
+}
+
+// PluginLangHelper provides the helper methods of a runtime implemented by a fn-lang-<runtime> plugin executable,
+// so that runtimes can be shipped without changing the CLI.
+type PluginLangHelper struct {
+	BaseHelper
+	path string
+	desc pluginDescription
+}
+
+// pluginLangHelper returns the helper of the runtime's plugin, nil if there is no plugin for it.
+func pluginLangHelper(runtime string) (LangHelper, error) {
+	if strings.ContainsAny(runtime, `/\`) {
+		return nil, nil
+	}
+	path, err := exec.LookPath(pluginPrefix + runtime)
+	if err != nil {
+		return nil, nil
+	}
+	out, err := exec.Command(path, "describe").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Could not describe the %s runtime plugin %s: %v", runtime, path, err)
+	}
+	lh := &PluginLangHelper{path: path}
+	if err := json.Unmarshal(out, &lh.desc); err != nil {
+		return nil, fmt.Errorf("Could not parse the description of the %s runtime plugin %s: %v", runtime, path, err)
+	}
+	return lh, nil
+}
+
+// pluginRuntimes returns the runtimes of the plugin executables on the PATH.
+func pluginRuntimes() []string {
+	var runtimes []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			if strings.HasPrefix(name, pluginPrefix) && len(name) > len(pluginPrefix) && !f.IsDir() && f.Mode()&0111 != 0 {
+				runtimes = append(runtimes, strings.TrimPrefix(name, pluginPrefix))
+			}
+		}
+	}
+	return runtimes
+}
+
+func (lh *PluginLangHelper) BuildFromImage() string { return lh.desc.BuildImage }
+
+func (lh *PluginLangHelper) RunFromImage() string {
+	if lh.desc.RunImage == "" {
+		return lh.desc.BuildImage
+	}
+	return lh.desc.RunImage
+}
+
+func (lh *PluginLangHelper) IsMultiStage() bool            { return !lh.desc.SingleStage }
+func (lh *PluginLangHelper) DockerfileBuildCmds() []string { return lh.desc.BuildCmds }
+func (lh *PluginLangHelper) DockerfileCopyCmds() []string  { return lh.desc.CopyCmds }
+func (lh *PluginLangHelper) Entrypoint() string            { return lh.desc.Entrypoint }
+func (lh *PluginLangHelper) Cmd() string                   { return lh.desc.Cmd }
+func (lh *PluginLangHelper) HasBoilerplate() bool          { return lh.desc.Boilerplate }
+func (lh *PluginLangHelper) HasPreBuild() bool             { return lh.desc.PreBuild }
+
+// ExecutableBoilerplateFiles returns the executable_files of the plugin description.
+func (lh *PluginLangHelper) ExecutableBoilerplateFiles() []string { return lh.desc.ExecutableFiles }
+
+// GenerateBoilerplate runs the plugin's init.
+func (lh *PluginLangHelper) GenerateBoilerplate() error { return lh.run("init") }
+
+// PreBuild runs the plugin's prebuild.
+func (lh *PluginLangHelper) PreBuild() error { return lh.run("prebuild") }
+
+func (lh *PluginLangHelper) run(action string) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(lh.path, action)
+	cmd.Dir = wd
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("The runtime plugin %s failed to %s: %v", lh.path, action, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const fooPlugin = `#!/bin/sh
+case "$1" in
+describe)
+  echo '{"build_image": "example/foo-build", "run_image": "example/foo", "build_cmds": ["ADD . /function/", "RUN make"],
+    "copy_cmds": ["COPY --from=build-stage /function/func /function/"], "entrypoint": "./func", "boilerplate": true,
+    "executable_files": ["func.foo"]}' ;;
+init)
+  echo 'hello' > func.foo ;;
+esac
+`
+
+func TestPluginLangHelper(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	bin := filepath.Join(tmp, "bin")
+	if err := os.Mkdir(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(bin, "fn-lang-foo"), []byte(fooPlugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	if !containsString(SupportedRuntimes(), "foo") {
+		t.Errorf("expected the plugin runtime to be supported, got %v", SupportedRuntimes())
+	}
+	if containsString(KnownRuntimes(), "foo") {
+		t.Errorf("expected the plugins to be left out of the known runtimes, got %v", KnownRuntimes())
+	}
+	lh, err := GetLangHelper("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lh.BuildFromImage() != "example/foo-build" || lh.RunFromImage() != "example/foo" || lh.Entrypoint() != "./func" {
+		t.Errorf("expected the helper to follow the plugin description, got %+v", lh)
+	}
+	if !lh.HasBoilerplate() || lh.HasPreBuild() {
+		t.Errorf("expected the plugin to generate boilerplate only, got %+v", lh)
+	}
+	if err := lh.GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if src := readFile(t, filepath.Join(tmp, "func.foo")); src != "hello\n" {
+		t.Errorf("expected the plugin to generate the boilerplate, got %q", src)
+	}
+	if err := MarkBoilerplateExecutable(lh); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(tmp, "func.foo")); err != nil || info.Mode().Perm()&0111 == 0 {
+		t.Errorf("expected the executable_files of the plugin to be executable, got %v %v", info, err)
+	}
+}
@@ -0,0 +1,42 @@
+package langs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// prebuildTimeout reads the FN_PREBUILD_TIMEOUT duration, such as 10m, that external pre-build commands are
+// allowed to run for. It returns zero, no timeout, when unset or invalid.
+func prebuildTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("FN_PREBUILD_TIMEOUT"))
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// runPreBuildCmd runs an external pre-build command with its output going to the terminal, killing it and
+// returning ErrPreBuildTimeout once FN_PREBUILD_TIMEOUT elapses, or ctx's error once it is done.
+func runPreBuildCmd(ctx context.Context, name string, args ...string) error {
+	if timeout := prebuildTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrPreBuildTimeout
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return dockerBuildError(err)
+	}
+	return nil
+}
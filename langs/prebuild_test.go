@@ -0,0 +1,21 @@
+package langs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPreBuildCmdTimeout(t *testing.T) {
+	os.Setenv("FN_PREBUILD_TIMEOUT", "100ms")
+	defer os.Unsetenv("FN_PREBUILD_TIMEOUT")
+
+	start := time.Now()
+	if err := runPreBuildCmd(context.Background(), "sleep", "5"); err != ErrPreBuildTimeout {
+		t.Errorf("expected the slow pre-build step to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the pre-build step to be cancelled at the timeout, it ran for %v", elapsed)
+	}
+}
@@ -1,5 +1,7 @@
 package langs
 
+import "fmt"
+
 type PythonLangHelper struct {
 	BaseHelper
 }
@@ -8,26 +10,68 @@ func (lh *PythonLangHelper) BuildFromImage() string {
 	return "funcy/python:2-dev"
 }
 
+// LooksLikeProject returns whether dir has a func.py handler or requirements.txt.
+func (lh *PythonLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "func.py", "requirements.txt")
+}
+
 func (lh *PythonLangHelper) RunFromImage() string {
 	return "funcy/python:2-dev"
 }
 
+// SupportsDockerlessBuild returns whether the function has no requirements to install.
+func (lh *PythonLangHelper) SupportsDockerlessBuild(dir string) bool {
+	return !anyExists(dir, "requirements.txt")
+}
+
+// HotReloads returns whether the changes leave requirements.txt alone, the sources are copied into the image as they are.
+func (lh *PythonLangHelper) HotReloads(changed []string) bool {
+	return !changesAny(changed, "requirements.txt")
+}
+
+// Validate checks that the function has a func.py handler.
+func (lh *PythonLangHelper) Validate(dir string) []LintIssue {
+	return requireFiles(dir, "python", "func.py")
+}
+
+// DependencyManifests returns requirements.txt, which list the function's dependencies.
+func (lh *PythonLangHelper) DependencyManifests() []string {
+	return []string{"requirements.txt"}
+}
+
+// TestCmds runs the tests with pytest, installing it unless requirements.txt did.
+func (lh *PythonLangHelper) TestCmds() []string {
+	return []string{
+		"pip install -q pytest",
+		"python -m pytest --junitxml=" + TestReportsDir + "/pytest.xml",
+	}
+}
+
 func (lh *PythonLangHelper) Entrypoint() string {
 	return "python2 func.py"
 }
 
+func (lh *PythonLangHelper) ColdStartClass() string { return ColdStartFast }
+
 func (h *PythonLangHelper) DockerfileBuildCmds() []string {
 	r := []string{}
 	if exists("requirements.txt") {
+		install := "RUN pip install -r requirements.txt"
+		if mirror := packageIndexURL(); mirror != "" {
+			install = fmt.Sprintf("RUN pip install --index-url %s -r requirements.txt", mirror)
+		}
 		r = append(r,
 			"ADD requirements.txt /function/",
-			"RUN pip install -r requirements.txt",
+			install,
 		)
 	}
 	r = append(r, "ADD . /function/")
 	return r
 }
 
+// BuildCacheDirs returns the pip cache.
+func (h *PythonLangHelper) BuildCacheDirs() []string { return []string{"/root/.cache/pip"} }
+
 func (h *PythonLangHelper) IsMultiStage() bool {
 	return false
 }
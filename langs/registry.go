@@ -0,0 +1,84 @@
+package langs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() LangHelper{}
+)
+
+// Register makes an external runtime available to GetLangHelper under name, with factory creating its helper on
+// each lookup. It fails if name is already taken by a built-in or previously registered helper, use
+// OverrideLangHelper to replace one on purpose.
+func Register(name string, factory func() LangHelper) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("a language helper needs a name and an implementation")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok || builtinLangHelper(name) != nil {
+		return fmt.Errorf("a language helper is already registered for %v", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// RegisterLangHelper is Register for a helper instance shared by all lookups.
+func RegisterLangHelper(name string, lh LangHelper) error {
+	if lh == nil {
+		return fmt.Errorf("a language helper needs a name and an implementation")
+	}
+	return Register(name, func() LangHelper { return lh })
+}
+
+// OverrideLangHelper registers lh under name, replacing any built-in or registered helper of the same name.
+func OverrideLangHelper(name string, lh LangHelper) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = func() LangHelper { return lh }
+}
+
+func registeredLangHelper(name string) LangHelper {
+	registryMu.RLock()
+	factory := registry[name]
+	registryMu.RUnlock()
+	if factory == nil {
+		return nil
+	}
+	return factory()
+}
+
+// UnknownRuntimeError is returned by GetLangHelper for runtimes, or runtime versions, without a helper
+type UnknownRuntimeError struct {
+	Runtime   string
+	Supported []string
+}
+
+func (e *UnknownRuntimeError) Error() string {
+	return fmt.Sprintf("no language helper found for %v, supported runtimes are: %s", e.Runtime, strings.Join(e.Supported, ", "))
+}
+
+// runtimeCandidates returns the helper names a runtime spec such as java, java1.8 or java:1.8 could refer to, the
+// spec itself first. Java style 1.x versions are also tried as x.
+func runtimeCandidates(spec string) []string {
+	name, version := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		name, version = spec[:i], spec[i+1:]
+	} else if i := strings.IndexAny(spec, "0123456789"); i > 0 {
+		name, version = spec[:i], spec[i:]
+	}
+
+	candidates := []string{spec}
+	if version != "" {
+		candidates = append(candidates, name+version)
+		if trimmed := strings.TrimPrefix(version, "1."); trimmed != version {
+			candidates = append(candidates, name+trimmed)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,87 @@
+package langs
+
+import "testing"
+
+type fakeLangHelper struct {
+	BaseHelper
+}
+
+func (lh *fakeLangHelper) Entrypoint() string { return "./fake" }
+
+func TestRegisterLangHelper(t *testing.T) {
+	fake := &fakeLangHelper{}
+	if err := RegisterLangHelper("fake", fake); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(registry, "fake")
+
+	if lh, _ := GetLangHelper("fake"); lh != fake {
+		t.Errorf("expected the registered helper, got %v", lh)
+	}
+	if err := RegisterLangHelper("fake", &fakeLangHelper{}); err == nil {
+		t.Error("expected a duplicate registration to fail")
+	}
+	if err := RegisterLangHelper("go", &fakeLangHelper{}); err == nil {
+		t.Error("expected registering over a built-in helper to fail")
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	created := 0
+	factory := func() LangHelper {
+		created++
+		return &fakeLangHelper{}
+	}
+	if err := Register("factory", factory); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(registry, "factory")
+
+	for i := 0; i < 2; i++ {
+		if lh, _ := GetLangHelper("factory"); lh == nil {
+			t.Fatal("expected the registered helper")
+		}
+	}
+	if created != 2 {
+		t.Errorf("expected a new helper per lookup, got %d", created)
+	}
+	if err := Register("factory", func() LangHelper { return &fakeLangHelper{} }); err == nil {
+		t.Error("expected a duplicate registration to fail")
+	}
+}
+
+func TestOverrideLangHelper(t *testing.T) {
+	fake := &fakeLangHelper{}
+	OverrideLangHelper("go", fake)
+	defer delete(registry, "go")
+
+	if lh, _ := GetLangHelper("go"); lh != fake {
+		t.Errorf("expected the overriding helper, got %v", lh)
+	}
+}
+
+func TestGetLangHelperVersions(t *testing.T) {
+	for spec, version := range map[string]string{
+		"java":     "9",
+		"java8":    "1.8",
+		"java1.8":  "1.8",
+		"java:1.8": "1.8",
+		"java:9":   "9",
+	} {
+		lh, err := GetLangHelper(spec)
+		if err != nil {
+			t.Errorf("%s: %v", spec, err)
+			continue
+		}
+		if java, ok := lh.(*JavaLangHelper); !ok || java.version != version {
+			t.Errorf("%s: expected Java %s, got %#v", spec, version, lh)
+		}
+	}
+
+	for _, spec := range []string{"cobol", "java:7", "node:8"} {
+		_, err := GetLangHelper(spec)
+		if unknown, ok := err.(*UnknownRuntimeError); !ok || len(unknown.Supported) == 0 {
+			t.Errorf("%s: expected an UnknownRuntimeError listing the supported runtimes, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package langs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RegistryMirrorEnv remaps the images builds pull from public registries to a mirror. It is the mirror of Docker
+// Hub, eg: mirror.example.com/dockerhub, or comma separated registry=mirror pairs, eg:
+// docker.io=mirror.example.com/dockerhub,gcr.io=mirror.example.com/gcr.
+const RegistryMirrorEnv = "FN_REGISTRY_MIRROR"
+
+// ImageDigestsEnv pins images to digests, as comma separated image=digest pairs, eg:
+// fnproject/node:latest=sha256:<digest>. Images are pinned before they are remapped to a mirror.
+const ImageDigestsEnv = "FN_IMAGE_DIGESTS"
+
+// dockerHub is the registry of the images whose reference names none
+const dockerHub = "docker.io"
+
+// mirrorPattern matches a registry host, and port, with an optional repository path the images are kept under
+var mirrorPattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ImageMirror is where builds pull the base images of the runtimes from, in place of the public registries the
+// helpers name.
+type ImageMirror struct {
+	// Registries maps registries, such as docker.io, to their mirror.
+	Registries map[string]string
+	// Digests maps image references, in full, such as docker.io/fnproject/node:latest, to the digest they are pinned to.
+	Digests map[string]string
+}
+
+// LoadImageMirror returns the registry mirrors and image digests of FN_REGISTRY_MIRROR and FN_IMAGE_DIGESTS.
+func LoadImageMirror() (*ImageMirror, error) {
+	registries, err := ParseRegistryMirror(os.Getenv(RegistryMirrorEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", RegistryMirrorEnv, err)
+	}
+	digests, err := ParseImageDigests(os.Getenv(ImageDigestsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ImageDigestsEnv, err)
+	}
+	return &ImageMirror{Registries: registries, Digests: digests}, nil
+}
+
+// ParseRegistryMirror returns the mirror of each registry of a registry mirror setting, see RegistryMirrorEnv.
+func ParseRegistryMirror(s string) (map[string]string, error) {
+	mirrors := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		registry, mirror := dockerHub, pair
+		if i := strings.Index(pair, "="); i >= 0 {
+			registry, mirror = canonicalRegistry(strings.TrimSpace(pair[:i])), strings.TrimSpace(pair[i+1:])
+		}
+		mirror = strings.TrimSuffix(mirror, "/")
+		if registry == "" || !mirrorPattern.MatchString(mirror) {
+			return nil, fmt.Errorf("invalid registry mirror %q, eg: mirror.example.com/dockerhub or gcr.io=mirror.example.com/gcr", pair)
+		}
+		mirrors[registry] = mirror
+	}
+	return mirrors, nil
+}
+
+// ParseImageDigests returns the digest each image of an image digests setting is pinned to, keyed by the image
+// reference in full. See ImageDigestsEnv.
+func ParseImageDigests(s string) (map[string]string, error) {
+	digests := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid image digest %q, eg: fnproject/node:latest=sha256:<digest>", pair)
+		}
+		image, digest := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if err := CheckImageDigest(image, digest); err != nil {
+			return nil, err
+		}
+		digests[CanonicalImage(image)] = digest
+	}
+	return digests, nil
+}
+
+// CheckImageDigest returns an error if image is not an image reference without a digest, or digest not a sha256
+// digest.
+func CheckImageDigest(image, digest string) error {
+	if !imageRefPattern.MatchString(image) || strings.Contains(image, "@") {
+		return fmt.Errorf("invalid image %q to pin, name it without a digest, eg: fnproject/node:latest", image)
+	}
+	if !digestPattern.MatchString(digest) {
+		return fmt.Errorf("invalid digest %q for %s, eg: sha256:<64 hex digits>", digest, image)
+	}
+	return nil
+}
+
+// FormatImagePairs writes registry mirrors or image digests back as a setting, sorted.
+func FormatImagePairs(pairs map[string]string) string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	formatted := make([]string, len(keys))
+	for i, k := range keys {
+		formatted[i] = k + "=" + pairs[k]
+	}
+	return strings.Join(formatted, ",")
+}
+
+// Image returns the reference to pull image with: pinned to its digest, if it has one, and from the mirror of its
+// registry, if there is one. References with build arguments, and scratch, are left alone.
+func (m *ImageMirror) Image(image string) string {
+	if image == "" || image == "scratch" || strings.Contains(image, "$") {
+		return image
+	}
+	if digest, ok := m.Digests[CanonicalImage(image)]; ok && !strings.Contains(image, "@") {
+		image += "@" + digest
+	}
+	registry, path := splitRegistry(image)
+	if mirror, ok := m.Registries[registry]; ok {
+		return mirror + "/" + path
+	}
+	return image
+}
+
+// Dockerfile returns the lines of a Dockerfile pulling their images through the mirror: the images of the FROM
+// lines and COPY --from flags that aren't stages of the Dockerfile, and the frontend of the syntax directive.
+func (m *ImageMirror) Dockerfile(lines []string) []string {
+	stages := map[string]bool{}
+	mirrored := make([]string, len(lines))
+	for i, line := range lines {
+		mirrored[i] = line
+		if strings.HasPrefix(line, "# syntax=") {
+			mirrored[i] = "# syntax=" + m.Image(strings.TrimSpace(strings.TrimPrefix(line, "# syntax=")))
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		changed := false
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			j := 1
+			for j < len(fields) && strings.HasPrefix(fields[j], "--") {
+				j++
+			}
+			if j == len(fields) {
+				continue
+			}
+			if !stages[strings.ToLower(fields[j])] {
+				image := m.Image(fields[j])
+				changed = image != fields[j]
+				fields[j] = image
+			}
+			if j+2 < len(fields) && strings.EqualFold(fields[j+1], "as") {
+				stages[strings.ToLower(fields[j+2])] = true
+			}
+		case "COPY":
+			for j, f := range fields {
+				from := strings.TrimPrefix(f, "--from=")
+				if from == f || stages[strings.ToLower(from)] || strings.Trim(from, "0123456789") == "" {
+					continue
+				}
+				if image := m.Image(from); image != from {
+					fields[j] = "--from=" + image
+					changed = true
+				}
+			}
+		}
+		if changed {
+			mirrored[i] = strings.Join(fields, " ")
+		}
+	}
+	return mirrored
+}
+
+// CanonicalImage returns image with its registry, and the library path of official Docker Hub images, spelled out,
+// so the references of an image all compare equal.
+func CanonicalImage(image string) string {
+	registry, path := splitRegistry(image)
+	return registry + "/" + path
+}
+
+// splitRegistry returns the registry of image and the path of the image in it
+func splitRegistry(image string) (string, string) {
+	registry, path := dockerHub, image
+	// a first component that isn't a hostname is the owner of a Docker Hub image
+	if i := strings.Index(image, "/"); i >= 0 {
+		if first := image[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			registry, path = canonicalRegistry(first), image[i+1:]
+		}
+	}
+	if registry == dockerHub && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return registry, path
+}
+
+// canonicalRegistry returns the aliases of Docker Hub as docker.io
+func canonicalRegistry(registry string) string {
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHub
+	}
+	return registry
+}
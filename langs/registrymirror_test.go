@@ -0,0 +1,112 @@
+package langs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseRegistryMirror(t *testing.T) {
+	mirrors, err := ParseRegistryMirror("mirror.example.com/dockerhub/, gcr.io=mirror.example.com:5000/gcr,index.docker.io=hub.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"docker.io": "hub.example.com", "gcr.io": "mirror.example.com:5000/gcr"}
+	if !reflect.DeepEqual(mirrors, expected) {
+		t.Errorf("expected %v, got %v", expected, mirrors)
+	}
+	if s := FormatImagePairs(mirrors); s != "docker.io=hub.example.com,gcr.io=mirror.example.com:5000/gcr" {
+		t.Errorf("expected the mirrors sorted, got %s", s)
+	}
+
+	for _, invalid := range []string{"Mirror", "=mirror.example.com", "mirror.example.com/hub:latest"} {
+		if _, err := ParseRegistryMirror(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestParseImageDigests(t *testing.T) {
+	digests, err := ParseImageDigests("fnproject/node:latest=" + testDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digests["docker.io/fnproject/node:latest"] != testDigest {
+		t.Errorf("expected the digest keyed by the image in full, got %v", digests)
+	}
+	for _, invalid := range []string{"fnproject/node", "fnproject/node=sha256:abc", "node@" + testDigest + "=" + testDigest} {
+		if _, err := ParseImageDigests(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestImageMirrorImage(t *testing.T) {
+	m := &ImageMirror{
+		Registries: map[string]string{"docker.io": "mirror.example.com/hub", "gcr.io": "mirror.example.com/gcr"},
+		Digests:    map[string]string{"docker.io/library/golang:1.10": testDigest},
+	}
+	for image, expected := range map[string]string{
+		"fnproject/node:latest":                 "mirror.example.com/hub/fnproject/node:latest",
+		"golang:1.10":                           "mirror.example.com/hub/library/golang:1.10@" + testDigest,
+		"docker.io/golang:1.10":                 "mirror.example.com/hub/library/golang:1.10@" + testDigest,
+		"gcr.io/kaniko-project/executor:latest": "mirror.example.com/gcr/kaniko-project/executor:latest",
+		"registry.example.com/team/base:1":      "registry.example.com/team/base:1",
+		"localhost:5000/base":                   "localhost:5000/base",
+		"scratch":                               "scratch",
+		"build-image-${TARGETARCH}":             "build-image-${TARGETARCH}",
+	} {
+		if mirrored := m.Image(image); mirrored != expected {
+			t.Errorf("expected %s to be pulled as %s, got %s", image, expected, mirrored)
+		}
+	}
+}
+
+func TestImageMirrorDockerfile(t *testing.T) {
+	m := &ImageMirror{Registries: map[string]string{"docker.io": "mirror.example.com/hub"}}
+	lines := m.Dockerfile([]string{
+		"# syntax=docker/dockerfile:1.4",
+		"FROM --platform=$BUILDPLATFORM fnproject/go:dev as build-stage",
+		"RUN go build -o func",
+		"FROM build-stage as test",
+		"FROM fnproject/go",
+		"COPY --from=build-stage /function/func /function/",
+		"COPY --from=0 /function/func /function/",
+		"COPY --from=busybox:1.36 /bin/sh /bin/sh",
+	})
+	expected := []string{
+		"# syntax=mirror.example.com/hub/docker/dockerfile:1.4",
+		"FROM --platform=$BUILDPLATFORM mirror.example.com/hub/fnproject/go:dev as build-stage",
+		"RUN go build -o func",
+		"FROM build-stage as test",
+		"FROM mirror.example.com/hub/fnproject/go",
+		"COPY --from=build-stage /function/func /function/",
+		"COPY --from=0 /function/func /function/",
+		"COPY --from=mirror.example.com/hub/library/busybox:1.36 /bin/sh /bin/sh",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, lines)
+	}
+}
+
+func TestLoadImageMirror(t *testing.T) {
+	defer os.Unsetenv(RegistryMirrorEnv)
+	defer os.Unsetenv(ImageDigestsEnv)
+
+	os.Setenv(RegistryMirrorEnv, "mirror.example.com/hub")
+	os.Setenv(ImageDigestsEnv, "fnproject/go:dev="+testDigest)
+	m, err := LoadImageMirror()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := m.Image("fnproject/go:dev"); image != "mirror.example.com/hub/fnproject/go:dev@"+testDigest {
+		t.Errorf("expected the pinned image from the mirror, got %s", image)
+	}
+
+	os.Setenv(ImageDigestsEnv, "fnproject/go:dev")
+	if _, err := LoadImageMirror(); err == nil {
+		t.Error("expected an image digest without a digest to be rejected")
+	}
+}
@@ -0,0 +1,328 @@
+package langs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The kinds of remote builders.
+const (
+	RemoteBuilderBuildKit = "buildkit"
+	RemoteBuilderKaniko   = "kaniko"
+)
+
+// defaultKanikoImage is the Kaniko executor Kaniko builds run, unless their builder names another
+const defaultKanikoImage = "gcr.io/kaniko-project/executor:latest"
+
+// RemoteBuilderConfig is where images are built away from the machine fn runs on: a BuildKit daemon, or Kaniko pods
+// of a Kubernetes cluster.
+type RemoteBuilderConfig struct {
+	// Kind is buildkit or kaniko.
+	Kind string `json:"kind"`
+	// Addr is the address of the BuildKit daemon, eg: tcp://buildkitd.example.com:1234.
+	Addr string `json:"addr,omitempty"`
+	// Namespace is the Kubernetes namespace Kaniko builds run in, and KubeContext the kubectl context of the cluster.
+	Namespace   string `json:"namespace,omitempty"`
+	KubeContext string `json:"kube_context,omitempty"`
+	// Secret is the Kubernetes secret, of type docker-registry, Kaniko pushes the images with.
+	Secret string `json:"secret,omitempty"`
+	// Image is the Kaniko executor image.
+	Image string `json:"image,omitempty"`
+}
+
+// RemoteBuild is an image build handed over to a remote builder. The Dockerfile the helper generated, in Dir, is
+// the contract between fn and the builder, which pushes the image to its registry.
+type RemoteBuild struct {
+	Dir        string
+	Dockerfile string
+	Image      string
+	Platforms  []string
+	NoCache    bool
+	// BuildArgs and Labels are KEY=value pairs.
+	BuildArgs []string
+	Labels    []string
+}
+
+// RemoteBuilder builds images remotely.
+type RemoteBuilder interface {
+	// Name describes the builder in the build output.
+	Name() string
+	// Build ships the build context of b to the builder, streams the build log to out and returns the digest of the
+	// image the builder pushed.
+	Build(b *RemoteBuild, out io.Writer) (string, error)
+}
+
+// NewRemoteBuilder returns the builder of cfg, checking that its settings and client are there.
+func NewRemoteBuilder(cfg RemoteBuilderConfig) (RemoteBuilder, error) {
+	switch cfg.Kind {
+	case RemoteBuilderBuildKit:
+		if cfg.Addr == "" {
+			return nil, errors.New("buildkit builders need the address of the BuildKit daemon")
+		}
+		if _, err := exec.LookPath("buildctl"); err != nil {
+			return nil, errors.New("buildctl is needed to build with BuildKit, install it from https://github.com/moby/buildkit/releases")
+		}
+		return &buildKitBuilder{cfg}, nil
+	case RemoteBuilderKaniko:
+		if _, err := exec.LookPath("kubectl"); err != nil {
+			return nil, errors.New("kubectl is needed to build with Kaniko, install it from https://kubernetes.io/docs/tasks/tools/")
+		}
+		return &kanikoBuilder{cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown remote builder %q, use %s or %s", cfg.Kind, RemoteBuilderBuildKit, RemoteBuilderKaniko)
+}
+
+// buildKitBuilder builds with a BuildKit daemon, through buildctl, which ships the build context itself.
+type buildKitBuilder struct {
+	cfg RemoteBuilderConfig
+}
+
+func (r *buildKitBuilder) Name() string { return "BuildKit at " + r.cfg.Addr }
+
+func (r *buildKitBuilder) Build(b *RemoteBuild, out io.Writer) (string, error) {
+	metadata, err := ioutil.TempFile("", "fn-buildkit-metadata")
+	if err != nil {
+		return "", err
+	}
+	metadata.Close()
+	defer os.Remove(metadata.Name())
+
+	cmd := exec.Command("buildctl", r.buildArgs(b, metadata.Name())...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running buildctl build: %v", err)
+	}
+	md, err := ioutil.ReadFile(metadata.Name())
+	if err != nil {
+		return "", err
+	}
+	return buildKitDigest(md)
+}
+
+func (r *buildKitBuilder) buildArgs(b *RemoteBuild, metadataFile string) []string {
+	args := []string{"--addr", r.cfg.Addr, "build", "--progress", "plain",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=" + b.Dir,
+		"--local", "dockerfile=" + filepath.Dir(b.Dockerfile),
+		"--opt", "filename=" + filepath.Base(b.Dockerfile),
+	}
+	if len(b.Platforms) > 0 {
+		args = append(args, "--opt", "platform="+strings.Join(b.Platforms, ","))
+	}
+	for _, arg := range b.BuildArgs {
+		args = append(args, "--opt", "build-arg:"+arg)
+	}
+	for _, label := range b.Labels {
+		args = append(args, "--opt", "label:"+label)
+	}
+	if b.NoCache {
+		args = append(args, "--no-cache")
+	}
+	return append(args,
+		"--output", "type=image,name="+b.Image+",push=true",
+		"--metadata-file", metadataFile,
+	)
+}
+
+// buildKitDigest returns the digest of the image of the metadata file buildctl writes
+func buildKitDigest(metadata []byte) (string, error) {
+	var md struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	if err := json.Unmarshal(metadata, &md); err != nil {
+		return "", fmt.Errorf("could not parse the build metadata: %v", err)
+	}
+	if md.Digest == "" {
+		return "", errors.New("the build metadata has no image digest")
+	}
+	return md.Digest, nil
+}
+
+// kanikoBuilder builds in a Kaniko pod, streaming the build context to it over kubectl's stdin. The pod reports the
+// digest of the image it pushed as its termination message.
+type kanikoBuilder struct {
+	cfg RemoteBuilderConfig
+}
+
+func (r *kanikoBuilder) Name() string {
+	if r.cfg.KubeContext != "" {
+		return "Kaniko in " + r.cfg.KubeContext
+	}
+	return "Kaniko"
+}
+
+func (r *kanikoBuilder) Build(b *RemoteBuild, out io.Writer) (string, error) {
+	if len(b.Platforms) > 1 {
+		return "", errors.New("Kaniko builds an image for a single platform, build for several with BuildKit")
+	}
+	var context bytes.Buffer
+	if err := writeBuildContext(&context, b.Dir); err != nil {
+		return "", err
+	}
+	pod := fmt.Sprintf("fn-kaniko-%d", time.Now().UnixNano())
+	args, err := r.runArgs(b, pod)
+	if err != nil {
+		return "", err
+	}
+	defer r.kubectl("delete", "pod", pod, "--wait=false").Run()
+
+	cmd := r.kubectl(args...)
+	cmd.Stdin = &context
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running the Kaniko build: %v", err)
+	}
+	digest, err := r.kubectl("get", "pod", pod, "-o", "jsonpath={.status.containerStatuses[0].state.terminated.message}").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read the digest of the Kaniko build: %v", err)
+	}
+	if d := strings.TrimSpace(string(digest)); d != "" {
+		return d, nil
+	}
+	return "", errors.New("the Kaniko build reported no image digest")
+}
+
+// kubectl returns the kubectl command running args against the cluster and namespace of the builder
+func (r *kanikoBuilder) kubectl(args ...string) *exec.Cmd {
+	var global []string
+	if r.cfg.KubeContext != "" {
+		global = append(global, "--context", r.cfg.KubeContext)
+	}
+	if r.cfg.Namespace != "" {
+		global = append(global, "--namespace", r.cfg.Namespace)
+	}
+	return exec.Command("kubectl", append(global, args...)...)
+}
+
+// runArgs returns the kubectl arguments running the Kaniko pod of b, and attaching to it
+func (r *kanikoBuilder) runArgs(b *RemoteBuild, pod string) ([]string, error) {
+	image := r.cfg.Image
+	if image == "" {
+		mirror, err := LoadImageMirror()
+		if err != nil {
+			return nil, err
+		}
+		image = mirror.Image(defaultKanikoImage)
+	}
+	dockerfile, err := filepath.Rel(b.Dir, b.Dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"run", pod, "--image", image, "--restart", "Never", "--stdin", "--quiet"}
+	if r.cfg.Secret != "" {
+		overrides, err := kanikoSecretOverrides(pod, image, r.cfg.Secret)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--overrides", overrides)
+	}
+	args = append(args, "--",
+		"--context", "tar://stdin",
+		"--dockerfile", filepath.ToSlash(dockerfile),
+		"--destination", b.Image,
+		"--digest-file", "/dev/termination-log",
+	)
+	if len(b.Platforms) == 1 {
+		args = append(args, "--custom-platform", b.Platforms[0])
+	}
+	for _, arg := range b.BuildArgs {
+		args = append(args, "--build-arg", arg)
+	}
+	for _, label := range b.Labels {
+		args = append(args, "--label", label)
+	}
+	if !b.NoCache {
+		args = append(args, "--cache=true")
+	}
+	return args, nil
+}
+
+// kanikoSecretOverrides returns the pod overrides mounting the docker-registry secret where Kaniko reads registry
+// credentials from. kubectl run only takes the container of the overrides whole, so it has the stdin settings too.
+func kanikoSecretOverrides(pod, image, secret string) (string, error) {
+	overrides := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []map[string]interface{}{{
+				"name":      pod,
+				"image":     image,
+				"stdin":     true,
+				"stdinOnce": true,
+				"volumeMounts": []map[string]string{
+					{"name": "docker-config", "mountPath": "/kaniko/.docker"},
+				},
+			}},
+			"volumes": []map[string]interface{}{{
+				"name": "docker-config",
+				"secret": map[string]interface{}{
+					"secretName": secret,
+					"items":      []map[string]string{{"key": ".dockerconfigjson", "path": "config.json"}},
+				},
+			}},
+		},
+	}
+	b, err := json.Marshal(overrides)
+	return string(b), err
+}
+
+// writeBuildContext writes dir as the gzipped tarball Kaniko reads build contexts from, leaving out version control
+// directories.
+func writeBuildContext(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".hg") {
+			return filepath.SkipDir
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,105 @@
+package langs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildKitArgs(t *testing.T) {
+	r := &buildKitBuilder{RemoteBuilderConfig{Kind: RemoteBuilderBuildKit, Addr: "tcp://buildkitd:1234"}}
+	b := &RemoteBuild{
+		Dir:        "/fns/hello",
+		Dockerfile: "/fns/hello/Dockerfile1234",
+		Image:      "registry.example.com/team/hello:0.0.2",
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+		BuildArgs:  []string{"HTTP_PROXY=http://proxy:3128"},
+		Labels:     []string{"team=payments"},
+	}
+	expected := []string{"--addr", "tcp://buildkitd:1234", "build", "--progress", "plain",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=/fns/hello",
+		"--local", "dockerfile=/fns/hello",
+		"--opt", "filename=Dockerfile1234",
+		"--opt", "platform=linux/amd64,linux/arm64",
+		"--opt", "build-arg:HTTP_PROXY=http://proxy:3128",
+		"--opt", "label:team=payments",
+		"--output", "type=image,name=registry.example.com/team/hello:0.0.2,push=true",
+		"--metadata-file", "/tmp/md.json",
+	}
+	if args := r.buildArgs(b, "/tmp/md.json"); !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+
+	digest, err := buildKitDigest([]byte(`{"containerimage.digest": "sha256:abc", "image.name": "hello"}`))
+	if err != nil || digest != "sha256:abc" {
+		t.Errorf("expected the digest of the metadata, got %q %v", digest, err)
+	}
+	if _, err := buildKitDigest([]byte(`{}`)); err == nil {
+		t.Error("expected an error for metadata without a digest")
+	}
+}
+
+func TestKanikoArgs(t *testing.T) {
+	r := &kanikoBuilder{RemoteBuilderConfig{Kind: RemoteBuilderKaniko, Namespace: "builds", Secret: "regcred"}}
+	b := &RemoteBuild{Dir: "/fns/hello", Dockerfile: "/fns/hello/Dockerfile1234", Image: "registry.example.com/hello:0.0.2"}
+	args, err := r.runArgs(b, "fn-kaniko-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(args, " ")
+	for _, expected := range []string{
+		"run fn-kaniko-1 --image " + defaultKanikoImage,
+		"--context tar://stdin --dockerfile Dockerfile1234 --destination registry.example.com/hello:0.0.2 --digest-file /dev/termination-log",
+		`"secretName":"regcred"`,
+		"--cache=true",
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("expected %q in %s", expected, joined)
+		}
+	}
+	if cmd := r.kubectl("get", "pods"); !reflect.DeepEqual(cmd.Args[1:], []string{"--namespace", "builds", "get", "pods"}) {
+		t.Errorf("expected kubectl to run in the namespace of the builder, got %v", cmd.Args)
+	}
+}
+
+func TestWriteBuildContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/master\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM fnproject/go\n"), 0644)
+
+	var buf bytes.Buffer
+	if err := writeBuildContext(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Dockerfile"}) {
+		t.Errorf("expected the context to have the Dockerfile alone, got %v", names)
+	}
+}
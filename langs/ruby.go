@@ -15,6 +15,11 @@ func (lh *RubyLangHelper) BuildFromImage() string {
 	return "funcy/ruby:dev"
 }
 
+// LooksLikeProject returns whether dir has a func.rb handler or Gemfile.
+func (lh *RubyLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "func.rb", "Gemfile")
+}
+
 func (lh *RubyLangHelper) RunFromImage() string {
 	return "funcy/ruby"
 }
@@ -22,10 +27,11 @@ func (lh *RubyLangHelper) RunFromImage() string {
 func (h *RubyLangHelper) DockerfileBuildCmds() []string {
 	r := []string{}
 	if exists("Gemfile") {
-		r = append(r,
-			"ADD Gemfile* /function/",
-			"RUN bundle install",
-		)
+		r = append(r, "ADD Gemfile* /function/")
+		if mirror := packageIndexURL(); mirror != "" {
+			r = append(r, fmt.Sprintf("RUN bundle config mirror.https://rubygems.org %s", mirror))
+		}
+		r = append(r, "RUN bundle install")
 	}
 	return r
 }
@@ -37,14 +43,34 @@ func (h *RubyLangHelper) DockerfileCopyCmds() []string {
 	}
 }
 
+// SupportsDockerlessBuild returns whether the function has no gems to install.
+func (lh *RubyLangHelper) SupportsDockerlessBuild(dir string) bool { return !anyExists(dir, "Gemfile") }
+
+// HotReloads returns whether the changes leave the Gemfile alone, the sources are copied into the image as they are.
+func (lh *RubyLangHelper) HotReloads(changed []string) bool {
+	return !changesAny(changed, "Gemfile", "Gemfile.lock")
+}
+
+// Validate checks that the function has a func.rb handler.
+func (lh *RubyLangHelper) Validate(dir string) []LintIssue {
+	return requireFiles(dir, "ruby", "func.rb")
+}
+
+// DependencyManifests returns the Gemfile and Gemfile.lock, which list the function's dependencies.
+func (lh *RubyLangHelper) DependencyManifests() []string {
+	return []string{"Gemfile", "Gemfile.lock"}
+}
+
 func (lh *RubyLangHelper) Entrypoint() string {
 	return "ruby func.rb"
 }
 
+func (lh *RubyLangHelper) ColdStartClass() string { return ColdStartFast }
+
 func (lh *RubyLangHelper) HasBoilerplate() bool { return true }
 
 func (lh *RubyLangHelper) GenerateBoilerplate() error {
-	wd, err := os.Getwd()
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
@@ -62,7 +88,7 @@ func (lh *RubyLangHelper) GenerateBoilerplate() error {
 		return fmt.Errorf(msg, "test.json")
 	}
 
-	if err := ioutil.WriteFile(codeFile, []byte(rubySrcBoilerplate), os.FileMode(0644)); err != nil {
+	if err := writeSourceFile(codeFile, rubySrcBoilerplate); err != nil {
 		return err
 	}
 
@@ -0,0 +1,100 @@
+package langs
+
+import "sort"
+
+// runtimeAliases maps runtimes to the other names GetLangHelper accepts for them
+var runtimeAliases = map[string][]string{
+	"java9":            {"java"},
+	"lambda-nodejs4.3": {"lambda-node-4"},
+}
+
+// CompletionEntry describes a runtime for shell completion
+type CompletionEntry struct {
+	Name    string
+	Aliases []string
+}
+
+// SupportedRuntimes returns the sorted names, aliases included, of the built-in, registered and plugin runtimes.
+func SupportedRuntimes() []string {
+	runtimes := KnownRuntimes()
+	for _, name := range pluginRuntimes() {
+		if !containsString(runtimes, name) {
+			runtimes = append(runtimes, name)
+		}
+	}
+	sort.Strings(runtimes)
+	return runtimes
+}
+
+// KnownRuntimes returns the sorted names, aliases included, of the built-in and registered runtimes, leaving out the
+// plugin runtimes SupportedRuntimes looks for on the PATH.
+func KnownRuntimes() []string {
+	runtimes := append([]string{}, builtinRuntimes...)
+	registryMu.RLock()
+	for name := range registry {
+		if builtinLangHelper(name) == nil {
+			runtimes = append(runtimes, name)
+		}
+	}
+	registryMu.RUnlock()
+	sort.Strings(runtimes)
+	return runtimes
+}
+
+// CompletionEntries returns a completion entry per supported runtime, with its aliases folded into it.
+func CompletionEntries() []CompletionEntry {
+	aliases := map[string]bool{}
+	for _, names := range runtimeAliases {
+		for _, alias := range names {
+			aliases[alias] = true
+		}
+	}
+
+	entries := []CompletionEntry{}
+	for _, runtime := range SupportedRuntimes() {
+		if !aliases[runtime] {
+			entries = append(entries, CompletionEntry{Name: runtime, Aliases: runtimeAliases[runtime]})
+		}
+	}
+	return entries
+}
+
+// RuntimeComparison pairs up the capabilities of two runtimes, the first value of each pair being the first
+// runtime's, to help choosing between them.
+type RuntimeComparison struct {
+	Runtimes       [2]string
+	ColdStartClass [2]string
+	MultiStage     [2]bool
+	DefaultMemory  [2]uint64
+}
+
+// Differences returns the names of the capabilities the runtimes differ in.
+func (c RuntimeComparison) Differences() []string {
+	var r []string
+	if c.ColdStartClass[0] != c.ColdStartClass[1] {
+		r = append(r, "ColdStartClass")
+	}
+	if c.MultiStage[0] != c.MultiStage[1] {
+		r = append(r, "MultiStage")
+	}
+	if c.DefaultMemory[0] != c.DefaultMemory[1] {
+		r = append(r, "DefaultMemory")
+	}
+	return r
+}
+
+// CompareRuntimes compares the capabilities of the runtimes a and b.
+func CompareRuntimes(a, b string) (RuntimeComparison, error) {
+	var c RuntimeComparison
+	for i, runtime := range []string{a, b} {
+		lh, err := GetLangHelper(runtime)
+		if err != nil {
+			return RuntimeComparison{}, err
+		}
+		c.Runtimes[i] = runtime
+		c.ColdStartClass[i] = lh.ColdStartClass()
+		c.MultiStage[i] = lh.IsMultiStage()
+		c.DefaultMemory[i] = lh.DefaultMemory()
+	}
+	return c, nil
+}
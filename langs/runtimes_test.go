@@ -0,0 +1,43 @@
+package langs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompletionEntries(t *testing.T) {
+	entries := map[string][]string{}
+	for _, entry := range CompletionEntries() {
+		entries[entry.Name] = entry.Aliases
+	}
+
+	if aliases, ok := entries["java9"]; !ok || !reflect.DeepEqual(aliases, []string{"java"}) {
+		t.Errorf("expected java9 with its java alias, got %v", entries)
+	}
+	if _, ok := entries["java"]; ok {
+		t.Error("expected the java alias to be folded into java9")
+	}
+	if len(entries)+2 != len(SupportedRuntimes()) {
+		t.Errorf("expected an entry per runtime besides the aliases, got %v", entries)
+	}
+}
+
+func TestCompareRuntimes(t *testing.T) {
+	c, err := CompareRuntimes("java8", "octave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ColdStartClass != [2]string{ColdStartSlow, ColdStartMedium} {
+		t.Errorf("expected the JVM to start slower, got %v", c.ColdStartClass)
+	}
+	if c.MultiStage != [2]bool{true, false} || c.DefaultMemory != [2]uint64{256, defaultMemory} {
+		t.Errorf("unexpected comparison %+v", c)
+	}
+	if diff := c.Differences(); !reflect.DeepEqual(diff, []string{"ColdStartClass", "MultiStage", "DefaultMemory"}) {
+		t.Errorf("expected the runtimes to differ in every capability, got %v", diff)
+	}
+
+	if _, err := CompareRuntimes("java8", "cobol"); err == nil {
+		t.Error("expected an unknown runtime to be rejected")
+	}
+}
@@ -1,45 +1,108 @@
 package langs
 
 import (
-	"errors"
-	"io/ioutil"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// RustLangHelper provides a set of helper methods for the lifecycle of Rust Cargo functions. The FDK crate version
+// can be pinned with FN_RUST_FDK_VERSION.
 type RustLangHelper struct {
 	BaseHelper
 }
 
+// rustFDKCrate is the crate of the Rust FDK
+const rustFDKCrate = "fdk"
+
+// BuildFromImage returns the Rust toolchain image, on the Debian release of the run image so the binary links
+// against the same glibc.
 func (lh *RustLangHelper) BuildFromImage() string {
-	return "rust:1"
+	return "rust:1-bookworm"
+}
+
+// LooksLikeProject returns whether dir has a Cargo.toml, or a src/main.rs.
+func (lh *RustLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "Cargo.toml", filepath.Join("src", "main.rs"))
 }
 
+// RunFromImage returns a slim Debian image, the function is a single binary.
 func (lh *RustLangHelper) RunFromImage() string {
-	return "debian:stretch"
+	return "debian:bookworm-slim"
 }
 
 func (lh *RustLangHelper) HasBoilerplate() bool { return true }
 
-func cargoTomlContent(username string) string {
+func cargoTomlContent(username, fdkVersion string) string {
 	return `[package]
 name = "func"
 version = "0.1.0"
 authors = ["` + username + `"]
+edition = "2021"
 
 [dependencies]
+` + rustFDKCrate + ` = "` + fdkVersion + `"
+tokio = { version = "1", features = ["macros", "rt-multi-thread"] }
 `
 }
 
 func mainContent() string {
-	return `fn main() {
-    println!("Hello, world!");
+	return `use fdk::{Function, FunctionError, RuntimeContext};
+
+#[tokio::main]
+async fn main() -> Result<(), FunctionError> {
+    if let Err(e) = Function::run(|_: &mut RuntimeContext, input: String| Ok(hello(input.trim()))).await {
+        eprintln!("{}", e);
+    }
+    Ok(())
+}
+
+fn hello(name: &str) -> String {
+    let name = if name.is_empty() { "World" } else { name };
+    format!("Hello {}!", name)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn hello_world() {
+        assert_eq!(hello(""), "Hello World!");
+    }
+
+    #[test]
+    fn hello_name() {
+        assert_eq!(hello("Johnny"), "Hello Johnny!");
+    }
 }
 `
 }
 
+// GenerateBoilerplate will generate a Cargo project with a hello function and its tests. It fails without writing
+// anything if any of the files already exists.
 func (lh *RustLangHelper) GenerateBoilerplate() error {
-	wd, err := os.Getwd()
+	return lh.GenerateBoilerplateContext(context.Background())
+}
+
+// GenerateBoilerplateContext is GenerateBoilerplate, aborting the FDK version lookup once ctx is done.
+func (lh *RustLangHelper) GenerateBoilerplateContext(ctx context.Context) error {
+	return lh.generateBoilerplate(ctx, false)
+}
+
+// FDKVersion returns the version of the FDK crate the boilerplate depends on.
+func (lh *RustLangHelper) FDKVersion(ctx context.Context) (string, error) {
+	return resolveFDKVersion(ctx, "rust", "Rust", RustFDKVersionResolver, "", rustFDKCrate)
+}
+
+// RegenerateBoilerplate generates the boilerplate again, replacing the files already there.
+func (lh *RustLangHelper) RegenerateBoilerplate() error {
+	return lh.generateBoilerplate(context.Background(), true)
+}
+
+func (lh *RustLangHelper) generateBoilerplate(ctx context.Context, overwrite bool) error {
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
@@ -48,22 +111,23 @@ func (lh *RustLangHelper) GenerateBoilerplate() error {
 		username = "unknown"
 	}
 
-	pathToCargoToml := filepath.Join(wd, "Cargo.toml")
-	if exists(pathToCargoToml) {
-		return ErrBoilerplateExists
+	files := map[string]string{
+		"Cargo.toml":  "", // rendered once the FDK version is known
+		"src/main.rs": mainContent(),
 	}
-	if err := ioutil.WriteFile(pathToCargoToml, []byte(cargoTomlContent(username)), os.FileMode(0644)); err != nil {
-		return err
-	}
-	if err = os.MkdirAll(filepath.Join(wd, "src"), os.FileMode(0755)); err != nil {
-		return err
+	if !overwrite {
+		if err := checkBoilerplate(wd, files); err != nil {
+			return err
+		}
 	}
-	pathToMain := filepath.Join(wd, "src", "main.rs")
-	if err := ioutil.WriteFile(pathToMain, []byte(mainContent()), os.FileMode(0644)); err != nil {
+
+	fdkVersion, err := lh.FDKVersion(ctx)
+	if err != nil {
 		return err
 	}
+	files["Cargo.toml"] = cargoTomlContent(username, fdkVersion)
 
-	return nil
+	return writeBoilerplate(wd, files)
 }
 
 func (lh *RustLangHelper) Entrypoint() string {
@@ -71,35 +135,117 @@ func (lh *RustLangHelper) Entrypoint() string {
 }
 
 func (lh *RustLangHelper) DockerfileCopyCmds() []string {
+	if isDebugBuild() {
+		return []string{
+			"COPY --from=build-stage /function/src/target/debug/func /function/func",
+		}
+	}
 	return []string{
 		"COPY --from=build-stage /function/src/target/release/func /function/func",
 	}
 }
 
+// DockerfileBuildCmds fetches the dependencies and builds the function, see DockerfileStages.
 func (lh *RustLangHelper) DockerfileBuildCmds() []string {
-	r := []string{}
-	r = append(r, "ADD . /function/src/")
-	r = append(r, "RUN cd /function/src/ && cargo build --release")
-	return r
+	return append(rustFetchCmds(), lh.cargoBuildCmds()...)
+}
+
+// DockerfileStages fetches the dependencies in a stage of their own, before the sources are added, so that they
+// are only downloaded again when Cargo.toml or Cargo.lock change.
+func (lh *RustLangHelper) DockerfileStages() []Stage {
+	return []Stage{
+		{Name: "deps", From: FromBuildImage, Cmds: rustFetchCmds()},
+		{Name: "build-stage", From: "deps", Cmds: lh.cargoBuildCmds()},
+		{From: FromRunImage, Cmds: lh.DockerfileCopyCmds()},
+	}
+}
+
+// BuildCacheDirs returns the Cargo registry, shared by the fetch and build stages.
+func (lh *RustLangHelper) BuildCacheDirs() []string { return []string{"/usr/local/cargo/registry"} }
+
+// rustFetchCmds returns the steps fetching the dependencies of the manifest, with a placeholder main as Cargo
+// needs a target to read it
+func rustFetchCmds() []string {
+	return []string{
+		"ADD Cargo.toml Cargo.lock* /function/src/",
+		"RUN mkdir -p /function/src/src && echo 'fn main() {}' > /function/src/src/main.rs && cd /function/src/ && cargo fetch",
+	}
+}
+
+// cargoBuildCmds returns the steps building the function binary from its sources
+func (lh *RustLangHelper) cargoBuildCmds() []string {
+	build := "RUN cd /function/src/ && cargo build"
+	if n := lh.BuildParallelism(); n > 1 {
+		build += fmt.Sprintf(" -j %d", n)
+	}
+	if !isDebugBuild() {
+		build += " --release"
+	}
+	return []string{"ADD . /function/src/", build}
+}
+
+func (lh *RustLangHelper) ColdStartClass() string { return ColdStartFast }
+
+// Validate checks that the function is a Cargo project depending on the FDK crate, at the version pinned if one is.
+func (lh *RustLangHelper) Validate(dir string) []LintIssue {
+	if issues := requireFiles(dir, "rust", "Cargo.toml", "src/main.rs"); issues != nil {
+		return issues
+	}
+	cargoToml, issues := readLintFile(dir, "Cargo.toml")
+	if issues != nil {
+		return issues
+	}
+	return checkFDKVersion("rust", "Cargo.toml", cargoFDKVersion(cargoToml))
+}
+
+// Migrate updates the FDK crate of the Cargo.toml to the current FDK version. Cargo.lock follows on the next build.
+func (lh *RustLangHelper) Migrate(dir string) ([]Migration, error) {
+	m, err := migrateFile(dir, "Cargo.toml", func(cargoToml []byte) ([]byte, []string, error) {
+		version, err := lh.FDKVersion(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+		// the crate is either "version" or { version = "version", ... }
+		updated, changes := replaceVersions(cargoToml, cargoFDKDependencyPattern, 1, rustFDKCrate, version)
+		updated, tableChanges := replaceVersions(updated, cargoFDKDependencyPattern, 2, rustFDKCrate, version)
+		return updated, append(changes, tableChanges...), nil
+	})
+	return migrations(m), err
 }
 
+// DependencyManifests returns Cargo.toml and Cargo.lock, which list the function's dependencies.
+func (lh *RustLangHelper) DependencyManifests() []string {
+	return []string{"Cargo.toml", "Cargo.lock"}
+}
+
+// TestCmds runs cargo test on the crate of the function.
+func (lh *RustLangHelper) TestCmds() []string { return []string{"cd /function/src/ && cargo test"} }
+
 func (lh *RustLangHelper) HasPreBuild() bool {
 	return true
 }
 
 func (lh *RustLangHelper) PreBuild() error {
-	wd, err := os.Getwd()
+	wd, err := FunctionDir()
 	if err != nil {
 		return err
 	}
 
 	if !exists(filepath.Join(wd, "Cargo.toml")) {
-		return errors.New("Could not find Cargo.toml - are you sure this is a Rust Cargo project?")
+		return notProjectError("Could not find Cargo.toml - are you sure this is a Rust Cargo project?")
 	}
 
 	return nil
 }
 
+func (lh *RustLangHelper) HasAfterBuild() bool {
+	return true
+}
+
 func (lh *RustLangHelper) AfterBuild() error {
-	return os.RemoveAll("target")
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(wd, "target"))
 }
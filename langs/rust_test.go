@@ -0,0 +1,39 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRustBoilerplate(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_RUST_FDK_VERSION", "0.1.3")
+	defer os.Unsetenv("FN_RUST_FDK_VERSION")
+	if err := (&RustLangHelper{}).GenerateBoilerplate(); err != nil {
+		t.Fatal(err)
+	}
+	if manifest := readFile(t, filepath.Join(tmp, "Cargo.toml")); !strings.Contains(manifest, "[dependencies]\nfdk = \"0.1.3\"\n") {
+		t.Errorf("expected the pinned FDK dependency, got:\n%s", manifest)
+	}
+	if main := readFile(t, filepath.Join(tmp, "src", "main.rs")); !strings.Contains(main, "#[test]") || !strings.Contains(main, "Function::run(") {
+		t.Errorf("expected the hello function to be run through the FDK and come with tests, got:\n%s", main)
+	}
+	if err := (&RustLangHelper{}).GenerateBoilerplate(); err != ErrBoilerplateExists && !strings.Contains(err.Error(), "Cargo.toml") {
+		t.Errorf("expected the existing boilerplate to be kept, got %v", err)
+	}
+}
+
+func TestRustStagesFetchBeforeSources(t *testing.T) {
+	stages := (&RustLangHelper{}).DockerfileStages()
+	if len(stages) != 3 || stages[0].Name != "deps" || stages[1].From != "deps" {
+		t.Fatalf("expected deps, build and run stages, got %+v", stages)
+	}
+	deps := strings.Join(stages[0].Cmds, "\n")
+	if !strings.Contains(deps, "cargo fetch") || strings.Contains(deps, "ADD . ") {
+		t.Errorf("expected the deps stage to fetch from the manifest alone, got:\n%s", deps)
+	}
+}
@@ -0,0 +1,357 @@
+package langs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// nixPackages lists the nixpkgs attributes providing the development toolchain for each runtime. Runtimes
+// missing here, such as binary, have no toolchain to provide and get no flake.
+var nixPackages = map[string][]string{
+	"go":               {"go"},
+	"node":             {"nodejs"},
+	"ruby":             {"ruby"},
+	"python":           {"python2"},
+	"php":              {"php"},
+	"rust":             {"rustc", "cargo"},
+	"dotnet":           {"dotnet-sdk"},
+	"lambda-nodejs4.3": {"nodejs"},
+	"lambda-node-4":    {"nodejs"},
+	"java":             {"jdk9", "maven"},
+	"java8":            {"jdk8", "maven"},
+	"java9":            {"jdk9", "maven"},
+	"octave":           {"octave"},
+	"kotlin":           {"jdk8", "gradle"},
+	"elixir":           {"elixir"},
+	"factor":           {"factor-lang"},
+	"haxe":             {"haxe"},
+	"solidity":         {"solc"},
+	"oberon":           {"obnc"},
+	"gst":              {"gnu-smalltalk"},
+}
+
+// unitTestCmds holds the native command running a runtime's generated unit tests
+var unitTestCmds = map[string]string{
+	"go":     "go test ./...",
+	"rust":   "cargo test",
+	"java":   "mvn test",
+	"java8":  "mvn test",
+	"java9":  "mvn test",
+	"haxe":   "haxe test.hxml",
+	"octave": `octave --eval "test greeting"`,
+	"factor": `factor -roots=. -e='USING: tools.test ; "hello" test'`,
+	"oberon": "obnc TestGreeting.obn && ./TestGreeting",
+	"gst":    "gst -q Greeting.st TestGreeting.st",
+	"kotlin": "gradle test",
+	"elixir": "mix test",
+}
+
+// lintCmds holds the command linting a runtime's sources, run from the pre-commit hooks
+var lintCmds = map[string]string{
+	"go":     "go vet ./...",
+	"node":   "npx eslint .",
+	"ruby":   "rubocop",
+	"python": "flake8",
+	"rust":   "cargo clippy -- -D warnings",
+	"java":   "mvn checkstyle:check",
+	"java8":  "mvn checkstyle:check",
+	"java9":  "mvn checkstyle:check",
+	"elixir": "mix format --check-formatted",
+}
+
+// indentStyles holds the indentation a runtime's sources follow, as "tab" or a number of spaces
+var indentStyles = map[string]string{
+	"go":     "tab",
+	"node":   "2",
+	"ruby":   "2",
+	"python": "4",
+	"php":    "4",
+	"rust":   "4",
+	"dotnet": "4",
+	"java":   "4",
+	"java8":  "4",
+	"java9":  "4",
+	"haxe":   "4",
+	"octave": "2",
+	"factor": "4",
+	"kotlin": "4",
+	"elixir": "2",
+}
+
+// GenerateScaffoldExtras writes the optional project files enabled through the FN_SCAFFOLD_* environment variables
+// next to the function boilerplate. Existing files are left untouched.
+func GenerateScaffoldExtras(runtime string) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if envEnabled("FN_SCAFFOLD_NIX") {
+		flake, err := nixFlakeContent(runtime)
+		if err != nil {
+			return err
+		}
+		if err := writeScaffoldFile(filepath.Join(wd, "flake.nix"), flake); err != nil {
+			return err
+		}
+	}
+
+	if envEnabled("FN_SCAFFOLD_README") {
+		if err := writeScaffoldFile(filepath.Join(wd, "README.md"), readmeContent(filepath.Base(wd), runtime)); err != nil {
+			return err
+		}
+	}
+
+	if envEnabled("FN_SCAFFOLD_HELM") {
+		if err := writeHelmChart(filepath.Join(wd, "chart"), filepath.Base(wd), runtime); err != nil {
+			return err
+		}
+	}
+
+	if envEnabled("FN_SCAFFOLD_COMPOSE") {
+		if lh, err := GetLangHelper(runtime); err == nil && lh.ComposeFragment() != "" {
+			if err := writeScaffoldFile(filepath.Join(wd, "docker-compose.yml"), "services:\n"+lh.ComposeFragment()); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch tool := os.Getenv("FN_SCAFFOLD_TASKFILE"); tool {
+	case "":
+	case "task":
+		if err := writeScaffoldFile(filepath.Join(wd, "Taskfile.yml"), taskfileContent(runtime)); err != nil {
+			return err
+		}
+	case "just":
+		if err := writeScaffoldFile(filepath.Join(wd, "justfile"), justfileContent(runtime)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown FN_SCAFFOLD_TASKFILE %s, use task or just", tool)
+	}
+
+	if envEnabled("FN_SCAFFOLD_EDITORCONFIG") {
+		if err := writeScaffoldFile(filepath.Join(wd, ".editorconfig"), editorConfigContent(runtime)); err != nil {
+			return err
+		}
+	}
+
+	if envEnabled("FN_SCAFFOLD_PRECOMMIT") {
+		if err := writeScaffoldFile(filepath.Join(wd, ".pre-commit-config.yaml"), preCommitContent(runtime)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// envEnabled reports whether a boolean environment toggle such as FN_SCAFFOLD_NIX=1 is switched on
+func envEnabled(name string) bool {
+	enabled, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && enabled
+}
+
+func writeScaffoldFile(path, content string) error {
+	if exists(path) {
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(content), os.FileMode(0644))
+}
+
+func nixFlakeContent(runtime string) (string, error) {
+	pkgs, ok := nixPackages[runtime]
+	if !ok {
+		return "", fmt.Errorf("FN_SCAFFOLD_NIX: no nixpkgs toolchain known for the %s runtime", runtime)
+	}
+	return fmt.Sprintf(nixFlake, strings.Join(pkgs, " ")), nil
+}
+
+func readmeContent(name, runtime string) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\nAn Fn function using the %s runtime.\n\n", name, runtime)
+	b.WriteString("## Build\n\n```sh\nfn build\n```\n\n")
+	b.WriteString("## Test\n\n```sh\n")
+	for _, cmd := range testCmds(runtime) {
+		b.WriteString(cmd + "\n")
+	}
+	b.WriteString("```\n\n")
+	b.WriteString("## Run locally\n\n```sh\necho -n '{\"name\":\"Johnny\"}' | fn run\n```\n\n")
+	b.WriteString("## Deploy\n\n```sh\nfn deploy --app myapp\n```\n")
+	return b.String()
+}
+
+// testCmds returns the commands testing the function, its unit tests if the runtime has any and then fn test
+func testCmds(runtime string) []string {
+	cmds := []string{}
+	if cmd, ok := unitTestCmds[runtime]; ok {
+		cmds = append(cmds, cmd)
+	}
+	return append(cmds, "fn test")
+}
+
+func taskfileContent(runtime string) string {
+	var b bytes.Buffer
+	b.WriteString("version: '3'\n\ntasks:\n  build:\n    cmds:\n      - fn build\n  test:\n    cmds:\n")
+	for _, cmd := range testCmds(runtime) {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(cmd))
+	}
+	b.WriteString("  deploy:\n    cmds:\n      - fn deploy --app {{.APP | default \"myapp\"}}\n")
+	return b.String()
+}
+
+func justfileContent(runtime string) string {
+	var b bytes.Buffer
+	b.WriteString("app := \"myapp\"\n\nbuild:\n    fn build\n\ntest:\n")
+	for _, cmd := range testCmds(runtime) {
+		fmt.Fprintf(&b, "    %s\n", cmd)
+	}
+	b.WriteString("\ndeploy:\n    fn deploy --app {{app}}\n")
+	return b.String()
+}
+
+// yamlQuote single quotes commands that YAML would otherwise misread, such as ones containing ": " or quotes
+func yamlQuote(s string) string {
+	if !strings.ContainsAny(s, ":'\"{}[]#&*!|>%@`") {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func editorConfigContent(runtime string) string {
+	var b bytes.Buffer
+	b.WriteString("root = true\n\n[*]\ncharset = utf-8\nend_of_line = lf\ninsert_final_newline = true\ntrim_trailing_whitespace = true\n")
+	switch indent := indentStyles[runtime]; indent {
+	case "":
+	case "tab":
+		b.WriteString("indent_style = tab\n")
+	default:
+		fmt.Fprintf(&b, "indent_style = space\nindent_size = %s\n", indent)
+	}
+	b.WriteString("\n[*.{json,yaml,yml}]\nindent_style = space\nindent_size = 2\n")
+	return b.String()
+}
+
+// preCommitContent returns pre-commit hooks keeping whitespace tidy, and running the runtime's linter if it has one
+func preCommitContent(runtime string) string {
+	var b bytes.Buffer
+	b.WriteString(preCommitConfig)
+	if cmd, ok := lintCmds[runtime]; ok {
+		fmt.Fprintf(&b, preCommitLintHook, yamlQuote(cmd))
+	}
+	return b.String()
+}
+
+// writeHelmChart writes a minimal chart deploying the function image on the port and memory its helper defaults to,
+// giving new pods the startup grace period of the helper before they count as available
+func writeHelmChart(dir, name, runtime string) error {
+	port, memory, grace := defaultFDKListenPort, uint64(defaultMemory), (&BaseHelper{}).StartupGracePeriodSeconds()
+	if lh, err := GetLangHelper(runtime); err == nil {
+		port, memory, grace = lh.FDKListenPort(), lh.DefaultMemory(), lh.StartupGracePeriodSeconds()
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), os.FileMode(0755)); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"Chart.yaml":  fmt.Sprintf(helmChart, name),
+		"values.yaml": fmt.Sprintf(helmValues, name, port, memory, grace),
+		filepath.Join("templates", "deployment.yaml"): helmDeployment,
+	}
+	for file, content := range files {
+		if err := writeScaffoldFile(filepath.Join(dir, file), content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const nixFlake = `{
+  description = "Fn function";
+
+  inputs.nixpkgs.url = "github:NixOS/nixpkgs/nixos-unstable";
+  inputs.flake-utils.url = "github:numtide/flake-utils";
+
+  outputs = { self, nixpkgs, flake-utils }:
+    flake-utils.lib.eachDefaultSystem (system:
+      let
+        pkgs = nixpkgs.legacyPackages.${system};
+        toolchain = with pkgs; [ %s ];
+      in {
+        devShells.default = pkgs.mkShell {
+          buildInputs = toolchain;
+        };
+
+        packages.default = pkgs.writeShellApplication {
+          name = "fn-build";
+          runtimeInputs = toolchain;
+          text = "exec fn build \"$@\"";
+        };
+      });
+}
+`
+
+const preCommitConfig = `repos:
+  - repo: https://github.com/pre-commit/pre-commit-hooks
+    rev: v4.4.0
+    hooks:
+      - id: trailing-whitespace
+      - id: end-of-file-fixer
+      - id: check-yaml
+`
+
+const preCommitLintHook = `  - repo: local
+    hooks:
+      - id: lint
+        name: lint
+        entry: %s
+        language: system
+        pass_filenames: false
+`
+
+const helmChart = `apiVersion: v2
+name: %s
+description: An Fn function
+type: application
+version: 0.1.0
+appVersion: "0.0.1"
+`
+
+const helmValues = `image:
+  # prefix with the registry fn build tags images with, e.g. myregistry/%[1]s
+  repository: %[1]s
+  tag: "0.0.1"
+replicas: 1
+port: %[2]d
+memory: %[3]dMi
+startupGracePeriodSeconds: %[4]d
+`
+
+const helmDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Chart.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+  minReadySeconds: {{ .Values.startupGracePeriodSeconds }}
+  selector:
+    matchLabels:
+      app: {{ .Chart.Name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Chart.Name }}
+    spec:
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+          ports:
+            - containerPort: {{ .Values.port }}
+          resources:
+            limits:
+              memory: {{ .Values.memory }}
+`
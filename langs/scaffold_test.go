@@ -0,0 +1,189 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirTemp switches the working directory to a fresh temp dir, as the helpers generate into the working directory
+func chdirTemp(t *testing.T) (string, func()) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := ioutil.TempDir("", "langs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	return tmp, func() {
+		os.Chdir(wd)
+		os.RemoveAll(tmp)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestScaffoldNixFlake(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(filepath.Join(tmp, "flake.nix")) {
+		t.Fatal("flake.nix generated without FN_SCAFFOLD_NIX")
+	}
+
+	os.Setenv("FN_SCAFFOLD_NIX", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_NIX")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	flake := readFile(t, filepath.Join(tmp, "flake.nix"))
+	if !strings.Contains(flake, "[ jdk8 maven ]") {
+		t.Errorf("expected flake to reference the Java toolchain, got:\n%s", flake)
+	}
+
+	for _, runtime := range builtinRuntimes {
+		if _, ok := nixPackages[runtime]; !ok && runtime != "binary" {
+			t.Errorf("expected nixpkgs attributes for the %s runtime", runtime)
+		}
+	}
+	if err := GenerateScaffoldExtras("binary"); err == nil {
+		t.Error("expected an error scaffolding a flake for a runtime without a toolchain")
+	}
+}
+
+func TestScaffoldReadme(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_README", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_README")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	readme := readFile(t, filepath.Join(tmp, "README.md"))
+	for _, cmd := range []string{"fn build", "mvn test", "fn test", "fn deploy --app myapp"} {
+		if !strings.Contains(readme, cmd) {
+			t.Errorf("expected README to mention %q, got:\n%s", cmd, readme)
+		}
+	}
+}
+
+func TestScaffoldEditorConfig(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_EDITORCONFIG", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_EDITORCONFIG")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	config := readFile(t, filepath.Join(tmp, ".editorconfig"))
+	for _, setting := range []string{"root = true", "end_of_line = lf", "indent_style = space\nindent_size = 4"} {
+		if !strings.Contains(config, setting) {
+			t.Errorf("expected .editorconfig to contain %q, got:\n%s", setting, config)
+		}
+	}
+}
+
+func TestScaffoldHelmChart(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_HELM", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_HELM")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	values := readFile(t, filepath.Join(tmp, "chart", "values.yaml"))
+	for _, value := range []string{"repository: " + filepath.Base(tmp), "port: 8080", "memory: 256Mi", "startupGracePeriodSeconds: 30"} {
+		if !strings.Contains(values, value) {
+			t.Errorf("expected values.yaml to contain %q, got:\n%s", value, values)
+		}
+	}
+	deployment := readFile(t, filepath.Join(tmp, "chart", "templates", "deployment.yaml"))
+	for _, ref := range []string{"containerPort: {{ .Values.port }}", "memory: {{ .Values.memory }}", "minReadySeconds: {{ .Values.startupGracePeriodSeconds }}"} {
+		if !strings.Contains(deployment, ref) {
+			t.Errorf("expected the deployment to reference %q, got:\n%s", ref, deployment)
+		}
+	}
+}
+
+func TestScaffoldCompose(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_COMPOSE", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_COMPOSE")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	compose := readFile(t, filepath.Join(tmp, "docker-compose.yml"))
+	if !strings.HasPrefix(compose, "services:\n  "+filepath.Base(tmp)+":\n") || !strings.Contains(compose, `- "8080:8080"`) {
+		t.Errorf("expected docker-compose.yml to hold the service of the function, got:\n%s", compose)
+	}
+
+	// runtimes without a compose fragment get no file
+	os.Remove(filepath.Join(tmp, "docker-compose.yml"))
+	if err := GenerateScaffoldExtras("go"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(filepath.Join(tmp, "docker-compose.yml")) {
+		t.Error("expected no docker-compose.yml for a runtime without a compose fragment")
+	}
+}
+
+func TestScaffoldTaskfile(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_TASKFILE", "task")
+	defer os.Unsetenv("FN_SCAFFOLD_TASKFILE")
+	if err := GenerateScaffoldExtras("octave"); err != nil {
+		t.Fatal(err)
+	}
+	taskfile := readFile(t, filepath.Join(tmp, "Taskfile.yml"))
+	if !strings.Contains(taskfile, "  test:\n    cmds:\n      - 'octave --eval \"test greeting\"'\n      - fn test\n") {
+		t.Errorf("expected the test task to run the unit tests, got:\n%s", taskfile)
+	}
+
+	os.Setenv("FN_SCAFFOLD_TASKFILE", "just")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	justfile := readFile(t, filepath.Join(tmp, "justfile"))
+	if !strings.Contains(justfile, "test:\n    mvn test\n    fn test\n") {
+		t.Errorf("expected the test recipe to run the unit tests, got:\n%s", justfile)
+	}
+}
+
+func TestScaffoldPreCommit(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+
+	os.Setenv("FN_SCAFFOLD_PRECOMMIT", "1")
+	defer os.Unsetenv("FN_SCAFFOLD_PRECOMMIT")
+	if err := GenerateScaffoldExtras("java8"); err != nil {
+		t.Fatal(err)
+	}
+	config := readFile(t, filepath.Join(tmp, ".pre-commit-config.yaml"))
+	for _, hook := range []string{"id: trailing-whitespace", "entry: 'mvn checkstyle:check'"} {
+		if !strings.Contains(config, hook) {
+			t.Errorf("expected the pre-commit config to contain %q, got:\n%s", hook, config)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SolidityLangHelper provides a set of helper methods for functions wrapping a Solidity contract off-chain. The
+// contract is compiled with Hardhat, or with Foundry when FN_SOLIDITY_TOOLCHAIN=foundry, and a Node.js handler
+// encodes calls against the compiled ABI.
+type SolidityLangHelper struct {
+	BaseHelper
+}
+
+func useFoundry() bool {
+	return os.Getenv("FN_SOLIDITY_TOOLCHAIN") == "foundry"
+}
+
+func solidityConfigFile() string {
+	if useFoundry() {
+		return "foundry.toml"
+	}
+	return "hardhat.config.js"
+}
+
+// BuildFromImage returns the Docker image of the chosen Solidity toolchain
+func (lh *SolidityLangHelper) BuildFromImage() string {
+	if useFoundry() {
+		return "ghcr.io/foundry-rs/foundry:latest"
+	}
+	return "node:18"
+}
+
+// LooksLikeProject returns whether dir has a Hardhat or Foundry config.
+func (lh *SolidityLangHelper) LooksLikeProject(dir string) bool {
+	return anyExists(dir, "hardhat.config.js", "foundry.toml")
+}
+
+// RunFromImage returns the Node.js image running the wrapper handler
+func (lh *SolidityLangHelper) RunFromImage() string {
+	return "node:18-alpine"
+}
+
+// Entrypoint runs the wrapper handler.
+func (lh *SolidityLangHelper) Entrypoint() string {
+	return "node func.js"
+}
+
+// DockerfileBuildCmds returns the build stage steps compiling the contracts.
+func (lh *SolidityLangHelper) DockerfileBuildCmds() []string {
+	if useFoundry() {
+		return []string{
+			"ADD . /function/",
+			"RUN forge build",
+		}
+	}
+	return []string{
+		"ADD package.json /function/",
+		"RUN npm install",
+		"ADD . /function/",
+		"RUN npx hardhat compile",
+	}
+}
+
+// DockerfileCopyCmds installs the handler's runtime dependencies and copies it along with the contract artifact.
+func (lh *SolidityLangHelper) DockerfileCopyCmds() []string {
+	artifact := "/function/artifacts/contracts/Greeter.sol/Greeter.json"
+	if useFoundry() {
+		artifact = "/function/out/Greeter.sol/Greeter.json"
+	}
+	return []string{
+		"ADD package.json /function/",
+		"RUN npm install --production",
+		"ADD func.js /function/",
+		"COPY --from=build-stage " + artifact + " /function/Greeter.json",
+	}
+}
+
+// HasBoilerplate returns whether the Solidity runtime has boilerplate that can be generated.
+func (lh *SolidityLangHelper) HasBoilerplate() bool { return true }
+
+// GenerateBoilerplate will generate the toolchain config, a sample contract and the wrapper handler.
+func (lh *SolidityLangHelper) GenerateBoilerplate() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	pathToConfig := filepath.Join(wd, solidityConfigFile())
+	if exists(pathToConfig) {
+		return ErrBoilerplateExists
+	}
+	config := hardhatConfigBoilerplate
+	if useFoundry() {
+		config = foundryConfigBoilerplate
+	}
+	if err := writeSourceFile(pathToConfig, config); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Join(wd, "contracts"), os.FileMode(0755)); err != nil {
+		return err
+	}
+	files := map[string]string{
+		filepath.Join("contracts", "Greeter.sol"): greeterContractBoilerplate,
+		"package.json": solidityPackageJSONBoilerplate,
+		"func.js":      solidityHandlerBoilerplate,
+	}
+	for name, content := range files {
+		if err := writeSourceFile(filepath.Join(wd, name), content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPreBuild returns whether the Solidity runtime has a pre-build step.
+func (lh *SolidityLangHelper) HasPreBuild() bool { return true }
+
+// PreBuild ensures that the function has a configuration for the chosen toolchain.
+func (lh *SolidityLangHelper) PreBuild() error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+
+	if !exists(filepath.Join(wd, solidityConfigFile())) {
+		return notProjectError("Could not find " + solidityConfigFile() + " - are you sure this is a Solidity project?")
+	}
+
+	return nil
+}
+
+const (
+	hardhatConfigBoilerplate = `module.exports = {
+  solidity: "0.8.17",
+};
+`
+
+	foundryConfigBoilerplate = `[profile.default]
+src = "contracts"
+out = "out"
+solc_version = "0.8.17"
+`
+
+	greeterContractBoilerplate = `// SPDX-License-Identifier: UNLICENSED
+pragma solidity ^0.8.17;
+
+contract Greeter {
+    event Greeted(string message);
+
+    function greet(string calldata name) external returns (string memory) {
+        string memory who = name;
+        if (bytes(who).length == 0) {
+            who = "World";
+        }
+        string memory message = string.concat("Hello ", who);
+        emit Greeted(message);
+        return message;
+    }
+}
+`
+
+	solidityPackageJSONBoilerplate = `{
+  "name": "func",
+  "private": true,
+  "dependencies": {
+    "ethers": "^5.7.2"
+  },
+  "devDependencies": {
+    "hardhat": "^2.12.0"
+  }
+}
+`
+
+	solidityHandlerBoilerplate = `const { utils } = require("ethers");
+const artifact = require("./Greeter.json");
+
+// Encodes a Greeter.greet call, ready to be signed and sent to the chain.
+const iface = new utils.Interface(artifact.abi);
+
+let input = "";
+process.stdin.on("data", (chunk) => (input += chunk));
+process.stdin.on("end", () => {
+  let name = "";
+  if (input) {
+    name = JSON.parse(input).name || "";
+  }
+  const data = iface.encodeFunctionData("greet", [name]);
+  process.stdout.write(JSON.stringify({ function: "greet", args: [name], data: data }));
+});
+`
+)
@@ -0,0 +1,236 @@
+package langs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// The SBOM formats fn build, fn push and fn deploy generate with --sbom.
+const (
+	SBOMSPDX      = "spdx"
+	SBOMCycloneDX = "cyclonedx"
+)
+
+// CheckSBOMFormat returns an error if format isn't one --sbom generates.
+func CheckSBOMFormat(format string) error {
+	switch format {
+	case SBOMSPDX, SBOMCycloneDX:
+		return nil
+	}
+	return fmt.Errorf("unknown SBOM format %q, use %s or %s", format, SBOMSPDX, SBOMCycloneDX)
+}
+
+// SBOMSource returns the syft source reading image from the local images of the container engine, for SBOMs of
+// images that haven't been pushed.
+func SBOMSource(engine ContainerEngine, image string) (string, error) {
+	switch engine.Name() {
+	case "docker", "podman":
+		return engine.Name() + ":" + image, nil
+	}
+	return "", fmt.Errorf("syft can't read the images of %s, push the image and generate the SBOM with fn push --sbom", engine.Name())
+}
+
+// GenerateSBOM writes an SBOM of the image syft reads from source, such as docker:image or registry:image, to out.
+// Language dependencies that don't end up in the image, such as those compiled into a jar, are read from the
+// manifests, paths relative to dir which the helper's DependencyManifests lists, and merged in. What syft prints
+// goes to output.
+func GenerateSBOM(source, dir string, manifests []string, format, out string, output io.Writer) error {
+	if err := CheckSBOMFormat(format); err != nil {
+		return err
+	}
+	if _, err := exec.LookPath("syft"); err != nil {
+		return fmt.Errorf("syft is needed to generate SBOMs, install it from https://github.com/anchore/syft")
+	}
+	image, err := runSyft(source, format, output)
+	if err != nil {
+		return err
+	}
+
+	staging, err := ioutil.TempDir("", "fn-sbom")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	staged := false
+	for _, manifest := range manifests {
+		b, err := ioutil.ReadFile(filepath.Join(dir, manifest))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		path := filepath.Join(staging, manifest)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			return err
+		}
+		staged = true
+	}
+	if staged {
+		deps, err := runSyft("dir:"+staging, format, output)
+		if err != nil {
+			return err
+		}
+		if image, err = mergeSBOMs(format, image, deps); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(out, image, 0644)
+}
+
+func runSyft(source, format string, output io.Writer) ([]byte, error) {
+	sbomOutput := "spdx-json"
+	if format == SBOMCycloneDX {
+		sbomOutput = "cyclonedx-json"
+	}
+	cmd := exec.Command("syft", source, "-q", "-o", sbomOutput)
+	cmd.Stderr = output
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error generating the SBOM of %s: %v", source, err)
+	}
+	return out, nil
+}
+
+// mergeSBOMs adds the packages of the deps SBOM, and their relationships, to the image one. Packages both list are
+// kept once.
+func mergeSBOMs(format string, image, deps []byte) ([]byte, error) {
+	var imageDoc, depsDoc map[string]interface{}
+	if err := json.Unmarshal(image, &imageDoc); err != nil {
+		return nil, fmt.Errorf("could not parse the SBOM of the image: %v", err)
+	}
+	if err := json.Unmarshal(deps, &depsDoc); err != nil {
+		return nil, fmt.Errorf("could not parse the SBOM of the dependency manifests: %v", err)
+	}
+	if format == SBOMCycloneDX {
+		mergeSBOMList(imageDoc, depsDoc, "components", "bom-ref")
+		mergeSBOMList(imageDoc, depsDoc, "dependencies", "ref")
+	} else {
+		mergeSBOMList(imageDoc, depsDoc, "packages", "SPDXID")
+		mergeSBOMList(imageDoc, depsDoc, "relationships", "")
+	}
+	return json.MarshalIndent(imageDoc, "", "  ")
+}
+
+// mergeSBOMList appends the entries of the list field of from to the one of to, leaving out the ones whose key, or
+// whole entry if key is empty, to already has.
+func mergeSBOMList(to, from map[string]interface{}, field, key string) {
+	list, _ := to[field].([]interface{})
+	seen := map[string]bool{}
+	id := func(entry interface{}) string {
+		if m, ok := entry.(map[string]interface{}); ok && key != "" {
+			return fmt.Sprint(m[key])
+		}
+		b, _ := json.Marshal(entry)
+		return string(b)
+	}
+	for _, entry := range list {
+		seen[id(entry)] = true
+	}
+	added, _ := from[field].([]interface{})
+	for _, entry := range added {
+		if !seen[id(entry)] {
+			seen[id(entry)] = true
+			list = append(list, entry)
+		}
+	}
+	if list != nil {
+		to[field] = list
+	}
+}
+
+// PushedImage returns the pushed image as repository@digest, so that it is what was pushed that gets signed and
+// attested, not whatever the tag points to by then.
+func PushedImage(image string) (string, error) {
+	engine, err := Engine()
+	if err != nil {
+		return "", err
+	}
+	out, err := engine.Command(engine.DigestArgs(image)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("error looking up the digest %s was pushed with: %v", image, err)
+	}
+	return pushedImage(image, string(out))
+}
+
+// pushedImage returns image pinned to the digest out prints, bare or as the repository@digest of the repository of
+// image.
+func pushedImage(image, out string) (string, error) {
+	repository := image
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	for _, line := range strings.Fields(out) {
+		digest := line
+		if i := strings.Index(line, "@"); i >= 0 {
+			if line[:i] != repository {
+				continue
+			}
+			digest = line[i+1:]
+		}
+		if digestPattern.MatchString(digest) {
+			return repository + "@" + digest, nil
+		}
+	}
+	return "", fmt.Errorf("could not find the digest %s was pushed with", image)
+}
+
+// SignImage signs the pushed image, a repository@digest, with cosign, keyless with a Sigstore identity unless key
+// names a key file or a KMS URI. The SBOM at sbomPath, if any, is attached as a signed attestation. What cosign
+// prints goes to output.
+func SignImage(image, key, sbomPath, format string, output io.Writer) error {
+	if err := runCosign(cosignSignArgs(image, key), output); err != nil {
+		return err
+	}
+	if sbomPath == "" {
+		return nil
+	}
+	return runCosign(cosignAttestArgs(image, key, sbomPath, format), output)
+}
+
+// AttachSBOM attaches the SBOM at sbomPath to the pushed image, a repository@digest, unsigned.
+func AttachSBOM(image, sbomPath, format string, output io.Writer) error {
+	return runCosign([]string{"attach", "sbom", "--sbom", sbomPath, "--type", format, image}, output)
+}
+
+func cosignSignArgs(image, key string) []string {
+	args := []string{"sign", "--yes"}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	return append(args, image)
+}
+
+func cosignAttestArgs(image, key, sbomPath, format string) []string {
+	predicateType := "spdxjson"
+	if format == SBOMCycloneDX {
+		predicateType = "cyclonedx"
+	}
+	args := []string{"attest", "--yes", "--predicate", sbomPath, "--type", predicateType}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	return append(args, image)
+}
+
+func runCosign(args []string, output io.Writer) error {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return fmt.Errorf("cosign is needed to sign images and attach SBOMs, install it from https://github.com/sigstore/cosign")
+	}
+	cmd := exec.Command("cosign", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running cosign %s: %v", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package langs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeSBOMs(t *testing.T) {
+	image := []byte(`{"spdxVersion": "SPDX-2.3",
+		"packages": [{"SPDXID": "SPDXRef-musl", "name": "musl"}, {"SPDXID": "SPDXRef-jackson", "name": "jackson"}],
+		"relationships": [{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-musl"}]}`)
+	deps := []byte(`{"spdxVersion": "SPDX-2.3",
+		"packages": [{"SPDXID": "SPDXRef-jackson", "name": "jackson"}, {"SPDXID": "SPDXRef-fdk", "name": "api"}],
+		"relationships": [{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-musl"},
+			{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-fdk"}]}`)
+
+	merged, err := mergeSBOMs(SBOMSPDX, image, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			Name string `json:"name"`
+		} `json:"packages"`
+		Relationships []interface{} `json:"relationships"`
+	}
+	if err := json.Unmarshal(merged, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range doc.Packages {
+		names = append(names, p.Name)
+	}
+	if expected := []string{"musl", "jackson", "api"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected packages %v, got %v", expected, names)
+	}
+	if len(doc.Relationships) != 2 {
+		t.Errorf("expected the duplicate relationship to be left out, got %v", doc.Relationships)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" {
+		t.Errorf("expected the image document to be kept, got version %q", doc.SPDXVersion)
+	}
+}
+
+func TestCosignArgs(t *testing.T) {
+	image := "fnproject/hello@sha256:" + strings.Repeat("a", 64)
+	if args, expected := cosignSignArgs(image, ""), []string{"sign", "--yes", image}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected keyless signing with %v, got %v", expected, args)
+	}
+	args := cosignAttestArgs(image, "cosign.key", "sbom.json", SBOMCycloneDX)
+	expected := []string{"attest", "--yes", "--predicate", "sbom.json", "--type", "cyclonedx", "--key", "cosign.key", image}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestPushedImage(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	for _, out := range []string{
+		digest + "\n",
+		"localhost:5000/fnproject/hello@sha256:" + strings.Repeat("b", 64) + "\nregistry.example.com:5000/fnproject/hello@" + digest + "\n",
+	} {
+		image, err := pushedImage("registry.example.com:5000/fnproject/hello:0.0.2", out)
+		if expected := "registry.example.com:5000/fnproject/hello@" + digest; err != nil || image != expected {
+			t.Errorf("expected %s from %q, got %q, %v", expected, out, image, err)
+		}
+	}
+	if _, err := pushedImage("fnproject/hello:0.0.2", "other/hello@"+digest+"\n"); err == nil {
+		t.Error("expected the digests of other repositories to be ignored")
+	}
+}
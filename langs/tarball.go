@@ -0,0 +1,114 @@
+package langs
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TarballBuild is a function source extracted from a tarball, ready to be built.
+type TarballBuild struct {
+	// Dir is the temporary directory holding the extracted source. Callers remove it once done.
+	Dir string
+	// BuildCmds and CopyCmds are the helper's Dockerfile commands for the extracted source.
+	BuildCmds []string
+	CopyCmds  []string
+}
+
+// PrepareFromTarball extracts a (optionally gzipped) source tarball into a temporary directory, runs the helper's
+// pre-build step against it and collects the Dockerfile commands, as if the function had been built from that
+// directory. Entries escaping the extraction directory are rejected.
+func PrepareFromTarball(lh LangHelper, r io.Reader) (*TarballBuild, error) {
+	dir, err := ioutil.TempDir("", "fn-build")
+	if err != nil {
+		return nil, err
+	}
+	if err := extractTarball(r, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	// helpers work against the current working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	defer os.Chdir(wd)
+
+	if lh.HasPreBuild() {
+		if err := lh.PreBuild(); err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+	}
+
+	return &TarballBuild{
+		Dir:       dir,
+		BuildCmds: lh.DockerfileBuildCmds(),
+		CopyCmds:  lh.DockerfileCopyCmds(),
+	}, nil
+}
+
+func extractTarball(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read source tarball: %v", err)
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("source tarball entry %q is outside of the function directory", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(0755)); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), os.FileMode(0755)); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("source tarball entry %q is not a regular file or directory", hdr.Name)
+		}
+	}
+}
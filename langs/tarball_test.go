@@ -0,0 +1,63 @@
+package langs
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tarball(t *testing.T, files map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestPrepareFromTarball(t *testing.T) {
+	tb, err := PrepareFromTarball(&RustLangHelper{}, tarball(t, map[string]string{
+		"Cargo.toml":  cargoTomlContent("test", "0.1.0"),
+		"src/main.rs": mainContent(),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tb.Dir)
+
+	if !exists(filepath.Join(tb.Dir, "src", "main.rs")) {
+		t.Error("expected the source to be extracted")
+	}
+	if len(tb.BuildCmds) == 0 || len(tb.CopyCmds) == 0 {
+		t.Errorf("expected Dockerfile commands, got %+v", tb)
+	}
+}
+
+func TestPrepareFromTarballRunsPreBuild(t *testing.T) {
+	_, err := PrepareFromTarball(&RustLangHelper{}, tarball(t, map[string]string{
+		"src/main.rs": mainContent(),
+	}))
+	if err == nil {
+		t.Error("expected the pre-build check for Cargo.toml to fail")
+	}
+}
+
+func TestPrepareFromTarballRejectsTraversal(t *testing.T) {
+	_, err := PrepareFromTarball(&RustLangHelper{}, tarball(t, map[string]string{
+		"../Cargo.toml": cargoTomlContent("test", "0.1.0"),
+	}))
+	if err == nil {
+		t.Error("expected an entry outside of the function directory to be rejected")
+	}
+}
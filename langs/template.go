@@ -0,0 +1,429 @@
+package langs
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ociTemplateScheme prefixes the URL of template sources published as OCI artifacts, such as
+// oci://ghcr.io/acme/fn-templates:1, rather than git repositories.
+const ociTemplateScheme = "oci://"
+
+// templateFileSuffix marks the template files rendered with the TemplateVars, others are copied as they are.
+const templateFileSuffix = ".tmpl"
+
+var templateSourceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// TemplateSource is a repository of function templates, a git repository or an OCI artifact holding a directory
+// per runtime with a directory per template in it, such as java/http-json.
+type TemplateSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// FDKVersioner is implemented by helpers whose boilerplate depends on an FDK version, which templates can use.
+type FDKVersioner interface {
+	FDKVersion(ctx context.Context) (string, error)
+}
+
+// TemplateVars are what templates are rendered with. File names are rendered too, so a template can put sources
+// under {{.PackagePath}}.
+type TemplateVars struct {
+	// Name is the function name.
+	Name    string
+	Runtime string
+	// Package is the package, or group, sources go in, such as com.example.fn.
+	Package string
+
+	ctx    context.Context
+	helper LangHelper
+}
+
+// NewTemplateVars returns the variables of a template for the function name in package, resolving the FDK version
+// with helper, if it has one, when the template uses it.
+func NewTemplateVars(ctx context.Context, helper LangHelper, name, runtime, pkg string) *TemplateVars {
+	return &TemplateVars{Name: name, Runtime: runtime, Package: pkg, ctx: ctx, helper: helper}
+}
+
+// PackagePath is the package as a slash separated path, com/example/fn for com.example.fn.
+func (v *TemplateVars) PackagePath() string { return strings.Replace(v.Package, ".", "/", -1) }
+
+// FDKVersion is the version of the runtime's FDK, looked up the way the helper's own boilerplate does.
+func (v *TemplateVars) FDKVersion() (string, error) {
+	versioner, ok := v.helper.(FDKVersioner)
+	if !ok {
+		return "", fmt.Errorf("the %s runtime has no FDK version to render templates with", v.Runtime)
+	}
+	return versioner.FDKVersion(v.ctx)
+}
+
+// templateSourcesFile returns where the template sources registered with fn templates add are kept.
+var templateSourcesFile = func() string {
+	return StateFile("template-sources.json")
+}
+
+// templateCacheDir returns where template sources are fetched to, a directory per source.
+var templateCacheDir = func() string {
+	return StateFile("templates")
+}
+
+// TemplateSources returns the registered template sources, in the order templates are looked up in them.
+func TemplateSources() ([]TemplateSource, error) {
+	var sources []TemplateSource
+	b, err := ioutil.ReadFile(templateSourcesFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &sources); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", templateSourcesFile(), err)
+	}
+	return sources, nil
+}
+
+func writeTemplateSources(sources []TemplateSource) error {
+	path := templateSourcesFile()
+	if path == "" {
+		return fmt.Errorf("could not find the home directory to keep template sources in")
+	}
+	b, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, os.FileMode(0644))
+}
+
+// AddTemplateSource registers a template source, after the ones already registered. The URL is a git repository,
+// or an OCI artifact prefixed with oci://.
+func AddTemplateSource(name, url string) error {
+	if !templateSourceNamePattern.MatchString(name) {
+		return fmt.Errorf("%q is not a valid template source name, use letters, digits, '.', '_' and '-'", name)
+	}
+	if url == "" {
+		return fmt.Errorf("the template source %s needs a URL", name)
+	}
+	sources, err := TemplateSources()
+	if err != nil {
+		return err
+	}
+	for _, s := range sources {
+		if s.Name == name {
+			return fmt.Errorf("template source %s already exists, remove it first", name)
+		}
+	}
+	return writeTemplateSources(append(sources, TemplateSource{Name: name, URL: url}))
+}
+
+// RemoveTemplateSource unregisters a template source and drops what was fetched of it.
+func RemoveTemplateSource(name string) error {
+	sources, err := TemplateSources()
+	if err != nil {
+		return err
+	}
+	for i, s := range sources {
+		if s.Name == name {
+			if err := writeTemplateSources(append(sources[:i], sources[i+1:]...)); err != nil {
+				return err
+			}
+			return os.RemoveAll(filepath.Join(templateCacheDir(), name))
+		}
+	}
+	return fmt.Errorf("template source %s does not exist", name)
+}
+
+// GenerateFromTemplate generates the boilerplate of a function from a template of runtime, given by name or as
+// source/name, rendered with vars. Like the helpers' boilerplate it fails without writing anything if any of the
+// files already exists, unless overwrite is set.
+func GenerateFromTemplate(ctx context.Context, runtime, name string, vars *TemplateVars, overwrite bool) error {
+	wd, err := FunctionDir()
+	if err != nil {
+		return err
+	}
+	dir, err := findTemplate(ctx, runtime, name)
+	if err != nil {
+		return err
+	}
+	files, err := renderTemplate(dir, vars)
+	if err != nil {
+		return err
+	}
+	if !overwrite {
+		if err := checkBoilerplate(wd, files); err != nil {
+			return err
+		}
+	}
+	return writeBoilerplate(wd, files)
+}
+
+// findTemplate fetches the template sources in turn until one has the template, returning its directory.
+func findTemplate(ctx context.Context, runtime, name string) (string, error) {
+	sources, err := TemplateSources()
+	if err != nil {
+		return "", err
+	}
+	if len(sources) == 0 {
+		return "", fmt.Errorf("no template sources are registered, add one with fn templates add")
+	}
+	sourceName := ""
+	if i := strings.Index(name, "/"); i >= 0 {
+		sourceName, name = name[:i], name[i+1:]
+	}
+	if name == "" || strings.Contains(name, "..") {
+		return "", fmt.Errorf("%q is not a valid template name", name)
+	}
+	for _, s := range sources {
+		if sourceName != "" && s.Name != sourceName {
+			continue
+		}
+		root, err := fetchTemplateSource(ctx, s)
+		if err != nil {
+			return "", err
+		}
+		dir := filepath.Join(root, runtime, name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		if sourceName != "" {
+			return "", fmt.Errorf("template source %s has no %s template for the %s runtime", sourceName, name, runtime)
+		}
+	}
+	if sourceName != "" {
+		return "", fmt.Errorf("template source %s does not exist", sourceName)
+	}
+	return "", fmt.Errorf("none of the template sources has a %s template for the %s runtime", name, runtime)
+}
+
+// fetchTemplateSource brings the copy of a template source up to date, returning its directory. Offline, or when
+// the source can't be reached, the copy fetched before is used.
+func fetchTemplateSource(ctx context.Context, s TemplateSource) (string, error) {
+	cacheDir := templateCacheDir()
+	if cacheDir == "" {
+		return "", fmt.Errorf("could not find the home directory to fetch templates to")
+	}
+	dir := filepath.Join(cacheDir, s.Name)
+	fetched := exists(dir)
+	if envEnabled(offlineEnv) {
+		if !fetched {
+			return "", fmt.Errorf("template source %s has not been fetched before, which %s requires", s.Name, offlineEnv)
+		}
+		return dir, nil
+	}
+
+	var err error
+	if strings.HasPrefix(s.URL, ociTemplateScheme) {
+		err = pullTemplateArtifact(ctx, strings.TrimPrefix(s.URL, ociTemplateScheme), dir)
+	} else {
+		err = cloneTemplateRepository(ctx, s.URL, dir)
+	}
+	if err != nil {
+		if !fetched || ctx.Err() != nil {
+			return "", fmt.Errorf("could not fetch template source %s: %v", s.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not fetch template source %s: %v, using the copy fetched before\n", s.Name, err)
+	}
+	return dir, nil
+}
+
+// cloneTemplateRepository clones a git repository of templates to dir, or pulls it if it was cloned before.
+func cloneTemplateRepository(ctx context.Context, url, dir string) error {
+	var cmd *exec.Cmd
+	if exists(filepath.Join(dir, ".git")) {
+		cmd = exec.CommandContext(ctx, "git", "-C", dir, "pull", "--ff-only", "--quiet")
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dir), os.FileMode(0755)); err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--quiet", "--", url, dir)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pullTemplateArtifact pulls an OCI artifact of templates, extracting its tar layers to dir in place of what was
+// pulled before.
+func pullTemplateArtifact(ctx context.Context, image, dir string) error {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return err
+	}
+	reg := &registryClient{ref: ref}
+	manifest, err := reg.manifest(ctx, dockerlessDefaultPlatform)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), os.FileMode(0755)); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), "."+filepath.Base(dir))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, layer := range manifest.Layers {
+		b, err := reg.blobBytes(ctx, layer)
+		if err != nil {
+			return err
+		}
+		if err := extractTemplateLayer(b, tmp); err != nil {
+			return fmt.Errorf("could not extract %s: %v", layer.Digest, err)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// extractTemplateLayer extracts the regular files and directories of a tar layer, gzipped or not, to dir.
+func extractTemplateLayer(layer []byte, dir string) error {
+	var r io.Reader = bytes.NewReader(layer)
+	if len(layer) > 1 && layer[0] == 0x1f && layer[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(bufio.NewReader(r))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean("/" + hdr.Name)[1:]
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(0755)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), os.FileMode(0755)); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// renderTemplate returns the files of the template in dir keyed by their slash separated path, with the names of
+// all of them and the content of the .tmpl ones rendered with vars.
+func renderTemplate(dir string, vars *TemplateVars) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		// a cloned template could otherwise link to any file of the user
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("the template file %s is a symbolic link, which templates can't have", rel)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		name, err := renderTemplateString(rel, filepath.ToSlash(rel), vars)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		content := string(b)
+		if strings.HasSuffix(name, templateFileSuffix) {
+			name = strings.TrimSuffix(name, templateFileSuffix)
+			if content, err = renderTemplateString(rel, content, vars); err != nil {
+				return err
+			}
+		}
+		if name = path.Clean("/" + name)[1:]; name == "" {
+			return fmt.Errorf("the template file %s renders to an empty name", rel)
+		}
+		files[name] = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the template in %s has no files", dir)
+	}
+	return files, nil
+}
+
+func renderTemplateString(file, text string, vars *TemplateVars) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := template.New(file).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the template file %s: %v", file, err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("could not render the template file %s: %v", file, err)
+	}
+	return b.String(), nil
+}
+
+// TemplatesOf lists the templates the fetched copy of a source has, as runtime/name, for fn templates list.
+func TemplatesOf(s TemplateSource) []string {
+	root := filepath.Join(templateCacheDir(), s.Name)
+	var templates []string
+	runtimes, _ := ioutil.ReadDir(root)
+	for _, rt := range runtimes {
+		if !rt.IsDir() || strings.HasPrefix(rt.Name(), ".") {
+			continue
+		}
+		names, _ := ioutil.ReadDir(filepath.Join(root, rt.Name()))
+		for _, n := range names {
+			if n.IsDir() && !strings.HasPrefix(n.Name(), ".") {
+				templates = append(templates, rt.Name()+"/"+n.Name())
+			}
+		}
+	}
+	sort.Strings(templates)
+	return templates
+}
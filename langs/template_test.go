@@ -0,0 +1,208 @@
+package langs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withTemplateDirs keeps the template sources and their copies of a test in a temporary directory
+func withTemplateDirs(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourcesFile, cacheDir := templateSourcesFile, templateCacheDir
+	templateSourcesFile = func() string { return filepath.Join(dir, "template-sources.json") }
+	templateCacheDir = func() string { return filepath.Join(dir, "templates") }
+	return func() {
+		templateSourcesFile, templateCacheDir = sourcesFile, cacheDir
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAddRemoveTemplateSource(t *testing.T) {
+	defer withTemplateDirs(t)()
+
+	if err := AddTemplateSource("acme", "https://git.example.com/acme/fn-templates.git"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddTemplateSource("oci", "oci://ghcr.io/acme/fn-templates:1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddTemplateSource("acme", "https://git.example.com/other.git"); err == nil {
+		t.Error("expected a source name to be registered once")
+	}
+	if err := AddTemplateSource("../acme", "https://git.example.com/other.git"); err == nil {
+		t.Error("expected source names to be checked, they name directories")
+	}
+	if err := RemoveTemplateSource("acme"); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := TemplateSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Name != "oci" {
+		t.Errorf("expected only the oci source to be left, got %+v", sources)
+	}
+}
+
+func TestGenerateFromTemplate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	defer withTemplateDirs(t)()
+	os.Setenv("FN_JAVA_FDK_VERSION", "1.0.99")
+	defer os.Unsetenv("FN_JAVA_FDK_VERSION")
+
+	repo, err := ioutil.TempDir("", "template-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+	files := map[string]string{
+		"java/http-json/pom.xml.tmpl":                                     "<artifactId>{{.Name}}</artifactId><version>{{.FDKVersion}}</version>\n",
+		"java/http-json/src/main/java/{{.PackagePath}}/Handler.java.tmpl": "package {{.Package}};\n",
+		"java/http-json/README.md":                                        "Run with {{ fn invoke }}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=fn", "-c", "user.email=fn@example.com", "commit", "--quiet", "-m", "templates"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := AddTemplateSource("acme", repo); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	vars := NewTemplateVars(context.Background(), &JavaLangHelper{}, "hello", "java", "org.acme.fn")
+	if err := GenerateFromTemplate(context.Background(), "java", "acme/http-json", vars, false); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"pom.xml":                                "<artifactId>hello</artifactId><version>1.0.99</version>",
+		"src/main/java/org/acme/fn/Handler.java": "package org.acme.fn;",
+		"README.md":                              "Run with {{ fn invoke }}",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(tmp, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s to contain %q, got %q", name, expected, b)
+		}
+	}
+
+	err = GenerateFromTemplate(context.Background(), "java", "http-json", vars, false)
+	if be, ok := err.(*BuildError); !ok || be.Category != ErrBoilerplateExists {
+		t.Errorf("expected generating over the files to fail, got %v", err)
+	}
+
+	os.Setenv(offlineEnv, "true")
+	defer os.Unsetenv(offlineEnv)
+	if err := GenerateFromTemplate(context.Background(), "java", "http-json", vars, true); err != nil {
+		t.Errorf("expected offline generation to use the copy fetched before, got %v", err)
+	}
+	if err := GenerateFromTemplate(context.Background(), "java", "http-xml", vars, true); err == nil {
+		t.Error("expected a missing template to fail")
+	}
+}
+
+func TestPullTemplateArtifact(t *testing.T) {
+	layer := gzipped(t, map[string]string{
+		"go/http/func.go.tmpl": "package main\n",
+		"../escape":            "outside\n",
+	})
+	manifest, _ := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeOCIManifest,
+		Config:        ociDescriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: digestOf([]byte("{}")), Size: 2},
+		Layers:        []ociDescriptor{{MediaType: mediaTypeOCILayer, Digest: digestOf(layer), Size: int64(len(layer))}},
+	})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/acme/fn-templates/manifests/1":
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Write(manifest)
+		case "/v2/acme/fn-templates/blobs/" + digestOf(layer):
+			w.Write(layer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(client *http.Client) { registryHTTPClient = client }(registryHTTPClient)
+	registryHTTPClient = server.Client()
+
+	dir, err := ioutil.TempDir("", "template-artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	image := strings.TrimPrefix(server.URL, "https://") + "/acme/fn-templates:1"
+	if err := pullTemplateArtifact(context.Background(), image, filepath.Join(dir, "acme")); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(dir, "acme", "go", "http", "func.go.tmpl")) {
+		t.Error("expected the template to be extracted")
+	}
+	if exists(filepath.Join(dir, "escape")) {
+		t.Error("expected paths out of the source to be kept in it")
+	}
+}
+
+func TestRenderTemplateRejectsSymlinks(t *testing.T) {
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(filepath.Join(tmp, "func.yaml"), []byte("name: {{.Name}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renderTemplate(tmp, &TemplateVars{Name: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(tmp, "passwd")); err != nil {
+		t.Skipf("can't create symbolic links: %v", err)
+	}
+	if _, err := renderTemplate(tmp, &TemplateVars{Name: "hello"}); err == nil {
+		t.Error("expected a symbolic link to be rejected")
+	}
+}
+
+func TestCloneTemplateRepositoryOptionURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmp, cleanup := chdirTemp(t)
+	defer cleanup()
+	marker := filepath.Join(tmp, "uploaded")
+	url := "--upload-pack=touch " + marker
+	// git reports the url as the repository it can't find, rather than taking it as an option
+	err := cloneTemplateRepository(context.Background(), url, filepath.Join(tmp, "clone"))
+	if err == nil || !strings.Contains(err.Error(), url) || exists(marker) {
+		t.Errorf("expected the url not to be taken as an option, got %v", err)
+	}
+}
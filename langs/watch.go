@@ -0,0 +1,216 @@
+package langs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ignoreFiles list the patterns of files left out of the image, and so unwatched by fn develop: .dockerignore as
+// Docker reads it and .fnignore for the files only fn should leave alone.
+var ignoreFiles = []string{".dockerignore", ".fnignore"}
+
+type ignorePattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// IgnoreMatcher tells the files of a function directory matched by the patterns of its .dockerignore and .fnignore,
+// with the syntax of .dockerignore: * and ? within a path component, ** across them and ! to take files back.
+type IgnoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// LoadIgnoreMatcher reads the ignore files of dir, missing ones match nothing.
+func LoadIgnoreMatcher(dir string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{}
+	for _, name := range ignoreFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			m.add(scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *IgnoreMatcher) add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	p := ignorePattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate, line = true, strings.TrimSpace(line[1:])
+	}
+	line = strings.Trim(filepath.ToSlash(filepath.Clean(line)), "/")
+	if line == "" || line == "." {
+		return
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '*' && i+1 < len(line) && line[i+1] == '*':
+			re.WriteString(".*")
+			i++
+			// **/ also matches no directory at all
+			if i+1 < len(line) && line[i+1] == '/' {
+				re.WriteString("/?")
+				i++
+			}
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	if compiled, err := regexp.Compile(re.String()); err == nil {
+		p.re = compiled
+		m.patterns = append(m.patterns, p)
+	}
+}
+
+// Ignored reports whether the file at the slash separated path relative to the function directory, or a directory
+// it is in, is matched, the last pattern matching deciding.
+func (m *IgnoreMatcher) Ignored(path string) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if matchesPathOrParent(p.re, path) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func matchesPathOrParent(re *regexp.Regexp, path string) bool {
+	for {
+		if re.MatchString(path) {
+			return true
+		}
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a function directory for changes to the files that aren't hidden or ignored.
+type Watcher struct {
+	dir   string
+	files map[string]fileState
+}
+
+// NewWatcher starts watching dir from the files it has now.
+func NewWatcher(dir string) (*Watcher, error) {
+	w := &Watcher{dir: dir}
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.files = files
+	return w, nil
+}
+
+// Changes returns the files, slash separated and relative to the directory, added, modified or removed since the
+// previous call, sorted.
+func (w *Watcher) Changes() ([]string, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, state := range files {
+		if previous, ok := w.files[path]; !ok || previous != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.files = files
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// Reset takes the files as they are now as unchanged, for changes made by fn itself, such as version bumps.
+func (w *Watcher) Reset() error {
+	files, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.files = files
+	return nil
+}
+
+func (w *Watcher) scan() (map[string]fileState, error) {
+	// reloaded each time so editing them takes effect
+	ignore, err := LoadIgnoreMatcher(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]fileState{}
+	err = filepath.Walk(w.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files removed while walking are picked up by the next scan
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == w.dir {
+			return nil
+		}
+		rel, err := filepath.Rel(w.dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(info.Name(), ".") || ignore.Ignored(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// changesAny reports whether any of the changed files is one of names.
+func changesAny(changed []string, names ...string) bool {
+	for _, name := range names {
+		if containsString(changed, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package langs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := &IgnoreMatcher{}
+	for _, line := range []string{"# comment", "node_modules", "*.log", "!keep.log", "docs/**/*.md", "/build/"} {
+		m.add(line)
+	}
+	for path, expected := range map[string]bool{
+		"func.js":                    false,
+		"node_modules/left-pad/x.js": true,
+		"debug.log":                  true,
+		"keep.log":                   false,
+		"logs/debug.log":             false,
+		"docs/README.md":             true,
+		"docs/api/calls.md":          true,
+		"docs/api/calls.txt":         false,
+		"build/out.js":               true,
+	} {
+		if m.Ignored(path) != expected {
+			t.Errorf("expected %s to be ignored: %v", path, expected)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("func.js", "v1")
+	write("lib/util.js", "v1")
+	write(".fnignore", "tmp\n")
+
+	w, err := NewWatcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("func.js", "v2 is longer")
+	write("tmp/scratch.js", "ignored")
+	write(".git/HEAD", "hidden")
+	os.Remove(filepath.Join(dir, "lib", "util.js"))
+
+	changed, err := w.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"func.js", "lib/util.js"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changed)
+	}
+	if changed, _ := w.Changes(); len(changed) != 0 {
+		t.Errorf("expected no changes since the previous call, got %v", changed)
+	}
+}
+
+func TestHotReloads(t *testing.T) {
+	lh := &NodeLangHelper{}
+	if !lh.HotReloads([]string{"func.js", "lib/util.js"}) {
+		t.Error("expected source changes to be copied onto the image")
+	}
+	if lh.HotReloads([]string{"func.js", "package.json"}) {
+		t.Error("expected dependency changes to rebuild the image")
+	}
+	if (&GoLangHelper{}).HotReloads([]string{"func.go"}) {
+		t.Error("expected compiled runtimes to rebuild the image")
+	}
+}
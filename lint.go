@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The route limits of the functions server, which it rejects routes beyond.
+const (
+	minMemory       = 64
+	maxSyncTimeout  = 120
+	maxAsyncTimeout = 3600
+	maxIdleTimeout  = 3600
+)
+
+func lint() cli.Command {
+	return cli.Command{
+		Name:  "lint",
+		Usage: "check the function in the current directory for problems before building it",
+		Description: "Checks func.yaml, its route settings and entrypoint, and the project of the runtime, such as " +
+			"the files the build needs and the FDK version it depends on, reporting all the problems found.",
+		Action: runLint,
+	}
+}
+
+func runLint(c *cli.Context) error {
+	issues := lintFunction(getWd())
+
+	if issues == nil {
+		issues = []langs.LintIssue{}
+	}
+	err := render(c, struct {
+		Issues []langs.LintIssue `json:"issues"`
+	}{issues}, func() error {
+		for _, issue := range issues {
+			if issue.File != "" {
+				fmt.Printf("%s: %s: %s\n", issue.Severity, issue.File, issue.Message)
+			} else {
+				fmt.Printf("%s: %s\n", issue.Severity, issue.Message)
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Println("No problems found.")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	errs := 0
+	for _, issue := range issues {
+		if issue.Severity == langs.LintError {
+			errs++
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("%d error(s) found", errs)
+	}
+	return nil
+}
+
+// lintFunction checks the function in dir, returning the issues found.
+func lintFunction(dir string) []langs.LintIssue {
+	fpath, err := findFuncfile(dir)
+	if err != nil {
+		return []langs.LintIssue{{Severity: langs.LintError, File: "func.yaml", Message: "func.yaml is missing, create it with fn init"}}
+	}
+	file := filepath.Base(fpath)
+	issues, err := lintFuncfileKeys(fpath)
+	if err != nil {
+		return append(issues, langs.LintIssue{Severity: langs.LintError, File: file, Message: fmt.Sprintf("could not parse %s: %v", file, err)})
+	}
+	ff, err := parseFuncfile(fpath)
+	if err != nil {
+		return append(issues, langs.LintIssue{Severity: langs.LintError, File: file, Message: fmt.Sprintf("could not parse %s: %v", file, err)})
+	}
+	issues = append(issues, lintFuncfile(dir, file, ff)...)
+	if _, err := langs.LoadDockerfileExtensions(dir, ff.BuildExtra); err != nil {
+		issues = append(issues, langs.LintIssue{Severity: langs.LintError, Message: err.Error()})
+	}
+
+	if ff.Runtime == "" || ff.Runtime == funcfileDockerRuntime {
+		return issues
+	}
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil {
+		return issues
+	}
+	return append(issues, helper.Validate(dir)...)
+}
+
+// lintFuncfileKeys reports the keys of a func.yaml which aren't func.yaml settings, and so are ignored, such as
+// misspelt ones.
+func lintFuncfileKeys(fpath string) ([]langs.LintIssue, error) {
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	if filepath.Ext(fpath) == ".json" {
+		err = json.Unmarshal(b, &raw)
+	} else {
+		err = yaml.Unmarshal(b, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	t := reflect.TypeOf(funcfile{})
+	for i := 0; i < t.NumField(); i++ {
+		known[strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]] = true
+	}
+	var unknown []string
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	var issues []langs.LintIssue
+	for _, key := range unknown {
+		issues = append(issues, langs.LintIssue{
+			Severity: langs.LintWarning,
+			File:     filepath.Base(fpath),
+			Message:  fmt.Sprintf("%s is not a func.yaml setting and is ignored", key),
+		})
+	}
+	return issues, nil
+}
+
+// lintFuncfile checks the settings of the func.yaml, named file, of the function in dir.
+func lintFuncfile(dir, file string, ff *funcfile) []langs.LintIssue {
+	var issues []langs.LintIssue
+	report := func(severity, format string, args ...interface{}) {
+		issues = append(issues, langs.LintIssue{Severity: severity, File: file, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.Contains(ff.Name, ":") {
+		report(langs.LintError, "name %q cannot contain a colon", ff.Name)
+	}
+	if ff.Version != "" {
+		if _, err := semver.NewVersion(ff.Version); err != nil {
+			report(langs.LintError, "version %q is not a semantic version, fn bump can't bump it", ff.Version)
+		}
+	}
+	if ff.Path != "" && !strings.HasPrefix(ff.Path, "/") {
+		report(langs.LintError, "path %q must start with /", ff.Path)
+	}
+
+	hasDockerfile := exists(filepath.Join(dir, "Dockerfile"))
+	switch {
+	case ff.Runtime == "":
+		report(langs.LintError, "runtime is missing, set it to one of %s", strings.Join(langs.SupportedRuntimes(), ", "))
+	case ff.Runtime == funcfileDockerRuntime:
+		if !hasDockerfile {
+			report(langs.LintError, "runtime is docker, but there is no Dockerfile")
+		}
+		if ff.Entrypoint != "" || ff.Cmd != "" {
+			report(langs.LintWarning, "entrypoint and cmd are ignored with the docker runtime, the Dockerfile sets them")
+		}
+	default:
+		helper, err := langs.GetLangHelper(ff.Runtime)
+		if err != nil {
+			report(langs.LintError, "runtime %s is not supported, use one of %s", ff.Runtime, strings.Join(langs.SupportedRuntimes(), ", "))
+			break
+		}
+		if hasDockerfile {
+			report(langs.LintWarning, "the Dockerfile is built rather than one generated for the %s runtime", ff.Runtime)
+		}
+		if err := langs.CheckBuildMode(helper, ff.Runtime, ff.BuildMode); err != nil {
+			report(langs.LintError, "%v", err)
+		}
+		issues = append(issues, lintEntrypoint(file, ff, helper)...)
+	}
+
+	switch ff.Format {
+	case "", DefaultFormat, HttpFormat:
+	default:
+		report(langs.LintError, "format %q is not one of %s or %s", ff.Format, DefaultFormat, HttpFormat)
+	}
+	maxTimeout := maxSyncTimeout
+	switch ff.Type {
+	case "", "sync":
+	case "async":
+		maxTimeout = maxAsyncTimeout
+	default:
+		report(langs.LintError, "type %q is not one of sync or async", ff.Type)
+	}
+	if ff.Memory != 0 && ff.Memory < minMemory {
+		report(langs.LintError, "memory %d MB is below the minimum of %d MB", ff.Memory, minMemory)
+	}
+	if ff.Timeout != nil && (*ff.Timeout <= 0 || int(*ff.Timeout) > maxTimeout) {
+		report(langs.LintError, "timeout %d must be between 1 and %d seconds for %s calls", *ff.Timeout, maxTimeout, routeType(ff))
+	}
+	if ff.IDLETimeout != nil && (*ff.IDLETimeout <= 0 || *ff.IDLETimeout > maxIdleTimeout) {
+		report(langs.LintError, "idle_timeout %d must be between 1 and %d seconds", *ff.IDLETimeout, maxIdleTimeout)
+	}
+	if err := ff.validate(); err != nil {
+		report(langs.LintError, "%v", err)
+	}
+	return issues
+}
+
+// lintEntrypoint checks that the generated Dockerfile has something to run, and that it is what the runtime's image
+// runs functions with.
+func lintEntrypoint(file string, ff *funcfile, helper langs.LangHelper) []langs.LintIssue {
+	if ff.Entrypoint == "" && ff.Cmd == "" {
+		return []langs.LintIssue{{Severity: langs.LintError, File: file, Message: "entrypoint and cmd are missing, you must provide one or the other"}}
+	}
+	expected := strings.Fields(helper.Entrypoint())
+	actual := strings.Fields(ff.Entrypoint)
+	if len(expected) > 0 && len(actual) > 0 && actual[0] != expected[0] {
+		return []langs.LintIssue{{
+			Severity: langs.LintWarning,
+			File:     file,
+			Message:  fmt.Sprintf("entrypoint runs %s, but the %s runtime runs functions with %s", actual[0], ff.Runtime, expected[0]),
+		}}
+	}
+	return nil
+}
+
+func routeType(ff *funcfile) string {
+	if ff.Type == "" {
+		return "sync"
+	}
+	return ff.Type
+}
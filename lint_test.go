@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fnproject/cli/langs"
+)
+
+func lintMessages(issues []langs.LintIssue) map[string]string {
+	messages := map[string]string{}
+	for _, issue := range issues {
+		messages[issue.Message] = issue.Severity
+	}
+	return messages
+}
+
+func TestLintFunction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	funcYAML := `name: hello
+version: 0.0.1
+runtime: node
+entrypoint: nodejs func.js
+format: grpc
+timeout: 600
+memroy: 256
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "func.yaml"), []byte(funcYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := lintMessages(lintFunction(dir))
+	for message, severity := range map[string]string{
+		"memroy is not a func.yaml setting and is ignored":                      langs.LintWarning,
+		`format "grpc" is not one of default or http`:                           langs.LintError,
+		"timeout 600 must be between 1 and 120 seconds for sync calls":          langs.LintError,
+		"entrypoint runs nodejs, but the node runtime runs functions with node": langs.LintWarning,
+		"func.js is missing, the node runtime builds from it":                   langs.LintError,
+	} {
+		if messages[message] != severity {
+			t.Errorf("expected the %s %q, got %v", severity, message, messages)
+		}
+	}
+}
+
+func TestLintFunctionWithoutFuncfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	issues := lintFunction(dir)
+	if len(issues) != 1 || issues[0].Severity != langs.LintError {
+		t.Errorf("expected a missing func.yaml to be reported, got %+v", issues)
+	}
+}
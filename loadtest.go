@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	client "github.com/fnproject/cli/client"
+	"github.com/urfave/cli"
+)
+
+var loadFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "load",
+		Usage: "call the function repeatedly and concurrently, reporting latency, error rate and throughput",
+	},
+	cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "number of calls in flight at once with --load",
+		Value: 10,
+	},
+	cli.DurationFlag{
+		Name:  "duration",
+		Usage: "how long to call the function for with --load",
+		Value: 30 * time.Second,
+	},
+	cli.StringFlag{
+		Name:  "results",
+		Usage: "write the --load results to a .json or .csv file",
+	},
+}
+
+// loadTest calls a function from concurrent workers for a duration, sharing a pool of connections between them.
+type loadTest struct {
+	url         string
+	method      string
+	body        []byte
+	env         []string
+	concurrency int
+	duration    time.Duration
+}
+
+// loadResult is a sample of the calls of a load test.
+type loadResult struct {
+	latency time.Duration
+	err     error
+}
+
+// loadStats summarize a load test, with latencies in milliseconds.
+type loadStats struct {
+	Calls      int     `json:"calls"`
+	Errors     int     `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	Throughput float64 `json:"throughput"`
+	P50        float64 `json:"p50_ms"`
+	P95        float64 `json:"p95_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+	// Duration is how long the calls took, in seconds.
+	Duration float64 `json:"duration_s"`
+	// Concurrency is the number of calls in flight at once.
+	Concurrency int `json:"concurrency"`
+}
+
+func (a *routesCmd) load(c *cli.Context, u string) error {
+	if c.Int("concurrency") < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if c.Duration("duration") <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+	results := c.String("results")
+	switch strings.ToLower(filepath.Ext(results)) {
+	case "", ".json", ".csv":
+	default:
+		return fmt.Errorf("--results must name a .json or .csv file")
+	}
+	var body []byte
+	if content := stdin(); content != nil {
+		var err error
+		if body, err = ioutil.ReadAll(content); err != nil {
+			return err
+		}
+	}
+
+	lt := &loadTest{
+		url:         u,
+		method:      c.String("method"),
+		body:        body,
+		env:         c.StringSlice("e"),
+		concurrency: c.Int("concurrency"),
+		duration:    c.Duration("duration"),
+	}
+	fmt.Fprintf(os.Stderr, "Calling %s from %d workers for %v\n", u, lt.concurrency, lt.duration)
+	samples, elapsed := lt.run()
+	stats := summarize(samples, elapsed, lt.concurrency)
+	printLoadStats(os.Stdout, stats, samples)
+	if results != "" {
+		if err := writeLoadStats(results, stats); err != nil {
+			return err
+		}
+	}
+	if stats.Calls > 0 && stats.Errors == stats.Calls {
+		return fmt.Errorf("every call failed")
+	}
+	return nil
+}
+
+// run calls the function until the duration is up, returning the samples and how long the calls took. A call taking
+// longer than the whole duration times out as an error, so that a hung function cannot hold the test up.
+func (lt *loadTest) run() ([]loadResult, time.Duration) {
+	httpClient := &http.Client{
+		Timeout: lt.duration,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        lt.concurrency,
+			MaxIdleConnsPerHost: lt.concurrency,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	method := lt.method
+	if method == "" {
+		method = "GET"
+		if lt.body != nil {
+			method = "POST"
+		}
+	}
+
+	start := time.Now()
+	deadline := start.Add(lt.duration)
+	var mu sync.Mutex
+	var samples []loadResult
+	var wg sync.WaitGroup
+	for i := 0; i < lt.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []loadResult
+			for time.Now().Before(deadline) {
+				local = append(local, lt.call(httpClient, method))
+			}
+			mu.Lock()
+			samples = append(samples, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return samples, time.Since(start)
+}
+
+func (lt *loadTest) call(httpClient *http.Client, method string) loadResult {
+	var content io.Reader
+	if lt.body != nil {
+		content = bytes.NewReader(lt.body)
+	}
+	req, err := http.NewRequest(method, lt.url, content)
+	if err != nil {
+		return loadResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(lt.env) > 0 {
+		client.EnvAsHeader(req, lt.env)
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return loadResult{latency: time.Since(start), err: err}
+	}
+	// drain the body so the connection goes back to the pool
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	result := loadResult{latency: time.Since(start)}
+	if resp.StatusCode >= 400 {
+		result.err = fmt.Errorf("status %v", resp.StatusCode)
+	}
+	return result
+}
+
+// summarize computes the latency percentiles of the successful calls, and the error rate and throughput of them all.
+func summarize(samples []loadResult, elapsed time.Duration, concurrency int) loadStats {
+	stats := loadStats{Calls: len(samples), Duration: elapsed.Seconds(), Concurrency: concurrency}
+	var latencies []time.Duration
+	for _, s := range samples {
+		if s.err != nil {
+			stats.Errors++
+			continue
+		}
+		latencies = append(latencies, s.latency)
+	}
+	if stats.Calls > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Calls)
+	}
+	if elapsed > 0 {
+		stats.Throughput = float64(stats.Calls) / elapsed.Seconds()
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.P50 = milliseconds(percentile(latencies, 50))
+	stats.P95 = milliseconds(percentile(latencies, 95))
+	stats.P99 = milliseconds(percentile(latencies, 99))
+	if len(latencies) > 0 {
+		stats.Max = milliseconds(latencies[len(latencies)-1])
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func printLoadStats(w io.Writer, stats loadStats, samples []loadResult) {
+	fmt.Fprintf(w, "Calls:       %d in %.1fs\n", stats.Calls, stats.Duration)
+	fmt.Fprintf(w, "Throughput:  %.1f calls/s\n", stats.Throughput)
+	fmt.Fprintf(w, "Errors:      %d (%.2f%%)\n", stats.Errors, stats.ErrorRate*100)
+	fmt.Fprintf(w, "Latency:     p50 %.1fms, p95 %.1fms, p99 %.1fms, max %.1fms\n", stats.P50, stats.P95, stats.P99, stats.Max)
+	// the first error is usually the one worth seeing, they tend to repeat
+	for _, s := range samples {
+		if s.err != nil {
+			fmt.Fprintf(w, "First error: %v\n", s.err)
+			break
+		}
+	}
+}
+
+// writeLoadStats writes the stats to path, as CSV if it ends in .csv and JSON otherwise.
+func writeLoadStats(path string, stats loadStats) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		w := csv.NewWriter(f)
+		w.Write([]string{"calls", "errors", "error_rate", "throughput", "p50_ms", "p95_ms", "p99_ms", "max_ms", "duration_s", "concurrency"})
+		w.Write([]string{
+			strconv.Itoa(stats.Calls),
+			strconv.Itoa(stats.Errors),
+			formatFloat(stats.ErrorRate),
+			formatFloat(stats.Throughput),
+			formatFloat(stats.P50),
+			formatFloat(stats.P95),
+			formatFloat(stats.P99),
+			formatFloat(stats.Max),
+			formatFloat(stats.Duration),
+			strconv.Itoa(stats.Concurrency),
+		})
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
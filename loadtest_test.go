@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for p, expected := range map[int]time.Duration{50: 50 * time.Millisecond, 95: 95 * time.Millisecond, 99: 99 * time.Millisecond} {
+		if actual := percentile(sorted, p); actual != expected {
+			t.Errorf("expected p%d %v, got %v", p, expected, actual)
+		}
+	}
+	if actual := percentile(nil, 50); actual != 0 {
+		t.Errorf("expected no latency without calls, got %v", actual)
+	}
+}
+
+func TestLoadTest(t *testing.T) {
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// every fourth call fails
+		if atomic.AddInt64(&calls, 1)%4 == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	lt := &loadTest{url: srv.URL, body: []byte(`{}`), concurrency: 4, duration: 100 * time.Millisecond}
+	samples, elapsed := lt.run()
+	stats := summarize(samples, elapsed, lt.concurrency)
+	if stats.Calls == 0 || int64(stats.Calls) != atomic.LoadInt64(&calls) {
+		t.Fatalf("expected a sample per call, got %d samples of %d calls", stats.Calls, calls)
+	}
+	if stats.Errors != stats.Calls/4 {
+		t.Errorf("expected %d errors, got %d", stats.Calls/4, stats.Errors)
+	}
+	if stats.Throughput <= 0 || stats.P50 > stats.P99 || stats.P99 > stats.Max {
+		t.Errorf("expected consistent stats, got %+v", stats)
+	}
+}
+
+func TestLoadTestTimesOutHungCalls(t *testing.T) {
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer srv.Close()
+	defer close(hung)
+
+	lt := &loadTest{url: srv.URL, concurrency: 1, duration: 100 * time.Millisecond}
+	samples, elapsed := lt.run()
+	if elapsed > time.Second {
+		t.Errorf("expected the hung call to time out, the test took %v", elapsed)
+	}
+	if stats := summarize(samples, elapsed, lt.concurrency); stats.Calls != 1 || stats.Errors != 1 {
+		t.Errorf("expected the hung call to count as an error, got %+v", stats)
+	}
+}
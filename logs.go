@@ -18,8 +18,11 @@ func logs() cli.Command {
 	c := logsCmd{client: client.APIClient()}
 
 	return cli.Command{
-		Name:  "logs",
-		Usage: "manage function calls for apps",
+		Name:      "logs",
+		Usage:     "stream the logs of a route, or get the log of a function call",
+		ArgsUsage: "<app> <route>",
+		Action:    c.tail,
+		Flags:     logsTailFlags,
 		Subcommands: []cli.Command{
 			{
 				Name:      "get",
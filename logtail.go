@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	fnclient "github.com/funcy/functions_go/client"
+	apicall "github.com/funcy/functions_go/client/call"
+	apiops "github.com/funcy/functions_go/client/operations"
+	"github.com/urfave/cli"
+)
+
+// The log providers fn logs reads from.
+const (
+	logProviderFn  = "fn"
+	logProviderOCI = "oci"
+)
+
+// followOverlap is how far back each poll of fn logs --follow looks again, for entries the provider indexes late
+const followOverlap = 5 * time.Minute
+
+// severities ranks the log severities, for --severity to show a severity and the ones above it
+var severities = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+var logsTailFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "follow, f",
+		Usage: "keep streaming the logs of new invocations",
+	},
+	cli.StringFlag{
+		Name:  "since",
+		Usage: "show logs from this time on, a duration ago such as 30m or an RFC 3339 time",
+		Value: "1h",
+	},
+	cli.StringFlag{
+		Name:  "until",
+		Usage: "show logs up to this time, a duration ago such as 5m or an RFC 3339 time",
+	},
+	cli.StringFlag{
+		Name:  "call-id",
+		Usage: "only show the logs of this invocation",
+	},
+	cli.StringFlag{
+		Name:  "severity",
+		Usage: "only show logs of this severity or above - debug, info, warn or error",
+	},
+	cli.StringFlag{
+		Name:  "provider",
+		Usage: "where to read the logs from - fn, the call logs of the functions server, or oci, OCI Logging",
+		Value: logProviderFn,
+	},
+	cli.StringFlag{
+		Name:  "oci-log",
+		Usage: "OCI Logging scope of the function's logs, <compartment-ocid>/<log-group-ocid>/<log-ocid>, for --provider oci",
+	},
+	cli.DurationFlag{
+		Name:  "interval",
+		Usage: "how often --follow polls for new logs",
+		Value: 2 * time.Second,
+	},
+	cli.StringFlag{
+		Name:  "output",
+		Usage: "output format - table (one line per entry), json (one entry per line) or yaml. Defaults to the global --output.",
+	},
+}
+
+// logEntry is a line of the logs of a function.
+type logEntry struct {
+	Time     time.Time `json:"time"`
+	CallID   string    `json:"call_id,omitempty"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+	// key identifies the entry across polls
+	key string
+}
+
+// logSource reads the logs of a function from a provider.
+type logSource interface {
+	// entries returns the entries logged from since up to until, oldest first.
+	entries(since, until time.Time) ([]logEntry, error)
+}
+
+// logFilter selects the entries fn logs shows.
+type logFilter struct {
+	callID   string
+	severity string
+}
+
+func (f logFilter) match(e logEntry) bool {
+	if f.callID != "" && e.CallID != f.callID {
+		return false
+	}
+	return f.severity == "" || severities[e.Severity] >= severities[f.severity]
+}
+
+// tail prints the entries of src from since up to until, then, following, keeps polling src every interval for new
+// ones until until, if set, is past.
+func tail(src logSource, since, until time.Time, follow bool, interval time.Duration, filter logFilter, print func(logEntry) error) error {
+	// seen has the time of the entries printed, to forget them once the polls no longer look back that far
+	seen := map[string]time.Time{}
+	for {
+		to := until
+		if to.IsZero() {
+			to = time.Now()
+		}
+		entries, err := src.entries(since, to)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, ok := seen[e.key]; ok {
+				continue
+			}
+			seen[e.key] = e.Time
+			if !filter.match(e) {
+				continue
+			}
+			if err := print(e); err != nil {
+				return err
+			}
+		}
+		if !follow || (!until.IsZero() && time.Now().After(until)) {
+			return nil
+		}
+		if from := to.Add(-followOverlap); from.After(since) {
+			since = from
+		}
+		for key, t := range seen {
+			if t.Before(since) {
+				delete(seen, key)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// parseLogTime parses --since and --until, a duration before now or an RFC 3339 time. Empty gives the zero time.
+func parseLogTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use a duration such as 30m or an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+// lineSeverity guesses the severity of a log line from the level it starts with, or names as level=, info if none.
+func lineSeverity(line string) string {
+	l := strings.ToLower(line)
+	if i := strings.Index(l, "level="); i >= 0 {
+		l = l[i+len("level="):]
+	} else {
+		l = strings.TrimLeft(l, "[ ")
+		if f := strings.Fields(l); len(f) > 0 {
+			l = f[0]
+		}
+	}
+	l = strings.Trim(l, "\"[]: ")
+	switch {
+	case strings.HasPrefix(l, "error"), strings.HasPrefix(l, "fatal"), strings.HasPrefix(l, "panic"), strings.HasPrefix(l, "severe"):
+		return "error"
+	case strings.HasPrefix(l, "warn"):
+		return "warn"
+	case strings.HasPrefix(l, "debug"), strings.HasPrefix(l, "trace"):
+		return "debug"
+	}
+	return "info"
+}
+
+// fnLogSource reads the call logs the functions server keeps for the calls of a route.
+type fnLogSource struct {
+	client     *fnclient.Functions
+	app, route string
+	// fetched keeps the logs of the calls that finished, which don't change, as long as the polls return them
+	fetched map[string][]logEntry
+}
+
+func (s *fnLogSource) entries(since, until time.Time) ([]logEntry, error) {
+	route := s.route
+	resp, err := s.client.Call.GetAppsAppCalls(&apicall.GetAppsAppCallsParams{
+		App:     s.app,
+		Route:   &route,
+		Context: context.Background(),
+	})
+	if err != nil {
+		switch e := err.(type) {
+		case *apicall.GetCallsCallNotFound:
+			return nil, errors.New(e.Payload.Error.Message)
+		default:
+			return nil, err
+		}
+	}
+	var entries []logEntry
+	fetched := map[string][]logEntry{}
+	for _, call := range resp.Payload.Calls {
+		created := time.Time(call.CreatedAt)
+		if created.Before(since) || created.After(until) {
+			continue
+		}
+		if call.Status == "running" || call.Status == "queued" {
+			// logged once it's done
+			continue
+		}
+		callEntries, ok := s.fetched[call.ID]
+		if !ok {
+			log, err := s.client.Operations.GetAppsAppCallsCallLog(&apiops.GetAppsAppCallsCallLogParams{
+				App:     s.app,
+				Call:    call.ID,
+				Context: context.Background(),
+			})
+			if err != nil {
+				if _, notFound := err.(*apiops.GetAppsAppCallsCallLogNotFound); !notFound {
+					return nil, err
+				}
+			} else {
+				t := time.Time(call.CompletedAt)
+				if t.IsZero() {
+					t = created
+				}
+				callEntries = logLines(log.Payload.Log.Log, t, call.ID)
+			}
+		}
+		fetched[call.ID] = callEntries
+		entries = append(entries, callEntries...)
+	}
+	s.fetched = fetched
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// logLines splits the log of a call into entries, which all get the time of the call
+func logLines(log string, t time.Time, callID string) []logEntry {
+	var entries []logEntry
+	for i, line := range strings.Split(strings.TrimRight(log, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		entries = append(entries, logEntry{
+			Time:     t,
+			CallID:   callID,
+			Severity: lineSeverity(line),
+			Message:  line,
+			key:      fmt.Sprintf("%s:%d", callID, i),
+		})
+	}
+	return entries
+}
+
+// ociLogSource searches the function's log in OCI Logging with the oci CLI.
+type ociLogSource struct {
+	scope string
+}
+
+func (s *ociLogSource) entries(since, until time.Time) ([]logEntry, error) {
+	if _, err := exec.LookPath("oci"); err != nil {
+		return nil, errors.New("the oci CLI is needed to read OCI Logging, install it from https://docs.oracle.com/iaas/Content/API/SDKDocs/cliinstall.htm")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("oci", "logging-search", "search-logs",
+		"--search-query", fmt.Sprintf("search %q | sort by datetime asc", s.scope),
+		"--time-start", since.UTC().Format(time.RFC3339),
+		"--time-end", until.UTC().Format(time.RFC3339),
+		"--all")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("oci logging-search search-logs failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ociLogEntries(out)
+}
+
+// ociLogEntries returns the entries of the output of oci logging-search search-logs
+func ociLogEntries(out []byte) ([]logEntry, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		// no results
+		return nil, nil
+	}
+	var search struct {
+		Data struct {
+			Results []struct {
+				Data struct {
+					Datetime   int64 `json:"datetime"`
+					LogContent struct {
+						ID   string                 `json:"id"`
+						Data map[string]interface{} `json:"data"`
+					} `json:"logContent"`
+				} `json:"data"`
+			} `json:"results"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &search); err != nil {
+		return nil, fmt.Errorf("could not parse the OCI Logging results: %v", err)
+	}
+	var entries []logEntry
+	for _, r := range search.Data.Results {
+		data := r.Data.LogContent.Data
+		message, _ := data["message"].(string)
+		if message == "" {
+			b, _ := json.Marshal(data)
+			message = string(b)
+		}
+		severity := lineSeverity(message)
+		if level, ok := data["level"].(string); ok {
+			severity = lineSeverity(level)
+		}
+		callID, _ := data["callID"].(string)
+		if callID == "" {
+			callID, _ = data["opcRequestId"].(string)
+		}
+		entries = append(entries, logEntry{
+			Time:     time.Unix(0, r.Data.Datetime*int64(time.Millisecond)),
+			CallID:   callID,
+			Severity: severity,
+			Message:  message,
+			key:      r.Data.LogContent.ID,
+		})
+	}
+	return entries, nil
+}
+
+// printLogEntry returns the printer of the entries in the output format, json printing one entry per line for jq.
+func printLogEntry(format string) func(logEntry) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		return func(e logEntry) error { return enc.Encode(e) }
+	case outputYAML:
+		return func(e logEntry) error {
+			fmt.Println("---")
+			return writeYAML(os.Stdout, e)
+		}
+	}
+	return func(e logEntry) error {
+		callID := ""
+		if e.CallID != "" {
+			callID = " " + e.CallID
+		}
+		_, err := fmt.Printf("%s%s %-5s %s\n", e.Time.Local().Format(time.RFC3339), callID, strings.ToUpper(e.Severity), e.Message)
+		return err
+	}
+}
+
+// tail streams the logs of a route, fn logs <app> <route>.
+func (log *logsCmd) tail(c *cli.Context) error {
+	c, err := reparseFlags(c, logsTailFlags)
+	if err != nil {
+		return err
+	}
+	if c.NArg() != 2 {
+		return errors.New("usage: fn logs <app> <route> [--follow], or fn logs get <app> <call-id>")
+	}
+	app, route := c.Args().Get(0), cleanRoutePath(c.Args().Get(1))
+
+	format := c.String("output")
+	if format == "" {
+		format = c.GlobalString("output")
+	}
+	if err := checkOutputFormat(format); err != nil {
+		return err
+	}
+	severity := strings.ToLower(c.String("severity"))
+	if _, ok := severities[severity]; severity != "" && !ok {
+		return fmt.Errorf("unknown severity %q, use debug, info, warn or error", severity)
+	}
+	now := time.Now()
+	since, err := parseLogTime(c.String("since"), now)
+	if err != nil {
+		return err
+	}
+	until, err := parseLogTime(c.String("until"), now)
+	if err != nil {
+		return err
+	}
+	if !until.IsZero() && until.Before(since) {
+		return errors.New("--until is before --since")
+	}
+
+	var src logSource
+	switch c.String("provider") {
+	case logProviderFn:
+		src = &fnLogSource{client: log.client, app: app, route: route, fetched: map[string][]logEntry{}}
+	case logProviderOCI:
+		if c.String("oci-log") == "" {
+			return errors.New("--provider oci needs the --oci-log of the function")
+		}
+		src = &ociLogSource{scope: c.String("oci-log")}
+	default:
+		return fmt.Errorf("unknown log provider %q, use %s or %s", c.String("provider"), logProviderFn, logProviderOCI)
+	}
+	filter := logFilter{callID: c.String("call-id"), severity: severity}
+	return tail(src, since, until, c.Bool("follow"), c.Duration("interval"), filter, printLogEntry(format))
+}
+
+// reparseFlags parses the flags of a command given after its args, such as fn logs app route --follow. urfave/cli
+// stops parsing flags at the first arg of commands that have subcommands.
+func reparseFlags(c *cli.Context, flags []cli.Flag) (*cli.Context, error) {
+	set := flag.NewFlagSet(c.App.Name, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	// the flags given before the args are parsed already
+	for _, f := range flags {
+		if name := strings.TrimSpace(strings.Split(f.GetName(), ",")[0]); c.IsSet(name) {
+			set.Set(name, c.String(name))
+		}
+	}
+	var flagArgs, args []string
+	rest := c.Args()
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			args = append(args, arg)
+			continue
+		}
+		flagArgs = append(flagArgs, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := set.Lookup(name); f != nil && !isBoolFlag(f) && i+1 < len(rest) {
+			i++
+			flagArgs = append(flagArgs, rest[i])
+		}
+	}
+	if err := set.Parse(append(flagArgs, args...)); err != nil {
+		return nil, err
+	}
+	// each name of a flag is a flag of the set, the names given set the others
+	visited := map[string]bool{}
+	set.Visit(func(f *flag.Flag) { visited[f.Name] = true })
+	for _, f := range flags {
+		names := strings.Split(f.GetName(), ",")
+		for _, name := range names {
+			if name = strings.TrimSpace(name); visited[name] {
+				for _, other := range names {
+					set.Set(strings.TrimSpace(other), set.Lookup(name).Value.String())
+				}
+				break
+			}
+		}
+	}
+	// global flags are looked up from the parent on, where they are
+	return cli.NewContext(c.App, set, c.Parent()), nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface {
+		IsBoolFlag() bool
+	})
+	return ok && b.IsBoolFlag()
+}
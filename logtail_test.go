@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func TestLineSeverity(t *testing.T) {
+	for line, expected := range map[string]string{
+		"ERROR could not connect":                 "error",
+		"[WARN] retrying":                         "warn",
+		`time=2023-01-01 level=debug msg="cache"`: "debug",
+		"handled request in 3ms":                  "info",
+		"SEVERE: java.lang.NullPointerException":  "error",
+	} {
+		if severity := lineSeverity(line); severity != expected {
+			t.Errorf("%q: expected %s, got %s", line, expected, severity)
+		}
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	if since, err := parseLogTime("30m", now); err != nil || !since.Equal(now.Add(-30*time.Minute)) {
+		t.Errorf("expected 30 minutes ago, got %v, %v", since, err)
+	}
+	if since, err := parseLogTime("2023-06-01T10:00:00Z", now); err != nil || since.Hour() != 10 {
+		t.Errorf("expected 10:00, got %v, %v", since, err)
+	}
+	if _, err := parseLogTime("yesterday", now); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+type fakeLogSource [][]logEntry
+
+func (s *fakeLogSource) entries(since, until time.Time) ([]logEntry, error) {
+	polls := *s
+	*s = polls[1:]
+	return polls[0], nil
+}
+
+func TestTailFollow(t *testing.T) {
+	now := time.Now()
+	first := []logEntry{
+		{Time: now, CallID: "a", Severity: "info", Message: "started", key: "a:0"},
+		{Time: now, CallID: "a", Severity: "error", Message: "failed", key: "a:1"},
+	}
+	second := append(first, logEntry{Time: now, CallID: "b", Severity: "error", Message: "failed again", key: "b:0"})
+	src := &fakeLogSource{first, second}
+
+	var printed []string
+	until := time.Now().Add(50 * time.Millisecond)
+	err := tail(src, time.Now().Add(-time.Hour), until, true, 100*time.Millisecond, logFilter{severity: "error"}, func(e logEntry) error {
+		printed = append(printed, e.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"failed", "failed again"}; !reflect.DeepEqual(printed, expected) {
+		t.Errorf("expected %v, got %v", expected, printed)
+	}
+}
+
+func TestOCILogEntries(t *testing.T) {
+	entries, err := ociLogEntries([]byte(`{"data": {"results": [{"data": {"datetime": 1685620800000, "logContent": {"id": "1", "data": {"message": "WARN slow", "opcRequestId": "req1"}}}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Severity != "warn" || entries[0].CallID != "req1" || entries[0].Time.Unix() != 1685620800 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestReparseFlags(t *testing.T) {
+	var args []string
+	var follow bool
+	var since string
+	app := cli.NewApp()
+	app.Commands = []cli.Command{{
+		Name:        "logs",
+		Flags:       logsTailFlags,
+		Subcommands: []cli.Command{{Name: "get", Action: func(*cli.Context) error { return nil }}},
+		Action: func(c *cli.Context) error {
+			c, err := reparseFlags(c, logsTailFlags)
+			if err != nil {
+				return err
+			}
+			args, follow, since = c.Args(), c.Bool("follow"), c.String("since")
+			return nil
+		},
+	}}
+	if err := app.Run([]string{"fn", "logs", "--since", "10m", "myapp", "hello", "-f"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []string{"myapp", "hello"}) || !follow || since != "10m" {
+		t.Errorf("expected the flags after the args to be parsed, got %v, %v, %v", args, follow, since)
+	}
+}
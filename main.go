@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/fnproject/cli/langs"
 	functions "github.com/funcy/functions_go"
 	"github.com/urfave/cli"
 )
@@ -49,7 +50,11 @@ USAGE:
 
 ENVIRONMENT VARIABLES:
    API_URL - Fn server address
-   FN_REGISTRY - Docker registry to push images to, use username only to push to Docker Hub - [[registry.hub.docker.com/]treeder]{{if .VisibleCommands}}
+   FN_REGISTRY - Docker registry to push images to, use username only to push to Docker Hub - [[registry.hub.docker.com/]treeder]
+   FN_CONTAINER_ENGINE - container engine to build, run and push images with - docker, podman or nerdctl
+   FN_OUTPUT - output format of the commands reading data - table, json or yaml
+   FN_API_RETRIES - how many times requests to the Fn server API are retried after network errors, 429 and 5xx statuses - 3 by default, function calls are never retried
+   FN_API_TIMEOUT - timeout of each attempt of a request to the Fn server API - 30s by default{{if .VisibleCommands}}
 
 COMMANDS:{{range .VisibleCategories}}{{if .Name}}
    {{.Name}}:{{end}}{{range .VisibleCommands}}
@@ -68,6 +73,29 @@ GLOBAL OPTIONS:
    {{end}}{{$option}}{{end}}{{end}}
 `
 
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "container-engine",
+			Usage:  "container engine to build, run and push images with - " + strings.Join(langs.ContainerEngines(), ", ") + ". Defaults to the first one installed.",
+			EnvVar: "FN_CONTAINER_ENGINE",
+		},
+		outputFlag,
+		cli.BoolFlag{
+			Name:   "debug-http",
+			Usage:  "trace the requests to the Fn server API, and their responses, to stderr, with credentials redacted",
+			EnvVar: "FN_DEBUG_HTTP",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		if engine := c.GlobalString("container-engine"); engine != "" {
+			os.Setenv("FN_CONTAINER_ENGINE", engine)
+		}
+		if c.GlobalBool("debug-http") {
+			os.Setenv("FN_DEBUG_HTTP", "true")
+		}
+		return checkOutputFormat(c.GlobalString("output"))
+	}
+
 	app.CommandNotFound = func(c *cli.Context, cmd string) {
 		fmt.Fprintf(os.Stderr, "command not found: %v\n", cmd)
 	}
@@ -83,10 +111,21 @@ GLOBAL OPTIONS:
 		calls(),
 		logs(),
 		testfn(),
+		templates(),
+		develop(),
+		lint(),
+		configure(),
+		contexts(),
+		inspectCmd(),
+		secrets(),
+		migrateCmd(),
+		completionCmd(),
 	}
 	app.Commands = append(app.Commands, aliasesFn()...)
 
 	prepareCmdArgsValidation(app.Commands)
+	app.EnableBashCompletion = true
+	prepareCmdCompletions(app.Commands)
 
 	return app
 }
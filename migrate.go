@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+func migrateCmd() cli.Command {
+	return cli.Command{
+		Name:      "migrate",
+		Usage:     "update the function in the current directory, or in path, to the current FDK and base images",
+		ArgsUsage: "[path]",
+		Action:    migrate,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "show the changes as a diff, without writing them",
+			},
+		},
+	}
+}
+
+func migrate(c *cli.Context) error {
+	dir := getWd()
+	if path := c.Args().First(); path != "" {
+		dir = filepath.Join(dir, path)
+	}
+	fpath, ff, err := findAndParseFuncfile(dir)
+	if err != nil {
+		return err
+	}
+	if ff.Runtime == "" || ff.Runtime == funcfileDockerRuntime {
+		return fmt.Errorf("fn migrate updates the projects of runtimes, %s has none", filepath.Base(fpath))
+	}
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil {
+		return err
+	}
+	migrations, err := helper.Migrate(dir)
+	if err != nil {
+		return err
+	}
+	m, err := migrateFuncfile(fpath, ff, helper)
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		migrations = append(migrations, *m)
+	}
+
+	if len(migrations) == 0 {
+		fmt.Println("The function is up to date")
+		return nil
+	}
+	for _, m := range migrations {
+		if c.Bool("dry-run") {
+			fmt.Print(lineDiff(m.File, m.Old, m.New))
+			continue
+		}
+		path := filepath.Join(dir, m.File)
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode()
+		}
+		if err := ioutil.WriteFile(path, m.New, mode); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s: %s\n", m.File, strings.Join(m.Changes, ", "))
+	}
+	return nil
+}
+
+// migrateFuncfile pins the build and run images of the func.yaml, if it overrides them with other tags of the images
+// of the helper, to the current ones, and brings its schema_version, if it has one, up to the latest. The file is
+// rewritten in place, to keep its comments and layout.
+func migrateFuncfile(fpath string, ff *funcfile, helper langs.LangHelper) (*langs.Migration, error) {
+	old, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	updated := old
+	var changes []string
+	set := func(key, from, to string) {
+		var re *regexp.Regexp
+		if filepath.Ext(fpath) == ".json" {
+			re = regexp.MustCompile(`("` + key + `"\s*:\s*)("?)` + regexp.QuoteMeta(from) + `("?)`)
+		} else {
+			re = regexp.MustCompile(`(?m)^(` + key + `:\s*)(["']?)` + regexp.QuoteMeta(from) + `(["']?)`)
+		}
+		if re.Match(updated) {
+			updated = re.ReplaceAll(updated, []byte("${1}${2}"+strings.Replace(to, "$", "$$", -1)+"${3}"))
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", key, from, to))
+		}
+	}
+	if current := helper.BuildFromImage(); ff.BuildImage != "" && ff.BuildImage != current && sameRepository(ff.BuildImage, current) {
+		set("build_image", ff.BuildImage, current)
+	}
+	if current := helper.RunFromImage(); ff.RunImage != "" && ff.RunImage != current && sameRepository(ff.RunImage, current) {
+		set("run_image", ff.RunImage, current)
+	}
+	if ff.SchemaVersion != 0 && ff.SchemaVersion < funcfileSchemaVersion {
+		set("schema_version", strconv.Itoa(ff.SchemaVersion), strconv.Itoa(funcfileSchemaVersion))
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return &langs.Migration{File: filepath.Base(fpath), Old: old, New: updated, Changes: changes}, nil
+}
+
+// sameRepository reports whether two image references are of the same repository, whatever their tags or digests
+func sameRepository(a, b string) bool {
+	return imageRepository(a) == imageRepository(b)
+}
+
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// a colon after the last slash starts the tag, others are of the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// lineDiff shows the lines of file that differ between old and updated, as a unified diff. Migrations rewrite values
+// in place, the lines line up.
+func lineDiff(file string, old, updated []byte) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", file, file)
+	oldLines := strings.Split(string(old), "\n")
+	newLines := strings.Split(string(updated), "\n")
+	if len(oldLines) != len(newLines) {
+		fmt.Fprintf(&b, "@@ -1,%d +1,%d @@\n", len(oldLines), len(newLines))
+		for _, l := range oldLines {
+			fmt.Fprintf(&b, "-%s\n", l)
+		}
+		for _, l := range newLines {
+			fmt.Fprintf(&b, "+%s\n", l)
+		}
+		return b.String()
+	}
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			fmt.Fprintf(&b, "@@ -%d +%d @@\n-%s\n+%s\n", i+1, i+1, oldLines[i], newLines[i])
+		}
+	}
+	return b.String()
+}
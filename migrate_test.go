@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fnproject/cli/langs"
+)
+
+func TestMigrateFuncfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fpath := filepath.Join(dir, "func.yaml")
+	content := "schema_version: 2\nname: hello\n# pinned for the old base image\nrun_image: funcy/node:0.1\nbuild_image: custom/node:dev\n"
+	if err := ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ff, err := parseFuncfile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrateFuncfile(fpath, ff, &langs.NodeLangHelper{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "schema_version: 3\nname: hello\n# pinned for the old base image\nrun_image: funcy/node\nbuild_image: custom/node:dev\n"
+	if m == nil || string(m.New) != expected {
+		t.Fatalf("expected the run image and schema version to be updated, got %+v", m)
+	}
+	diff := lineDiff(m.File, m.Old, m.New)
+	if !strings.Contains(diff, "-run_image: funcy/node:0.1\n+run_image: funcy/node\n") || strings.Contains(diff, "build_image") {
+		t.Errorf("expected the diff of the changed lines, got\n%s", diff)
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	for image, expected := range map[string]string{
+		"funcy/node":     "funcy/node",
+		"funcy/node:dev": "funcy/node",
+		"registry.example.com:5000/node:1@sha256:abc": "registry.example.com:5000/node",
+		"registry.example.com:5000/node":              "registry.example.com:5000/node",
+	} {
+		if repo := imageRepository(image); repo != expected {
+			t.Errorf("expected %s for %s, got %s", expected, image, repo)
+		}
+	}
+}
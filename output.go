@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The formats of the global --output flag. Tables are for people, JSON and YAML keep the same fields across
+// releases for scripts to parse.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFlag = cli.StringFlag{
+	Name:   "output",
+	Usage:  "output format of the commands reading data, such as list and inspect - table, json or yaml",
+	Value:  outputTable,
+	EnvVar: "FN_OUTPUT",
+}
+
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, use %s, %s or %s", format, outputTable, outputJSON, outputYAML)
+}
+
+// structuredOutput reports whether --output asks for JSON or YAML rather than a table.
+func structuredOutput(c *cli.Context) bool {
+	format := c.GlobalString("output")
+	return format == outputJSON || format == outputYAML
+}
+
+// render writes v to stdout in the --output format, calling table to print it as a table.
+func render(c *cli.Context, v interface{}, table func() error) error {
+	switch c.GlobalString("output") {
+	case outputJSON:
+		return writeJSON(os.Stdout, v)
+	case outputYAML:
+		return writeYAML(os.Stdout, v)
+	}
+	return table()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeYAML writes v as YAML with the field names of its JSON encoding, which the API models are tagged with.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// buildResult is what fn build reports about the image it built.
+type buildResult struct {
+	Image string `json:"image"`
+	// ID is the image ID, the digest of its configuration, empty if the engine keeps no local copy of the image, as
+	// for builds for several platforms and remote builds.
+	ID string `json:"id,omitempty"`
+	// Digest is the digest of the manifest the image was pushed with, that remote builds push, or empty if the image
+	// hasn't been pushed.
+	Digest string `json:"digest,omitempty"`
+	// Size is the size of the image in bytes, or of the tarball for builds without Docker.
+	Size    int64  `json:"size"`
+	Tarball string `json:"tarball,omitempty"`
+}
+
+// inspectImage returns the build result of the local image.
+func inspectImage(image string) (*buildResult, error) {
+	result := &buildResult{Image: image}
+	engine, err := langs.Engine()
+	if err != nil {
+		return nil, err
+	}
+	out, err := engine.Command("image", "inspect", "--format", "{{.Id}} {{.Size}} {{range .RepoDigests}}{{.}} {{end}}", image).Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not inspect %v, its ID and size are unknown: %v\n", image, err)
+		return result, nil
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return result, nil
+	}
+	result.ID = fields[0]
+	result.Size, _ = strconv.ParseInt(fields[1], 10, 64)
+	// the repository digests of the image are of the manifests it was pushed, or pulled, with
+	for _, repoDigest := range fields[2:] {
+		if i := strings.Index(repoDigest, "@"); i >= 0 && sameRepository(repoDigest, image) {
+			result.Digest = repoDigest[i+1:]
+			break
+		}
+	}
+	return result, nil
+}
+
+// quietStdout sends what is printed to stdout to stderr instead, until the returned func is called, so that only
+// the structured output goes to stdout.
+func quietStdout() func() {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return func() { os.Stdout = stdout }
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteYAMLUsesJSONNames(t *testing.T) {
+	var out bytes.Buffer
+	type app struct {
+		Name   string            `json:"name"`
+		Config map[string]string `json:"config"`
+	}
+	if err := writeYAML(&out, []app{{Name: "myapp", Config: map[string]string{"DB_URL": "postgres://db"}}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "- config:\n    DB_URL: postgres://db\n  name: myapp\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	for _, format := range []string{outputTable, outputJSON, outputYAML} {
+		if err := checkOutputFormat(format); err != nil {
+			t.Errorf("expected %s to be accepted, got %v", format, err)
+		}
+	}
+	if err := checkOutputFormat("xml"); err == nil {
+		t.Error("expected xml to be rejected")
+	}
+}
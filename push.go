@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/urfave/cli"
 )
@@ -11,6 +12,7 @@ func push() cli.Command {
 	cmd := pushcmd{}
 	var flags []cli.Flag
 	flags = append(flags, cmd.flags()...)
+	flags = append(flags, signFlags...)
 	return cli.Command{
 		Name:   "push",
 		Usage:  "push function to Docker Hub",
@@ -49,8 +51,11 @@ func (p *pushcmd) flags() []cli.Flag {
 // the route can be overriden inside the functions file.
 func (p *pushcmd) push(c *cli.Context) error {
 	setRegistryEnv(p)
+	if err := checkSignFlags(c, false); err != nil {
+		return err
+	}
 
-	_, ff, err := loadFuncfile()
+	fpath, ff, err := loadFuncfile()
 	if err != nil {
 		if _, ok := err.(*notFoundError); ok {
 			return errors.New("image name is missing or no function file found")
@@ -63,6 +68,9 @@ func (p *pushcmd) push(c *cli.Context) error {
 	if err := dockerPush(ff); err != nil {
 		return err
 	}
+	if err := publishSupplyChain(c, fpath, ff, ff.ImageName(), os.Stdout); err != nil {
+		return err
+	}
 
 	fmt.Printf("Function %v pushed successfully to Docker Hub.\n", ff.ImageName())
 	return nil
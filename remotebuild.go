@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+// builderEnv is remote for builds handed over to the builder of the context builderContextEnv names
+const (
+	builderEnv        = "FN_BUILDER"
+	builderContextEnv = "FN_BUILDER_CONTEXT"
+)
+
+var builderFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "builder",
+		Usage: "local, to build with the container engine, or remote, to build with the builder of --context, which pushes the image",
+		Value: "local",
+	},
+	cli.StringFlag{
+		Name:   "context",
+		Usage:  "context whose registry mirror base images are pulled from, and, with --builder remote, whose remote builder builds the image and whose registry it is pushed to. See fn contexts create.",
+		EnvVar: "FN_CONTEXT",
+	},
+}
+
+// checkRemoteBuilder checks the settings of a remote builder of a context
+func checkRemoteBuilder(cfg *langs.RemoteBuilderConfig) error {
+	switch cfg.Kind {
+	case langs.RemoteBuilderBuildKit:
+		if cfg.Addr == "" {
+			return errors.New("--builder-addr is required for buildkit builders")
+		}
+	case langs.RemoteBuilderKaniko:
+	default:
+		return fmt.Errorf("unknown builder %q, use %s or %s", cfg.Kind, langs.RemoteBuilderBuildKit, langs.RemoteBuilderKaniko)
+	}
+	return nil
+}
+
+// setBuilderEnv passes --builder and --context on to the builds. Builds pull base images through the registry mirror
+// of the context, and remote builds push the image to its registry, unless another registry is set.
+func setBuilderEnv(c *cli.Context) error {
+	remote := false
+	switch c.String("builder") {
+	case "", "local":
+	case "remote":
+		remote = true
+	default:
+		return fmt.Errorf("unknown builder %q, use local or remote", c.String("builder"))
+	}
+	name := c.String("context")
+	if name == "" {
+		if remote {
+			return errors.New("--builder remote needs the --context whose builder to build with")
+		}
+		return nil
+	}
+	contexts, err := lookupContexts(name)
+	if err != nil {
+		return err
+	}
+	buildContext = contexts[0]
+	if !remote {
+		return nil
+	}
+	if contexts[0].Builder == nil {
+		return fmt.Errorf("context %s has no remote builder, set one with fn contexts create --builder", name)
+	}
+	os.Setenv(builderEnv, "remote")
+	os.Setenv(builderContextEnv, name)
+	if os.Getenv(envFnRegistry) == "" {
+		os.Setenv(envFnRegistry, contexts[0].Registry)
+	}
+	return nil
+}
+
+// remoteBuild reports whether builds are handed over to a remote builder
+func remoteBuild() bool {
+	return os.Getenv(builderEnv) == "remote"
+}
+
+// remoteBuilder returns the remote builder of the context builds are handed over to.
+func remoteBuilder() (langs.RemoteBuilder, error) {
+	contexts, err := lookupContexts(os.Getenv(builderContextEnv))
+	if err != nil {
+		return nil, err
+	}
+	if contexts[0].Builder == nil {
+		return nil, fmt.Errorf("context %s has no remote builder", contexts[0].Name)
+	}
+	return langs.NewRemoteBuilder(*contexts[0].Builder)
+}
+
+// buildFlagValues returns the values of the flag, such as --build-arg, of build arguments
+func buildFlagValues(args []string, flag string) []string {
+	var values []string
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag {
+			values = append(values, args[i+1])
+			i++
+		}
+	}
+	return values
+}
+
+// pinnedImage returns image pinned to the digest the function at fpath was last built into it with remotely, or
+// image if it wasn't.
+func pinnedImage(fpath, image string) string {
+	if digest := builtDigest(fpath, image); digest != "" && !strings.Contains(image, "@") {
+		return image + "@" + digest
+	}
+	return image
+}
@@ -64,12 +64,12 @@ var routeFlags = []cli.Flag{
 
 var updateRouteFlags = routeFlags
 
-var callFnFlags = append(runflags(),
+var callFnFlags = append(append(runflags(),
 	cli.BoolFlag{
 		Name:  "display-call-id",
 		Usage: "whether display call ID or not",
 	},
-)
+), loadFlags...)
 
 func routes() cli.Command {
 
@@ -186,19 +186,19 @@ func (a *routesCmd) list(c *cli.Context) error {
 		}
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
-	fmt.Fprint(w, "path", "\t", "image", "\t", "endpoint", "\n")
-	for _, route := range resp.Payload.Routes {
-		endpoint := path.Join(client.Host(), "r", appName, route.Path)
-		if err != nil {
-			return fmt.Errorf("error parsing functions route path: %s", err)
-		}
-
-		fmt.Fprint(w, route.Path, "\t", route.Image, "\t", endpoint, "\n")
+	routes := resp.Payload.Routes
+	if routes == nil {
+		routes = []*fnmodels.Route{}
 	}
-	w.Flush()
-
-	return nil
+	return render(c, routes, func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprint(w, "path", "\t", "image", "\t", "endpoint", "\n")
+		for _, route := range routes {
+			endpoint := path.Join(client.Host(), "r", appName, route.Path)
+			fmt.Fprint(w, route.Path, "\t", route.Image, "\t", endpoint, "\n")
+		}
+		return w.Flush()
+	})
 }
 
 func (a *routesCmd) call(c *cli.Context) error {
@@ -210,6 +210,9 @@ func (a *routesCmd) call(c *cli.Context) error {
 		Host:   client.Host(),
 	}
 	u.Path = path.Join(u.Path, "r", appName, route)
+	if c.Bool("load") {
+		return a.load(c, u.String())
+	}
 	content := stdin()
 
 	return client.CallFN(u.String(), content, os.Stdout, c.String("method"), c.StringSlice("e"), c.Bool("display-call-id"))
@@ -497,8 +500,7 @@ func (a *routesCmd) inspect(c *cli.Context) error {
 	enc.SetIndent("", "\t")
 
 	if prop == "" {
-		enc.Encode(resp.Payload.Route)
-		return nil
+		return render(c, resp.Payload.Route, func() error { return enc.Encode(resp.Payload.Route) })
 	}
 
 	data, err := json.Marshal(resp.Payload.Route)
@@ -516,9 +518,7 @@ func (a *routesCmd) inspect(c *cli.Context) error {
 	if err != nil {
 		return errors.New("failed to inspect that route's field")
 	}
-	enc.Encode(field)
-
-	return nil
+	return render(c, field, func() error { return enc.Encode(field) })
 }
 
 func (a *routesCmd) delete(c *cli.Context) error {
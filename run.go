@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/fnproject/cli/langs"
 	"github.com/urfave/cli"
 )
 
@@ -114,7 +115,7 @@ func preRun(c *cli.Context) (*funcfile, []string, error) {
 		ff.Name = filepath.Base(filepath.Dir(fpath)) // todo: should probably make a copy of ff before changing it
 	}
 
-	_, err = buildfunc(fpath, ff, c.Bool("no-cache"))
+	_, err = buildfunc(fpath, ff, c.Bool("no-cache"), false, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -148,9 +149,12 @@ func (r *runCmd) run(c *cli.Context) error {
 
 // TODO: share all this stuff with the Docker driver in server or better yet, actually use the Docker driver
 func runff(ff *funcfile, stdin io.Reader, stdout, stderr io.Writer, method string, envVars []string, links []string, format string, runs int) error {
-	sh := []string{"docker", "run", "--rm", "-i", fmt.Sprintf("--memory=%dm", ff.Memory)}
+	engine, err := langs.Engine()
+	if err != nil {
+		return err
+	}
+	sh := []string{engine.Name(), "run", "--rm", "-i", fmt.Sprintf("--memory=%dm", ff.Memory)}
 
-	var err error
 	var env []string    // env for the shelled out docker run command
 	var runEnv []string // env to pass into the container via -e's
 
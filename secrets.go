@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// secretScheme prefixes the func.yaml config values fn deploy resolves from a secret store, as
+// secret://<provider>/<path>[#<key>].
+const secretScheme = "secret://"
+
+// secretRef is a reference to a secret, the value of a config var.
+type secretRef struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+func (r secretRef) String() string {
+	s := secretScheme + r.Provider + "/" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// parseSecretRef parses a config value, returning false if it isn't a secret reference.
+func parseSecretRef(value string) (secretRef, bool, error) {
+	if !strings.HasPrefix(value, secretScheme) {
+		return secretRef{}, false, nil
+	}
+	rest := strings.TrimPrefix(value, secretScheme)
+	var ref secretRef
+	if i := strings.LastIndex(rest, "#"); i >= 0 {
+		rest, ref.Key = rest[:i], rest[i+1:]
+	}
+	i := strings.Index(rest, "/")
+	if i <= 0 || i == len(rest)-1 {
+		return secretRef{}, true, fmt.Errorf("invalid secret reference %s, use secret://<provider>/<path>[#<key>]", value)
+	}
+	ref.Provider, ref.Path = rest[:i], rest[i+1:]
+	return ref, true, nil
+}
+
+// secretProvider reads secrets from a secret store.
+type secretProvider interface {
+	// Resolve returns the value of the secret ref refers to.
+	Resolve(ref secretRef) (string, error)
+}
+
+// secretProviders are the stores secret references can name, by provider name.
+var secretProviders = map[string]secretProvider{
+	"vault":     &vaultProvider{},
+	"oci-vault": &ociVaultProvider{},
+	"env-file":  &envFileProvider{},
+}
+
+func resolveSecret(ref secretRef) (string, error) {
+	p, ok := secretProviders[ref.Provider]
+	if !ok {
+		var names []string
+		for name := range secretProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown secret provider %q in %s, use one of %s", ref.Provider, ref, strings.Join(names, ", "))
+	}
+	value, err := p.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %v", ref, err)
+	}
+	return value, nil
+}
+
+// resolveSecrets returns a copy of config with its secret references replaced by the secrets, read from their
+// stores. The secrets are only held in memory, config itself is left as it is.
+func resolveSecrets(config map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(config))
+	for k, v := range config {
+		ref, ok, err := parseSecretRef(v)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %v", k, err)
+		}
+		if ok {
+			if v, err = resolveSecret(ref); err != nil {
+				return nil, fmt.Errorf("config %s: %v", k, err)
+			}
+		}
+		resolved[k] = v
+	}
+	return resolved, nil
+}
+
+// vaultProvider reads secrets from HashiCorp Vault, at VAULT_ADDR with VAULT_TOKEN or the token the vault CLI keeps
+// in ~/.vault-token. Paths are API paths, such as secret/data/app for the KV version 2 engine, and the key is the
+// field of the secret.
+type vaultProvider struct{}
+
+func (p *vaultProvider) Resolve(ref secretRef) (string, error) {
+	if ref.Key == "" {
+		return "", errors.New("vault secrets need the #key of the field to read")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			b, _ := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(b))
+		}
+	}
+	if token == "" {
+		return "", errors.New("VAULT_TOKEN is not set and there is no ~/.vault-token, log in with vault login")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(ref.Path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s", resp.Status)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("could not parse the vault response: %v", err)
+	}
+	fields := secret.Data
+	// the KV version 2 engine nests the fields, next to the metadata of the version
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, isMetadata := fields["metadata"]; isMetadata {
+			fields = nested
+		}
+	}
+	value, ok := fields[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("the secret has no field %s", ref.Key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// ociVaultProvider reads secrets from OCI Vault with the oci CLI, and its configured profile. The path is the OCID of
+// the secret.
+type ociVaultProvider struct{}
+
+func (p *ociVaultProvider) Resolve(ref secretRef) (string, error) {
+	if _, err := exec.LookPath("oci"); err != nil {
+		return "", errors.New("the oci CLI is needed to read OCI Vault secrets, install it from https://docs.oracle.com/iaas/Content/API/SDKDocs/cliinstall.htm")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("oci", "secrets", "secret-bundle", "get", "--secret-id", ref.Path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("oci secrets secret-bundle get failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ociSecretContent(out)
+}
+
+// ociSecretContent returns the secret of the output of oci secrets secret-bundle get
+func ociSecretContent(out []byte) (string, error) {
+	var bundle struct {
+		Data struct {
+			Content struct {
+				ContentType string `json:"content-type"`
+				Content     string `json:"content"`
+			} `json:"secret-bundle-content"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &bundle); err != nil {
+		return "", fmt.Errorf("could not parse the secret bundle: %v", err)
+	}
+	content := bundle.Data.Content
+	if content.ContentType != "BASE64" {
+		return "", fmt.Errorf("unsupported secret content type %q", content.ContentType)
+	}
+	b, err := base64.StdEncoding.DecodeString(content.Content)
+	if err != nil {
+		return "", fmt.Errorf("could not decode the secret: %v", err)
+	}
+	return string(b), nil
+}
+
+// envFileProvider reads secrets from a file of KEY=value lines, such as a .env file kept out of source control. The
+// path is relative to the function directory, and the key is the variable to read.
+type envFileProvider struct{}
+
+func (p *envFileProvider) Resolve(ref secretRef) (string, error) {
+	if ref.Key == "" {
+		return "", errors.New("env-file secrets need the #key of the variable to read")
+	}
+	f, err := os.Open(ref.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != ref.Key {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		return value, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no %s", ref.Path, ref.Key)
+}
+
+func secrets() cli.Command {
+	return cli.Command{
+		Name:  "secrets",
+		Usage: "check the secrets func.yaml config references, as secret://<provider>/<path>[#<key>]",
+		Subcommands: []cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "check that the secrets of the function in the current directory, or in path, can be read, without deploying it",
+				ArgsUsage: "[path]",
+				Action:    verifySecrets,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "env",
+						Usage: "environment of the func.yaml whose config to check",
+					},
+				},
+			},
+		},
+	}
+}
+
+func verifySecrets(c *cli.Context) error {
+	wd := getWd()
+	dir := wd
+	if path := c.Args().First(); path != "" {
+		dir = filepath.Join(wd, path)
+	}
+	_, ff, err := findAndParseFuncfile(dir)
+	if err != nil {
+		return err
+	}
+	if err := ff.validate(); err != nil {
+		return err
+	}
+	if ff, err = ff.resolve(c.String("env")); err != nil {
+		return err
+	}
+	// env-file paths are relative to the function directory
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	var keys []string
+	for k := range ff.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	checked, failed := 0, 0
+	for _, k := range keys {
+		ref, ok, err := parseSecretRef(ff.Config[k])
+		if !ok {
+			continue
+		}
+		checked++
+		if err == nil {
+			_, err = resolveSecret(ref)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s\tFAILED\t%v\n", k, err)
+		} else {
+			fmt.Printf("%s\tOK\t%s\n", k, ref)
+		}
+	}
+	if checked == 0 {
+		fmt.Println("No secrets referenced")
+		return nil
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d secrets could not be resolved", failed, checked)
+	}
+	fmt.Printf("All %d secrets resolved\n", checked)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		ref   secretRef
+		ok    bool
+		err   bool
+	}{
+		{"plain", secretRef{}, false, false},
+		{"secret://vault/secret/data/app#password", secretRef{"vault", "secret/data/app", "password"}, true, false},
+		{"secret://oci-vault/ocid1.vaultsecret.oc1..aaaa", secretRef{"oci-vault", "ocid1.vaultsecret.oc1..aaaa", ""}, true, false},
+		{"secret://vault", secretRef{}, true, true},
+		{"secret:///path#key", secretRef{}, true, true},
+	} {
+		ref, ok, err := parseSecretRef(tt.value)
+		if ref != tt.ref || ok != tt.ok || (err != nil) != tt.err {
+			t.Errorf("%s: expected %+v, %v, error %v, got %+v, %v, %v", tt.value, tt.ref, tt.ok, tt.err, ref, ok, err)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	envFile := filepath.Join(tmp, "prod.env")
+	ioutil.WriteFile(envFile, []byte("# prod secrets\nexport API_KEY=\"s3cr3t\"\nOTHER=1\n"), 0600)
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/app" || r.Header.Get("X-Vault-Token") != "token" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"password": "hunter2"}, "metadata": {"version": 3}}}`))
+	}))
+	defer vault.Close()
+	defer os.Setenv("VAULT_ADDR", os.Getenv("VAULT_ADDR"))
+	defer os.Setenv("VAULT_TOKEN", os.Getenv("VAULT_TOKEN"))
+	os.Setenv("VAULT_ADDR", vault.URL)
+	os.Setenv("VAULT_TOKEN", "token")
+
+	config := map[string]string{
+		"API_KEY":  "secret://env-file/" + envFile + "#API_KEY",
+		"DB_PASS":  "secret://vault/secret/data/app#password",
+		"LOG_MODE": "json",
+	}
+	resolved, err := resolveSecrets(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved["API_KEY"] != "s3cr3t" || resolved["DB_PASS"] != "hunter2" || resolved["LOG_MODE"] != "json" {
+		t.Errorf("unexpected resolved config %v", resolved)
+	}
+	if config["DB_PASS"] != "secret://vault/secret/data/app#password" {
+		t.Errorf("expected the config to be left as it is, got %v", config)
+	}
+
+	if _, err := resolveSecrets(map[string]string{"X": "secret://vault/secret/data/app#missing"}); err == nil {
+		t.Error("expected an error for a missing vault field")
+	}
+	if _, err := resolveSecrets(map[string]string{"X": "secret://keychain/x#y"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestOCISecretContent(t *testing.T) {
+	secret, err := ociSecretContent([]byte(`{"data": {"secret-bundle-content": {"content-type": "BASE64", "content": "aHVudGVyMg=="}}}`))
+	if err != nil || secret != "hunter2" {
+		t.Errorf("expected hunter2, got %q, %v", secret, err)
+	}
+}
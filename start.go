@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
 	"syscall"
 
+	"github.com/fnproject/cli/langs"
 	"github.com/urfave/cli"
 )
 
@@ -40,12 +40,16 @@ func start(c *cli.Context) error {
 	if err != nil {
 		log.Fatalln("Getwd failed:", err)
 	}
+	engine, err := langs.Engine()
+	if err != nil {
+		return err
+	}
 	args := []string{"run", "--rm", "-i",
 		"--name", "functions",
 		"-v", fmt.Sprintf("%s/data:/app/data", wd),
-		"-v", "/var/run/docker.sock:/var/run/docker.sock",
 		"-p", "8080:8080",
 	}
+	args = append(args, engine.ServerArgs()...)
 	for _, v := range denvs {
 		args = append(args, "-e", v)
 	}
@@ -53,7 +57,7 @@ func start(c *cli.Context) error {
 		args = append(args, "-d")
 	}
 	args = append(args, functionsDockerImage)
-	cmd := exec.Command("docker", args...)
+	cmd := engine.Command(args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err = cmd.Start()
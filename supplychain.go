@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+var sbomFlag = cli.StringFlag{
+	Name:  "sbom",
+	Usage: "generate an SBOM of the image with syft, including the function's language dependencies - spdx or cyclonedx",
+}
+
+// signFlags sign pushed images and attach SBOMs to them, for fn push and fn deploy
+var signFlags = []cli.Flag{
+	sbomFlag,
+	cli.BoolFlag{
+		Name:  "sign",
+		Usage: "sign the pushed image with cosign, keyless unless --sign-key is set, and attest its SBOM if --sbom is set",
+	},
+	cli.StringFlag{
+		Name:  "sign-key",
+		Usage: "cosign key to sign with, a key file or a KMS URI such as awskms:///alias/fn",
+	},
+}
+
+// checkSignFlags fails early on --sbom and --sign settings the image can't be published with.
+func checkSignFlags(c *cli.Context, local bool) error {
+	if format := c.String("sbom"); format != "" {
+		if err := langs.CheckSBOMFormat(format); err != nil {
+			return err
+		}
+	}
+	if c.String("sign-key") != "" && !c.Bool("sign") {
+		return errors.New("--sign-key is only used with --sign")
+	}
+	if local && (c.Bool("sign") || c.String("sbom") != "") {
+		return errors.New("--sign and --sbom publish to the registry, they can't be used with --local")
+	}
+	return nil
+}
+
+// publishSupplyChain attaches an SBOM of image, the pushed image of the function at fpath, to it, and signs the
+// image, as --sbom and --sign ask. Both are done to the digest image was pushed with. Their progress goes to out.
+func publishSupplyChain(c *cli.Context, fpath string, ff *funcfile, image string, out io.Writer) error {
+	format := c.String("sbom")
+	sign := c.Bool("sign")
+	if format == "" && !sign {
+		return nil
+	}
+	image, err := langs.PushedImage(image)
+	if err != nil {
+		return err
+	}
+
+	sbomPath := ""
+	if format != "" {
+		f, err := ioutil.TempFile("", "sbom")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+		fmt.Fprintf(out, "Generating the %s SBOM of %v\n", format, image)
+		if err := generateSBOM(fpath, ff, "registry:"+image, format, f.Name(), out); err != nil {
+			return err
+		}
+		sbomPath = f.Name()
+	}
+
+	if sign {
+		fmt.Fprintf(out, "Signing %v\n", image)
+		return langs.SignImage(image, c.String("sign-key"), sbomPath, format, out)
+	}
+	fmt.Fprintf(out, "Attaching the SBOM to %v\n", image)
+	return langs.AttachSBOM(image, sbomPath, format, out)
+}
+
+// generateSBOM writes the SBOM of the function at fpath, whose image syft reads from source, to out. What syft
+// prints goes to output.
+func generateSBOM(fpath string, ff *funcfile, source, format, out string, output io.Writer) error {
+	var manifests []string
+	if ff.Runtime != "" && ff.Runtime != funcfileDockerRuntime {
+		if helper, err := langs.GetLangHelper(ff.Runtime); err == nil {
+			manifests = helper.DependencyManifests()
+		}
+	}
+	return langs.GenerateSBOM(source, filepath.Dir(fpath), manifests, format, out, output)
+}
+
+// sbomFile is where fn build writes the SBOM of a function, next to its func.yaml. It's hidden, so that it doesn't
+// count as a change to the sources that the next build has to rebuild for.
+func sbomFile(fpath, format string) string {
+	return filepath.Join(filepath.Dir(fpath), ".sbom."+format+".json")
+}
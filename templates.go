@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+type templatesCmd struct{}
+
+func templates() cli.Command {
+	t := templatesCmd{}
+
+	return cli.Command{
+		Name:  "templates",
+		Usage: "manage the template sources fn init --template generates boilerplate from",
+		Subcommands: []cli.Command{
+			{
+				Name:      "add",
+				Aliases:   []string{"a"},
+				Usage:     "register a git repository, or an oci:// artifact, of templates",
+				ArgsUsage: "<name> <url>",
+				Action:    t.add,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "list the template sources and the templates fetched of them",
+				Action:  t.list,
+			},
+			{
+				Name:      "remove",
+				Aliases:   []string{"r"},
+				Usage:     "unregister a template source",
+				ArgsUsage: "<name>",
+				Action:    t.remove,
+			},
+		},
+	}
+}
+
+func (t *templatesCmd) add(c *cli.Context) error {
+	name, url := c.Args().Get(0), c.Args().Get(1)
+	if err := langs.AddTemplateSource(name, url); err != nil {
+		return err
+	}
+	fmt.Println("Template source", name, "added")
+	return nil
+}
+
+func (t *templatesCmd) list(c *cli.Context) error {
+	sources, err := langs.TemplateSources()
+	if err != nil {
+		return err
+	}
+	type listedSource struct {
+		langs.TemplateSource
+		Templates []string `json:"templates"`
+	}
+	listed := []listedSource{}
+	for _, s := range sources {
+		templates := langs.TemplatesOf(s)
+		if templates == nil {
+			templates = []string{}
+		}
+		listed = append(listed, listedSource{s, templates})
+	}
+	return render(c, listed, func() error {
+		if len(listed) == 0 {
+			fmt.Println("No template sources, add one with fn templates add")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprint(w, "name", "\t", "url", "\t", "templates", "\n")
+		for _, s := range listed {
+			fmt.Fprint(w, s.Name, "\t", s.URL, "\t", strings.Join(s.Templates, ", "), "\n")
+		}
+		return w.Flush()
+	})
+}
+
+func (t *templatesCmd) remove(c *cli.Context) error {
+	name := c.Args().Get(0)
+	if err := langs.RemoveTemplateSource(name); err != nil {
+		return err
+	}
+	fmt.Println("Template source", name, "removed")
+	return nil
+}
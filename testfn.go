@@ -36,8 +36,10 @@ func testfn() cli.Command {
 type testcmd struct {
 	*functions.RoutesApi
 
-	build  bool
-	remote string
+	build   bool
+	remote  string
+	unit    bool
+	reports string
 }
 
 func (t *testcmd) flags() []cli.Flag {
@@ -52,6 +54,17 @@ func (t *testcmd) flags() []cli.Flag {
 			Usage:       "run tests by calling the function on `appname`",
 			Destination: &t.remote,
 		},
+		cli.BoolFlag{
+			Name:        "unit",
+			Usage:       "run the unit tests of the function's runtime, such as mvn test or pytest, in its build image",
+			Destination: &t.unit,
+		},
+		cli.StringFlag{
+			Name:        "reports",
+			Usage:       "directory the JUnit XML reports of --unit are written to",
+			Value:       "test-reports",
+			Destination: &t.reports,
+		},
 	}
 }
 
@@ -70,8 +83,11 @@ func (t *testcmd) test(c *cli.Context) error {
 	if ff.Name == "" {
 		ff.Name = filepath.Base(filepath.Dir(fpath)) // todo: should probably make a copy of ff before changing it
 	}
+	if t.unit {
+		return runUnitTests(fpath, ff, t.reports)
+	}
 
-	ff, err = buildfunc(fpath, ff, false)
+	ff, err = buildfunc(fpath, ff, false, false, false)
 	ff, envVars, err := preRun(c)
 	if err != nil {
 		return err
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+// unitTestStage is the stage fn test --unit adds onto the build stage of the generated Dockerfile
+const unitTestStage = "unit-test"
+
+// runUnitTests runs the unit tests of the function, with the commands of its helper, in a container of its build
+// stage. The JUnit XML reports are written to reports, and a failing run exits fn with the exit code of the tests.
+func runUnitTests(fpath string, ff *funcfile, reports string) error {
+	if ff.Runtime == "" || ff.Runtime == funcfileDockerRuntime {
+		return fmt.Errorf("fn test --unit runs the tests of a runtime, func.yaml has none")
+	}
+	helper, err := langs.GetLangHelper(ff.Runtime)
+	if err != nil {
+		return err
+	}
+	cmds := helper.TestCmds()
+	if len(cmds) == 0 {
+		return fmt.Errorf("the %s runtime has no unit test runner", ff.Runtime)
+	}
+	engine, err := langs.Engine()
+	if err != nil {
+		return err
+	}
+	if err := engine.CheckVersion(); err != nil {
+		return err
+	}
+	proxies, err := langs.LoadProxyConfig()
+	if err != nil {
+		return err
+	}
+	if err := setImageMirror(ff); err != nil {
+		return err
+	}
+	// the tests run in the JVM build stage of native builds too
+	if err := setBuildMode(helper, ff); err != nil {
+		return err
+	}
+	// the pre-build containers run the images of the function
+	if _, err := langs.ResolveImages(helper, ff.Runtime, ff.BuildImage, ff.RunImage); err != nil {
+		return err
+	}
+	if helper.HasPreBuild() {
+		ctx, stop := interruptContext()
+		err := langs.PreBuildContext(ctx, helper)
+		stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Dir(fpath)
+	dockerfile, err := writeTmpDockerfile(helper, dir, ff)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dockerfile)
+	if langs.UseInitWrapper() {
+		defer os.Remove(filepath.Join(dir, langs.InitWrapperFile))
+	}
+	if helper.NativeImageBuild() {
+		defer os.Remove(filepath.Join(dir, langs.NativeImageReflectionFile))
+	}
+	target, err := addUnitTestStage(dockerfile)
+	if err != nil {
+		return err
+	}
+
+	image := ff.ImageName() + "-" + unitTestStage
+	args := []string{"build", "-t", image, "-f", dockerfile}
+	if target != "" {
+		args = append(args, "--target", target)
+	}
+	args = append(args, proxies.BuildArgs()...)
+	args = append(args, ".")
+	fmt.Printf("Building test image %v\n", image)
+	cmd := engine.Command(args...)
+	cmd.Dir = dir
+	if langs.DockerfileSyntax(helper) != "" {
+		cmd.Env = append(os.Environ(), engine.BuildEnv()...)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running %s build: %v", engine.Name(), err)
+	}
+
+	if !filepath.IsAbs(reports) {
+		reports = filepath.Join(dir, reports)
+	}
+	if err := os.MkdirAll(reports, 0755); err != nil {
+		return err
+	}
+	args = []string{"run", "--rm", "--entrypoint", "sh", "-v", reports + ":" + langs.TestReportsDir}
+	args = append(args, langs.CacheVolumeArgs(helper, ff.Runtime)...)
+	args = append(args, image, "-c", unitTestScript(cmds))
+	fmt.Printf("Running the %s unit tests of %v\n", ff.Runtime, ff.Name)
+	cmd = engine.Command(args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code := exitErr.ExitCode()
+			return cli.NewExitError(fmt.Sprintf("ERROR: unit tests failed with exit code %d, reports are in %s", code, reports), code)
+		}
+		return fmt.Errorf("error running the unit tests: %v", err)
+	}
+	fmt.Printf("Unit tests passed, reports are in %s\n", reports)
+	return nil
+}
+
+// addUnitTestStage appends a stage adding the function sources onto the build stage of the Dockerfile, for runtimes
+// whose build stage only holds the dependencies, and returns the target to build. Single stage Dockerfiles have the
+// sources in their only stage, which is built whole.
+func addUnitTestStage(dockerfile string) (string, error) {
+	df, err := ioutil.ReadFile(dockerfile)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(string(df), " as build-stage\n") {
+		return "", nil
+	}
+	stage := fmt.Sprintf("\nFROM build-stage as %s\nADD . /function/\n", unitTestStage)
+	return unitTestStage, ioutil.WriteFile(dockerfile, append(df, stage...), 0644)
+}
+
+// unitTestScript runs cmds one after the other, stopping at the first that fails
+func unitTestScript(cmds []string) string {
+	return "set -e\n" + strings.Join(cmds, "\n")
+}
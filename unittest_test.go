@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddUnitTestStage(t *testing.T) {
+	for _, tt := range []struct {
+		dockerfile string
+		target     string
+	}{
+		{"FROM node:8 as build-stage\nRUN npm install\nFROM node:8-alpine\nADD . /function/\n", unitTestStage},
+		{"FROM python:3\nADD . /function/\n", ""},
+	} {
+		f, err := ioutil.TempFile("", "Dockerfile")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(tt.dockerfile)
+		f.Close()
+
+		target, err := addUnitTestStage(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != tt.target {
+			t.Errorf("expected target %q, got %q", tt.target, target)
+		}
+		b, _ := ioutil.ReadFile(f.Name())
+		if added := strings.TrimPrefix(string(b), tt.dockerfile); (added != "") != (tt.target != "") ||
+			(tt.target != "" && !strings.HasPrefix(added, "\nFROM build-stage as unit-test\n")) {
+			t.Errorf("unexpected stage added to %q: %q", tt.dockerfile, added)
+		}
+	}
+}
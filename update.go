@@ -3,10 +3,11 @@ package main
 import (
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"github.com/fnproject/cli/langs"
 	"github.com/urfave/cli"
 )
 
@@ -15,6 +16,14 @@ func updateCmd() cli.Command {
 		Name:   "update",
 		Usage:  "pulls latest functions server",
 		Action: update,
+		Subcommands: []cli.Command{
+			{
+				Name:      "context",
+				Usage:     "update a setting of a context: " + strings.Join(contextSettings, ", "),
+				ArgsUsage: "<context> <setting> [value], or <context> image-digest <image> [digest]",
+				Action:    (&contextsCmd{}).update,
+			},
+		},
 	}
 }
 
@@ -22,7 +31,7 @@ func update(c *cli.Context) error {
 	args := []string{"pull",
 		functionsDockerImage,
 	}
-	cmd := exec.Command("docker", args...)
+	cmd := langs.EngineCommand(args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err := cmd.Start()
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fnproject/cli/langs"
+	"github.com/urfave/cli"
+)
+
+// wizard asks the questions of interactive commands, reading the answers a line at a time.
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newWizard(in io.Reader, out io.Writer) *wizard {
+	return &wizard{in: bufio.NewReader(in), out: out}
+}
+
+// ask returns the answer to question, or def if it is left empty.
+func (w *wizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", fmt.Errorf("no answer to %q, the input ended", question)
+	} else if err != nil && err != io.EOF {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// choose lists the options, numbered, and returns the one the answer to question names or numbers. It asks again
+// until it gets one.
+func (w *wizard) choose(question string, options []string, def string) (string, error) {
+	for i, option := range options {
+		fmt.Fprintf(w.out, "%3d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := w.ask(question, def)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], nil
+		}
+		for _, option := range options {
+			if answer == option {
+				return option, nil
+			}
+		}
+		fmt.Fprintf(w.out, "%q is not one of the choices\n", answer)
+	}
+}
+
+// askSettings asks for the runtime, route type, memory and registry of the function fn init --interactive creates,
+// offering the flags, or what fn init would otherwise pick, as the defaults. The runtime isn't asked for with a
+// Dockerfile, which makes it docker.
+func (a *initFnCmd) askSettings(c *cli.Context, w *wizard) error {
+	wd := getWd()
+	if a.Runtime == "" && !exists("Dockerfile") {
+		var runtimes []string
+		for _, entry := range langs.CompletionEntries() {
+			runtimes = append(runtimes, entry.Name)
+		}
+		detected, _ := langs.DetectRuntime(wd)
+		runtime, err := w.choose("Runtime", runtimes, detected)
+		if err != nil {
+			return err
+		}
+		a.Runtime = runtime
+	}
+
+	routeType := c.String("type")
+	if routeType == "" {
+		routeType = "sync"
+	}
+	routeType, err := w.choose("Route type", []string{"sync", "async"}, routeType)
+	if err != nil {
+		return err
+	}
+	a.Type = routeType
+
+	memory := c.Uint64("memory")
+	if helper, err := langs.GetLangHelper(a.Runtime); err == nil && !c.IsSet("memory") && helper.DefaultMemory() > 0 {
+		memory = helper.DefaultMemory()
+	}
+	for {
+		answer, err := w.ask("Memory in MiB", strconv.FormatUint(memory, 10))
+		if err != nil {
+			return err
+		}
+		if m, err := strconv.ParseUint(answer, 10, 64); err == nil && m > 0 {
+			a.Memory = m
+			break
+		}
+		fmt.Fprintf(w.out, "%q is not a number of MiB\n", answer)
+	}
+
+	// the registry goes into func.yaml when it isn't the one FN_REGISTRY already sets
+	registry, err := w.ask("Registry to push the image to", os.Getenv(envFnRegistry))
+	if err != nil {
+		return err
+	}
+	if registry != os.Getenv(envFnRegistry) {
+		a.Registry = registry
+	}
+	return nil
+}
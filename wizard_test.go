@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestWizardChoose(t *testing.T) {
+	var out bytes.Buffer
+	w := newWizard(strings.NewReader("ruby\n3\n\n"), &out)
+	choice, err := w.choose("Runtime", []string{"go", "java", "python"}, "go")
+	if err != nil || choice != "python" {
+		t.Errorf("expected the third choice after an unknown one, got %q, %v", choice, err)
+	}
+	if !strings.Contains(out.String(), `"ruby" is not one of the choices`) {
+		t.Errorf("expected the unknown choice to be reported, got:\n%s", out.String())
+	}
+	if choice, err := w.choose("Runtime", []string{"go", "java"}, "java"); err != nil || choice != "java" {
+		t.Errorf("expected the default for an empty answer, got %q, %v", choice, err)
+	}
+	if _, err := w.choose("Runtime", []string{"go"}, "go"); err == nil {
+		t.Error("expected the end of the input to be reported")
+	}
+}
+
+func TestInitAskSettings(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "wizard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv(envFnRegistry, os.Getenv(envFnRegistry))
+	os.Setenv(envFnRegistry, "team")
+
+	a := &initFnCmd{}
+	app := cli.NewApp()
+	app.Commands = []cli.Command{{
+		Name:  "init",
+		Flags: initFlags(a),
+		Action: func(c *cli.Context) error {
+			w := newWizard(strings.NewReader("go\n\nlots\n256\nregistry.example.com/team\n"), ioutil.Discard)
+			return a.askSettings(c, w)
+		},
+	}}
+	if err := app.Run([]string{"fn", "init", "--type", "async"}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Runtime != "go" || a.Type != "async" || a.Memory != 256 || a.Registry != "registry.example.com/team" {
+		t.Errorf("unexpected settings %+v", a.funcfile)
+	}
+}